@@ -117,7 +117,7 @@ func assetsStaticFurnaceMinJs() (*asset, error) {
 	return a, nil
 }
 
-var _assetsStaticIndexHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xdc\x99\x4b\x6f\xdb\x46\x10\xc7\xef\xfa\x14\xd3\xed\x21\x09\x10\x8a\xb1\x91\x36\x85\x41\xf2\xd2\x3a\x7d\x20\x68\x82\xc6\x08\xd0\x93\x31\x22\x87\xe2\xda\xcb\x5d\x76\x77\x29\x45\x29\xfa\xdd\x8b\xe5\x43\xa6\x68\x92\x92\x01\x4b\x0e\x72\xa2\xb9\x8f\x99\xff\xce\xcc\xfe\x38\x4a\x82\xef\x7e\x79\xff\xf3\xd5\xdf\x1f\x2e\x21\xb3\xb9\x88\x66\x81\x7b\x80\x40\xb9\x0c\x19\x49\x16\xcd\x66\x41\x46\x98\x44\x33\x00\x80\x20\x27\x8b\x10\x67\xa8\x0d\xd9\x90\x95\x36\xf5\x7e\x62\xdd\x29\x89\x39\x85\x6c\xc5\x69\x5d\x28\x6d\x19\xc4\x4a\x5a\x92\x36\x64\x6b\x9e\xd8\x2c\x4c\x68\xc5\x63\xf2\xaa\x97\x97\xc0\x25\xb7\x1c\x85\x67\x62\x14\x14\x9e\xcd\x5f\xb5\xa6\x4c\xac\x79\x61\xc1\xe8\x38\x64\xf3\xb9\x3f\x9f\xfb\x37\xff\x94\xa4\x37\xf3\x9c\xcb\xf9\x8d\x61\x51\xe0\xd7\x4b\x46\xd7\xa7\xa5\x96\x18\xd3\xd8\x06\xc1\xe5\x2d\x68\x12\x21\x33\x76\x23\xc8\x64\x44\x96\x41\xa6\x29\x1d\x32\x10\x1b\xc3\x0e\xde\x78\x37\xde\xec\xdb\x91\x68\x37\x05\x85\xcc\xd2\x67\xeb\xdf\xe0\x0a\xeb\x51\xd6\x55\x6e\x32\x3c\xff\xe1\xc7\x89\x53\xee\x37\xb1\x22\xcd\x53\x8e\x0b\x41\x87\x05\xab\x40\xf9\x45\xa9\xfc\xb0\xc5\xf5\x48\x6f\x6d\xe0\xd7\x35\x32\x0b\x16\x2a\xd9\xb4\x67\xce\x91\x4b\x88\x05\x1a\x13\x32\x2c\xbd\xa5\xe6\x49\x13\xc7\x6a\x3a\xe1\xab\xce\xac\xdb\xd8\x99\xed\xaf\x70\x85\x84\x5c\x92\xf6\x52\x51\xee\xd8\x19\x5a\xad\xd5\x7a\x60\x45\xb5\xca\x09\x25\xdd\x71\xdc\x0c\x6c\xff\xf2\xbc\x8c\xb4\xea\xbe\x27\xa8\x6f\x19\x68\x25\x28\x64\x0b\x94\x92\xf4\x88\xf1\xda\xc1\xd9\x3d\xe3\xd5\x83\xcb\x25\x8b\x3e\x6d\x53\x03\xef\xd4\x12\x3e\x71\x5a\x93\x0e\xfc\xec\x6c\xc2\xa0\x29\x50\xde\x37\x69\xca\x85\xe0\x92\x58\xf4\x91\x08\x16\x24\xd4\x1a\x52\xa5\x01\x63\xcb\x95\x34\xb0\x51\x25\xc4\x28\xa1\x20\x9d\x2a\x9d\x83\x92\x60\x33\x6e\xe0\xae\x36\x40\xa8\xe5\x1c\x02\xdf\x99\x1f\x89\x95\x5f\xfb\x1a\x88\xb5\x9f\xf0\x55\x2f\x59\x03\x43\x0b\x3d\x9e\xd0\x02\x97\xe4\xad\x35\x16\x85\xcb\xc6\xfe\xec\x66\xe7\xed\x4e\x43\xd5\x19\xef\x82\xfa\x2b\x59\xf8\xc8\x97\x92\x12\xb8\xd2\x44\xf0\x1b\x61\x12\xf8\xd9\xf9\x80\x95\xbe\xa2\xbe\xaa\x89\xc2\xd9\x29\x46\xe1\xe5\x89\xf7\x7a\xaa\x0c\x8a\xe8\xca\xc5\x5b\x15\xa4\xd1\xc9\x05\x93\xa9\xb5\x01\x9b\x11\x98\x5a\xab\x75\x5a\xdd\x21\xaa\xc4\xb9\x09\x97\x91\xc0\x2f\x26\xad\x8e\xce\x55\xf3\x02\x17\x24\x9c\xbd\x90\x2d\xc9\x5e\x1b\x9b\x5d\x3b\x37\xd7\x86\x7f\x21\x16\x55\xd1\x71\x7f\xc2\xf3\x77\x84\x2b\x82\x85\x40\x79\x5b\xb9\x17\x68\xc9\xd8\x17\x81\x5f\x59\xd8\xe3\x85\xcb\xa2\xb4\x9d\x92\x74\x30\xf2\xea\xc1\x9d\x37\xcf\x5b\x08\x15\xdf\xb2\x0e\xb3\x18\xf0\x64\x48\x1b\x14\x02\x63\xca\x94\x48\x48\x87\xec\x77\x69\x48\xdb\x3a\x42\xf5\xb4\xbf\x47\xd2\x42\xef\x5d\x82\x0d\xa7\xbf\x67\x1d\xe9\xb1\x45\xcf\x41\x7d\x47\x17\x8b\xde\x92\x8d\xb3\x81\xa2\xc2\x89\xdc\x8c\x25\x6e\xe0\x66\x6c\xa7\xee\x17\x95\x4a\x53\x43\xd6\x3b\x83\xe6\xfd\xcd\x64\x91\x75\x4e\x92\x70\x53\x08\xdc\x78\x79\xc2\xa2\xbf\xc8\x94\xc2\x5e\xec\xa9\x25\x4d\x3b\xc9\xd0\xd5\x26\x87\xf5\x42\xd3\x83\x8e\x32\x36\x9c\x0d\x26\x65\xea\x2a\x7f\xd0\x6a\x45\x0e\x07\x86\x1b\x4b\x32\xde\x7c\xcd\xf7\xd8\xb1\x4b\x26\x9e\x92\x62\x03\x85\x76\x2b\xec\x06\x54\x7a\x9c\xab\xdc\x89\xc9\x75\xca\xb5\xb1\x2c\x7a\xeb\x1e\xcd\x7d\xce\x4b\x63\x61\x41\xb0\xd4\x84\x96\x1c\x4e\x50\xc2\xab\x17\x17\x27\xbc\xd0\xf7\x15\xc2\x0a\x45\x49\x21\x3b\x7b\x94\xfb\x3b\x11\x10\x43\xb1\x92\x89\xfb\x16\xba\x67\x13\x12\x31\x8c\xb8\x0b\x78\xa2\x98\x34\x22\x4f\x88\xb2\x8e\xf7\x16\x69\xe8\xc2\x93\xa9\x75\xf7\x8e\xb9\xe2\x55\xe9\xb7\x0b\xb7\x6e\x12\x1e\x1f\x72\x87\x51\xa7\x21\x5d\xbb\x32\x27\x7d\x4b\xc2\x73\x1f\xb8\xa9\x10\x8c\xc6\xb6\x9a\x8d\x51\xae\xd0\x0c\x1e\x33\xe1\xb8\xd4\x98\x8f\x16\xdb\x54\xda\xfc\x46\xeb\x31\x39\xef\x5a\xb6\x4b\x69\x35\x27\xf3\x35\x13\x9e\x6a\x89\xc0\xe5\x71\xa0\xde\xd8\x6f\x81\x0e\x15\xd1\x4f\x0a\xa8\x5d\x05\x2d\xb0\x5f\x1d\x03\xd8\xad\xab\x16\xd6\x70\x29\x13\x78\x4e\x9f\x63\x51\x1a\xbe\xa2\x97\xd0\x07\x36\x0a\x71\xd2\x0f\x58\x4f\xe0\x09\x41\xdd\x78\x6e\x21\x4d\xed\xcd\xf8\x96\x88\xdc\xf1\xdd\x9c\xcf\xbb\x31\x4a\x4e\xf8\x83\x3e\xc8\xdb\x04\x1d\x00\xf1\xed\xfe\x1e\x24\x5b\x13\xfb\x00\x09\xfb\x20\xf9\xc0\x46\x78\x88\x8f\x47\xa1\xd9\xde\xe6\x9a\xcb\xea\xc2\x29\x39\x0c\xde\xad\xa1\x11\x2c\x56\xbd\x82\xeb\x72\xb7\x76\xdc\x0b\xca\xaa\x68\x37\x0f\x22\xe5\xc1\xf4\xd8\xba\xba\xae\xbc\x8f\xfc\xa4\x1d\xeb\xf7\x4e\x44\x90\x71\x91\x8f\x01\x92\x87\xe1\xb6\xaf\xa5\x52\xce\xa2\xcb\x2a\x45\xcf\xff\xf8\xf8\xfe\xcf\x43\xc3\xe2\xce\x87\x9a\xf0\x21\x91\x19\x8a\x46\xad\x00\xb4\x5a\x9b\x90\xbd\x66\x0e\x37\x26\x64\x6f\xce\x77\x7f\xf8\x3f\xfb\x97\x65\x5c\x5a\x76\x01\x2c\x56\xc5\x06\xb0\xfe\x20\x41\xaa\x55\x0e\x69\x97\x8d\xec\xbf\x67\x51\xe0\xb7\xea\x4e\x02\xea\xde\x91\xee\x75\xd4\x77\x17\xe2\x49\xfb\xe9\x23\x37\xd4\xfd\xc4\x3e\x51\x43\x0d\x7b\x9b\xea\xbd\x31\xdc\x9e\xc4\x6b\xf2\x79\xf0\x87\xa4\x1f\x83\x93\x75\xdb\xbd\xa1\x83\x5f\x03\x3f\x47\x2e\xa3\x59\xe0\x37\xff\x3e\x1f\xf8\xd5\xff\xf6\xfc\x1f\x00\x00\xff\xff\xe2\x9c\x69\x82\xfd\x19\x00\x00")
+var _assetsStaticIndexHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xdc\x59\x5f\x6f\xdb\x38\x12\x7f\xf7\xa7\x98\xe3\x3d\x34\x05\x2a\xab\x09\x7a\xd7\x43\x40\x09\x38\xec\xb6\xfb\x07\x41\x5b\x6c\x8b\x02\xfb\x14\x8c\xa5\x91\xc4\x84\x22\xb5\x24\x65\xd7\x5d\xec\x77\x5f\x50\x96\x12\xc5\x91\x64\x27\x6d\x9c\x6e\x13\x01\x32\xc9\xe1\xfc\x9f\x1f\x47\x12\xff\xd7\x8f\x6f\x7f\xf8\xf0\xfb\xbb\x57\x50\xb8\x52\xc6\x33\xee\x6f\x20\x51\xe5\x11\x23\xc5\xe2\xd9\x8c\x17\x84\x69\x3c\x03\x00\xe0\x25\x39\x84\xa4\x40\x63\xc9\x45\xac\x76\x59\xf0\x3f\xd6\x5f\x52\x58\x52\xc4\x96\x82\x56\x95\x36\x8e\x41\xa2\x95\x23\xe5\x22\xb6\x12\xa9\x2b\xa2\x94\x96\x22\xa1\xa0\x19\x3c\x03\xa1\x84\x13\x28\x03\x9b\xa0\xa4\xe8\x78\xfe\xbc\x63\x65\x13\x23\x2a\x07\xd6\x24\x11\x9b\xcf\xc3\xf9\x3c\xbc\xf8\xa3\x26\xb3\x9e\x97\x42\xcd\x2f\x2c\x8b\x79\xb8\x21\x19\xa5\xcf\x6a\xa3\x30\xa1\xb1\x0d\x52\xa8\x4b\x30\x24\x23\x66\xdd\x5a\x92\x2d\x88\x1c\x83\xc2\x50\x36\xc4\x20\xb1\x96\xed\xbd\xf1\x7a\xbe\xdd\x77\x43\x45\xb7\xae\x28\x62\x8e\x3e\xb9\xf0\x02\x97\xb8\x99\x65\x7d\xcd\x6d\x81\x27\xff\xf9\xef\x84\x95\xbb\x59\x2c\xc9\x88\x4c\xe0\x42\xd2\x04\x9b\x1e\x7d\x85\xea\xb3\xd6\xe5\x7e\xc4\x9b\x99\x2d\x5a\x1e\x6e\x72\x64\xc6\x17\x3a\x5d\x77\x36\x97\x28\x14\x24\x12\xad\x8d\x18\xd6\x41\x6e\x44\xda\xfa\xd1\x5f\x3c\x15\xcb\xde\xaa\xdf\xd8\x5b\xdd\xa6\xf0\x89\x84\x42\x91\x09\x32\x59\xdf\xe0\x33\x44\x6d\xf4\x6a\x80\xc2\x5f\x4d\x32\x93\xe9\x08\xb1\x0e\xda\x89\xab\x5f\x41\x50\x90\xd1\xfd\x71\x8a\xe6\x92\x81\xd1\x92\x22\xb6\x40\xa5\xc8\x8c\x30\xf7\x17\x2f\x8e\x6f\x31\x6f\x6e\x42\xe5\x2c\xfe\x78\x15\x1a\x38\xd3\x39\x7c\x14\xb4\x22\xc3\xc3\xe2\x78\x82\xa1\xad\x50\xdd\x66\x69\xeb\x85\x14\x8a\x58\xfc\x9e\x08\x16\x24\xf5\x0a\x32\x6d\x00\x13\x27\xb4\xb2\xb0\xd6\x35\x24\xa8\xa0\x22\x93\x69\x53\x82\x56\xe0\x0a\x61\xe1\x3a\x37\x40\xea\x7c\x0e\x3c\xf4\xec\x87\xa5\x6f\xa2\x4a\xe6\xf6\x2a\x0f\x53\xb1\x8c\x67\xbb\xa6\x16\x66\x3c\xa0\x15\xe6\x14\xac\x0c\x56\x95\x8f\xc6\xee\xe8\x16\x27\xdd\x4e\x4b\x8d\x8d\xd7\x4e\xfd\xff\x42\xd7\x6e\x63\x9e\xd4\x39\x0f\x8b\x93\x81\xfd\x0b\x73\xef\x94\xe9\x51\x25\x5a\x06\x65\x7a\x05\x7a\x43\xff\xdc\x35\xde\x6d\x37\x34\x03\x06\x22\x8d\x98\xd4\xf9\xb9\xc7\xcf\x14\x1d\x4e\xec\xf7\x17\x77\xbe\x1c\x62\x1e\x6e\xee\xe3\xb2\xc2\x86\xff\x30\xc1\x40\x44\xa6\xa6\x27\x1c\x7c\x26\x96\x04\x98\xa6\xc2\xfb\xdd\x1e\xc2\xc1\x2f\x26\x1c\xc4\xab\xf8\x83\x8f\xb5\x2d\xf4\xca\x02\x29\x67\x04\x59\x40\x0b\xae\xa0\x35\xa0\x69\x54\xa5\x14\x9c\xf6\x33\x3e\xcd\x9f\x01\xaa\xb4\x19\x58\x91\x2b\xbf\x64\x88\xc0\x9b\xe7\x37\xa1\x03\xa1\x12\x59\xa7\xe4\x49\xca\x39\x0f\xab\x49\xe1\xa3\x6b\xfe\xe2\xd8\x9e\x06\xff\x66\x9d\x49\x58\x07\x89\xc3\xc0\x1f\x1d\x6d\x22\x88\x25\x9d\x3b\x9d\xe7\xd2\x97\xaf\x43\xe3\x60\x85\x2e\x29\x84\xca\x79\x88\x13\xb2\xc3\xea\x4e\x91\x1e\xf1\xad\xce\x32\x4b\x2e\x38\x86\x76\xfc\x72\xd2\xd7\x3d\x2b\x52\x61\x2b\x89\xeb\xa0\x4c\x59\x7c\x86\x8e\xac\xbb\x76\xe4\xe9\x0e\xaf\x19\xba\x36\xdd\xba\xc2\x1f\xe1\x95\xa1\x7b\x08\x7e\x43\xab\x2e\xe6\x3b\x64\xf6\x2c\x6f\x37\x04\x17\x56\xab\x09\x6b\x6f\xab\xda\x6e\xdc\xad\xee\x44\x04\x86\x97\x46\x76\xf0\xc2\x40\x78\xb7\xea\xfc\x89\x1c\xbc\xdf\xe4\xf5\x07\x1f\x8e\x9f\x09\xd3\x6f\xa5\x46\x75\x45\x06\xbd\xba\x6d\xb5\x0e\xd5\x60\x73\x6e\xb5\x95\xfa\x85\xc5\x27\x71\x41\xd2\xf3\x8b\x58\x4e\xee\xdc\xba\xe2\xdc\x67\xe8\xb9\x15\x9f\x89\xc5\x8d\x77\xfc\x4f\x38\x3a\x23\x5c\x12\x2c\x24\xaa\x4b\x4f\x0e\xb2\x49\xe7\xa7\x3c\x6c\x38\xec\x90\x22\x54\x55\xbb\xce\x19\x58\x07\xbe\x17\x0b\x36\x93\x37\x46\x41\xb0\x90\x3a\xb9\x64\xbd\x96\x6d\x03\x00\xb7\x75\x83\x4a\x62\x42\x85\x96\x29\x99\x88\xfd\xa2\x2c\x99\xb6\xb8\xbc\xbe\x6c\x28\x25\xfa\x7f\x7c\x31\x98\x35\x77\x05\xa6\x56\x2f\x16\xbf\x26\x97\x14\x03\x49\xf5\x0f\x01\xa7\xdf\xc8\xd6\xd2\xed\x09\x49\x5d\x30\x4c\xb3\x69\xb2\xd2\xc7\x6a\xf6\xab\x95\xf2\x3b\xa3\x97\xe4\xbb\x21\x2b\xac\x23\x95\xac\xbf\xe5\x3a\xf6\xad\x9b\x4a\x03\xad\xe4\x1a\x2a\xe3\x29\xdc\x1a\x74\xf6\x30\xa5\xdc\xf3\xc9\x79\x26\x8c\x75\x2c\x7e\xed\x6f\x4d\x7d\xc0\x51\x59\x5b\x07\x0b\x82\xdc\x10\x3a\x32\xfe\x54\x57\xf0\xfc\xe9\xe9\x01\x0b\xfa\xb6\x86\xb0\x44\x59\x53\xc4\x8e\xbf\x4a\xfd\x4e\x38\xc4\x52\xa2\x55\xea\x1f\x05\xfc\xbd\x75\x89\x1c\x86\xb8\x53\x78\x24\x9f\xb4\x4a\x1e\x10\xca\x7a\xd2\x3b\x48\xf3\x7d\xa0\xcf\xe0\x7e\x8d\xf9\xe4\xd5\xd9\xf7\x0b\x6e\x3d\x53\x1f\x00\xe4\xf6\x43\x9d\x16\xe9\x3a\xca\x92\xcc\x25\xc9\xc0\x1f\x70\x53\x2e\x18\x94\xd8\xfd\xf3\x04\xd5\x12\xed\xa0\x99\xa9\xc0\xdc\x60\x39\x5a\x77\x23\xc6\xf8\x8b\x87\xad\xae\xf1\x6c\xcf\x5d\xf7\x6c\xd9\x5e\x6d\x5a\xcb\x6f\x19\xe1\xdb\xee\x17\x84\x7a\x18\x50\x6f\xf9\x77\x80\x0e\x0d\xa2\x1f\x14\xa0\x6e\x6a\xd0\x01\xf6\xf3\x87\x00\xec\x4e\x54\x07\xd6\xf0\x4a\xa5\x70\x44\x9f\x12\x59\x5b\xb1\xa4\x67\xb0\x0d\xd8\x28\xe5\xd3\xd3\x47\xf0\xc5\xe1\x81\xba\x95\xdc\x81\x74\x3b\xfc\xbe\x10\xb9\x27\xfb\x3e\x4f\xa3\xfd\x00\xed\x01\xe2\x63\x20\xd9\xb1\xd8\x05\x90\xbb\x3c\x39\xbc\x34\x36\x5d\x98\x01\x31\x0f\x83\x66\x3b\x9b\xeb\xe6\x55\x8f\x15\x5a\x0d\x03\xef\x2e\x58\x6c\x7a\x05\xdf\xe5\x5e\xf1\xf1\x03\x54\xcd\x3b\x89\xf5\x9d\x90\x72\x6f\xf4\xb8\x12\x75\xde\x48\x1f\x79\xa4\x1d\xeb\xf7\x0e\x84\x20\xe3\x4a\x7e\x0d\x20\xb9\x1b\xdc\x6e\xeb\xd2\x18\xc7\x62\x7f\xe2\xae\xe1\xe8\xd7\xf7\x6f\xdf\xec\xeb\x16\x6f\x38\x1a\xc2\xbb\x78\x66\xc8\x1b\x0d\x85\xff\x86\xb0\xb2\x11\x7b\xe1\xbf\x8b\x49\x1b\xb1\x97\x27\x37\x1f\xfc\x9f\xfc\xc9\x0a\xa1\x1c\x3b\x05\x96\xe8\x6a\x0d\xb8\x39\x90\x20\x33\xba\x84\xac\x8f\x8d\xec\xaf\x27\xfe\xed\x70\xab\xdd\x97\x3b\x6f\x0f\xa0\xde\x32\xe9\x56\x47\x7d\xb5\xfe\xb8\xfd\xf4\x03\xc1\x77\x87\xc3\x5b\x5e\x78\xac\x86\x7a\x77\x53\xbd\xd3\x87\x57\x96\x04\x6d\x3c\xf7\x3e\x48\xb6\x7d\x70\xb0\x6e\x7b\x6b\x6a\xef\x21\x0f\xfd\xa7\xc8\x78\xc6\xc3\xf6\xf3\x24\x0f\x0b\x57\xca\xf8\xef\x01\x00\xdc\x78\xb5\x0a\xfc\x1e\x00\x00")
 
 func assetsStaticIndexHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -132,7 +132,7 @@ func assetsStaticIndexHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "assets/static/index.html", size: 6653, mode: os.FileMode(420), modTime: time.Unix(1539553430, 0)}
+	info := bindataFileInfo{name: "assets/static/index.html", size: 7932, mode: os.FileMode(420), modTime: time.Unix(1792340224, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -177,7 +177,7 @@ func assetsStaticPanzoomJs() (*asset, error) {
 	return a, nil
 }
 
-var _assetsStaticRootHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x90\xcd\x4a\xc4\x40\x0c\x80\xcf\xee\x53\xc4\x9e\xb5\xb9\x4b\x76\x40\xdc\x7a\x52\x14\x5d\x04\x8f\xa9\x4d\x3b\x03\x33\xcd\x30\x93\x2d\xf8\xf6\xe2\x56\x5c\xf7\x66\x2e\x39\x24\xdf\x97\x1f\xba\xdc\x3d\xdd\xed\xdf\x9f\x3b\xf0\x96\xa2\xdb\xd0\x77\x82\xc8\xf3\xb4\x6d\x64\x6e\xdc\xe6\x82\xbc\xf0\xe0\x36\xf0\x13\x64\xc1\xa2\xb8\x37\x29\x61\x0c\xdc\x47\x81\x07\x9d\xe0\x55\xca\x22\x85\x70\x2d\x1e\x9b\x09\x4f\x20\xf5\x3a\x7c\xfe\x71\x64\xb7\xf7\xa1\x42\x3d\x52\x90\x8b\x2e\x61\x90\x0a\x3c\x43\x48\x39\x4a\x92\xd9\xd8\x82\xce\xa0\x23\x30\x9c\xcf\x6a\x09\xf3\x99\xea\x5e\x0b\x24\x2d\x02\x83\x18\x87\x58\xaf\x20\x47\xe1\x2a\xb0\x84\x1a\x0c\x88\xc1\x17\x19\xb7\x8d\x37\xcb\xf5\x06\x71\x0a\xe6\x0f\x7d\xfb\xa1\x09\x27\x5d\xf8\x80\xcb\xaf\xff\x3a\xea\x54\xb1\x8f\xda\x63\xe2\x6a\x52\xf0\xa5\xbb\xdd\x3d\x76\x6d\x1a\x1a\xf7\x5f\x9e\x90\xdd\x69\x49\xc2\xf5\x76\xc2\xf5\xc1\x5f\x01\x00\x00\xff\xff\x34\xb3\xba\x0f\x71\x01\x00\x00")
+var _assetsStaticRootHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x56\x51\x6f\xdb\x36\x10\x7e\x9e\x7f\xc5\x8d\xcb\x83\x84\xda\x52\xdb\x87\x61\x48\x29\x05\x43\x93\x01\x1d\xba\x76\x68\xd2\x01\x03\x0a\x04\x67\xe9\x2c\x31\xa3\x48\x8d\x3c\x2b\xf3\x86\xfc\xf7\x81\xb2\x1d\xcb\xae\xed\x38\x95\x04\x24\xbc\xbb\xef\xee\x78\x47\x7e\x67\xf9\xfd\xe5\xc7\xb7\x37\x7f\xfe\x7e\x05\x35\x37\x3a\x1f\xc9\xf0\x07\x34\x9a\x2a\x13\x64\x44\x3e\xfa\x4e\xd6\x84\x65\x3e\x82\xd5\x23\x1b\x62\x84\xa2\x46\xe7\x89\x33\x31\xe7\xd9\xe4\x27\xb1\xab\x36\xd8\x50\x26\x3a\x45\xf7\xad\x75\x2c\xa0\xb0\x86\xc9\x70\x26\xee\x55\xc9\x75\x56\x52\xa7\x0a\x9a\xf4\x8b\x31\x28\xa3\x58\xa1\x9e\xf8\x02\x35\x65\xaf\x92\x97\x43\x77\xac\x58\x53\xfe\x07\x39\x35\x53\x38\xd5\x04\xef\x6d\x05\xd7\xe4\x3a\x72\x32\x5d\x2a\x37\xc6\xbe\x70\xaa\x65\xf0\xae\xc8\xc4\xdd\xdf\x73\x72\x8b\xa4\x51\x26\xb9\xf3\x22\x97\xe9\x52\x39\xb0\xd6\xca\xfc\x05\x8e\x74\x26\x3c\x2f\x34\xf9\x9a\x88\x05\xd4\x8e\x66\x99\x98\xcd\x9d\xc1\x82\x7a\x78\xe1\xbd\x38\x11\xb6\x91\xec\xa2\x56\xa9\xf1\xa2\xa5\x4c\x30\xfd\xc3\xe9\x1d\x76\xb8\x94\x0e\xec\xc2\x37\x9b\x9b\x82\x95\x35\x30\xb3\xae\x41\xbe\x51\x0d\x45\x8d\x8f\xe1\xbf\x2d\xab\xf0\x39\xe2\xb9\x33\xd0\x78\xb8\x00\x43\xf7\x70\x89\xdc\x9b\x26\x6c\xdf\x5d\x7f\xbc\x66\xa7\x4c\x15\xc5\x70\x0e\x42\xbc\xd9\x02\x3f\x8c\xb6\x96\x67\xd1\x63\xcc\x68\x5f\x9c\xb3\xa4\x22\xfe\xf5\xfa\xe3\x87\x48\x68\x5b\xdd\x6a\xe5\x39\xb9\xf3\xd6\x88\xf1\x26\xd9\x28\x48\xf7\x81\xc3\xdb\xa1\x83\xa9\x2d\x17\x90\xc1\x59\x24\x7e\xd0\xb6\xf2\xc0\x41\x20\xe2\x37\xa3\x3d\xf6\xa0\x66\x4b\x87\x49\x30\x4d\x34\x99\x8a\x6b\xc8\x32\x78\x79\x28\x42\x78\x83\xc3\x04\xdb\x96\x4c\x19\x9d\x45\x42\xb2\xcb\x45\xbc\x25\x28\xa1\xb0\xda\xb7\x68\xb2\x2f\xe2\xc7\x2f\x22\xa8\x43\x2f\x22\xf1\xc1\x42\x88\x04\x35\x76\x04\x53\x22\x03\x85\x23\x64\x2a\x61\x41\x9c\x88\x38\x3e\x90\xe7\xa6\x0b\xfb\xf5\x0f\x7b\xa5\x9b\x9d\x79\xeb\x78\x50\x7c\x1c\xc3\xf4\xd8\x0e\x57\x0d\xc7\x24\x5c\x30\x90\x30\x5d\xfe\x73\x01\x93\x57\x70\x0e\xd1\x4a\x9e\x6f\xe4\x41\xfc\xf2\x40\xee\x0f\x07\xe4\x67\x09\x61\x51\x6f\xca\x3f\x6c\xb2\x1a\x87\x3a\x7d\x73\x13\x0e\xa2\xc2\xd7\x5b\x97\x3b\x2d\xc3\x7e\xcd\xec\x22\x11\xae\x98\x18\x83\x28\x91\xd1\x13\xa7\x02\x5e\x00\x99\xc2\x96\xf4\xf9\xd3\xbb\xb7\xb6\x69\xad\x21\xc3\x91\xb6\x55\xbf\xfb\x18\x5e\x80\x48\xd7\x1d\x7e\x94\xc6\xe3\x13\x93\x78\x84\x95\xb4\xbc\xa6\xa1\x43\x59\x06\x6b\x4f\x70\x01\x42\xc0\x39\xec\x98\x3c\xdf\xbf\x67\x64\x7a\x3e\x8c\x1d\xd1\xad\x57\xff\x7e\x03\x54\x23\x93\xe7\x5b\xcf\x35\x5c\x0c\x59\x66\x5b\x97\xb0\x6a\xc8\x33\x36\xed\x92\x42\x9e\x17\x67\xc7\xed\xea\x3a\xc5\xf1\x41\x1f\xf1\x33\x8e\xe9\x43\x9c\xcc\x50\xe9\x27\x68\x6b\x95\xd5\x16\xdd\x1c\x3c\x9a\xc7\xf9\xe1\xb3\xe9\x87\x0f\x5b\x98\x11\x17\x35\x70\x4d\x10\x2e\x08\xd8\x59\x38\x00\xfe\x00\x45\xec\xe6\x3e\x5c\x6f\x4f\x24\x99\x6e\xc6\xac\x0c\x77\x68\x30\x3b\x1a\x54\x06\x0a\x8d\xde\x67\x02\xe7\x93\xca\xa9\x72\x67\x64\xc8\x52\x75\x03\x8b\x80\xdf\xb1\xd8\xb5\x6a\xb1\xa2\xc9\xbd\x0b\x9b\x77\xfd\x78\x46\x65\xc8\x4d\x66\x7a\xfe\x95\xf3\xf5\x2b\xdb\xfc\xa6\x56\x1e\x7c\x3f\x7d\xa1\x75\xb6\x53\x25\x79\x40\x03\xaa\x69\x35\x35\x64\x18\xc3\x15\x08\x45\x41\xd8\x9e\xd9\x89\x4c\xdb\x83\x6e\x7f\xb1\x0e\x1a\xeb\x08\x4a\x62\x54\xda\x8f\xa1\xd5\x84\x9e\xa0\x53\x5e\x31\x48\x5c\x8d\xd7\x9a\xb9\xf5\xe7\x69\x5a\x29\xae\xe7\xd3\xa4\xb0\x4d\x5a\xd9\x0e\xe7\x69\xf7\x18\x6b\x12\xba\x91\x4e\xb5\x9d\xa6\x0d\x7a\x26\x97\x7e\xba\xfa\xf9\xf2\xb7\xab\xa4\x29\x45\x7e\x2a\x5e\xa6\x98\x2f\x13\xde\x9f\x71\xfd\x7a\x5d\x46\x4f\xfd\xf9\x9b\x84\xe6\x29\x53\x89\xfc\x7d\x0f\xaf\x5f\x1f\x29\x21\xad\x46\x8d\xf5\x61\xbe\x4c\x17\xc0\x83\xaa\xa2\x5b\x1e\xac\xa0\x21\x6d\xef\xc7\x80\xa6\xec\xa5\xa8\xbd\x05\xec\x50\xe9\xbe\xa6\xe8\x01\x37\x95\xd9\x1e\xcb\x79\x98\xd5\x21\x4a\xef\x6a\xb3\x9b\x75\x1a\xc3\x47\x72\xef\x4e\x95\xbd\x13\x2f\xd6\x3b\xeb\xc5\x07\x4e\x42\xf8\x24\x6f\xff\x2e\xdc\xf7\x84\x09\x7c\xd4\x60\xe5\x28\xff\x80\x0d\xc9\x94\xeb\xd3\xac\x2f\x37\x6c\x7b\x3a\xe8\x3a\xb0\xec\xe9\xe6\x57\x86\x9d\x22\x7f\x3a\xe0\x7d\xcf\xa9\x10\x58\x19\x42\x65\x4e\x47\xbe\x5d\x52\xe3\xd3\x00\x99\x1e\xab\xa7\x4c\x9f\xe8\x88\xec\x29\x30\x97\x29\x6f\xf3\xcb\xf0\x95\x69\xdf\xf7\xaf\x95\x32\x2d\x55\x97\x8f\x8e\x88\x64\x1a\x78\x6a\xcd\x66\xcb\x10\x32\xad\xb9\xd1\xf9\xe8\xff\x01\x00\x27\x8b\x37\xd3\x66\x0c\x00\x00")
 
 func assetsStaticRootHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -192,12 +192,12 @@ func assetsStaticRootHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "assets/static/root.html", size: 369, mode: os.FileMode(420), modTime: time.Unix(1539553430, 0)}
+	info := bindataFileInfo{name: "assets/static/root.html", size: 3174, mode: os.FileMode(420), modTime: time.Unix(1792339097, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _assetsStaticScriptJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x59\xff\x73\xd3\x38\x16\xff\xbd\x7f\xc5\x1b\xef\x1e\xb5\xb7\x4e\xea\x16\xca\x75\x13\x42\x07\xd2\x00\xdd\xeb\xd2\x1d\x28\xb7\x77\xc7\x32\x19\xc5\x7e\x89\x45\x1d\x29\x48\x72\xda\xb2\xdb\xff\xfd\x46\xf2\x97\xc8\x8e\x13\xd2\x86\x3b\xcf\xd0\x18\x3d\xe9\xf3\xbe\x3f\x3d\xc9\x6f\x2f\x4e\x07\xc3\xdf\xcf\x4e\x2f\xdf\x40\x0f\x1e\x1f\x06\xdd\x1d\x33\xf2\x66\x70\xf6\xfa\xcd\x25\xf4\xe0\x69\x31\x72\xf1\xea\xd5\xfb\x81\x1e\x39\x28\x46\x5e\x9e\xbd\xb6\x46\x8f\xba\x3b\x3b\xfd\x8b\xf3\x8b\x77\xef\xa1\x07\x7f\xee\x00\x00\x38\x21\x67\x92\x4a\x85\x2c\xbc\x75\x3a\xe0\x9c\xd3\x49\xac\xfa\x5c\x90\xc4\xf1\xb3\x09\x94\x85\x49\x2a\x29\x67\xcd\xe4\x90\x24\x61\x9a\x10\x85\x91\xa6\x9f\x12\x71\xf5\x8f\x98\x5c\xd1\x82\x3c\xa6\x42\xaa\x61\x4c\x64\xac\xc9\x2f\xce\xcf\xfa\x83\x97\xe7\x1f\x06\x05\x59\x62\xc8\x59\xb4\x9a\xae\x04\xe2\x6a\x6a\x82\x64\x3c\xa4\x6c\x96\x2a\x4d\xfe\xe1\xc9\x71\xff\xe7\x97\x81\xe3\xef\xdc\x75\x77\x76\xc6\x29\x0b\x15\xe5\x0c\x42\x81\x44\xe1\xbb\x57\xfd\xa7\x3f\x3f\x3d\xfc\x15\xc5\x55\x82\x97\x02\xf1\x1c\xc9\xf8\x95\xe0\xd3\x8b\xd1\x67\x0c\xd5\x1b\x22\x63\x57\xd1\x29\x4a\x45\xa6\x33\x1f\x78\x39\xea\xe5\x86\xda\xdf\x87\x39\x0a\x6d\x07\x08\x6e\x82\xa0\x18\xcb\x00\x35\xd8\xe5\xed\x0c\x2b\xa4\xcb\x02\x0e\x5a\x30\xa2\x93\x16\xb2\x88\x12\xe6\xc3\x31\x8c\x6e\x15\xca\x62\xda\x39\x9f\x0c\x98\x12\xb7\x66\x7d\x4b\x23\x1c\x07\x07\x05\x31\x93\x0e\xb4\x05\xa0\x03\x8f\x0f\xab\x4b\x07\x37\x0a\x99\x16\x49\x76\x0c\xe7\x9c\xf7\x9c\x08\x10\x73\xe8\x01\xc3\x6b\xf8\x40\x99\x3a\x7e\x21\x04\xb9\x75\x0f\x60\x0f\xf4\xbf\x63\xd8\x83\x43\xd8\xd3\x70\x7b\x70\xe8\x75\x35\x54\xc4\x43\x09\x61\x42\xe8\x14\x28\xa3\x0a\x23\x50\x1c\x4a\x55\xde\xa3\x82\xd2\x3a\x25\x8f\x30\x15\x97\x3a\x92\xaa\x94\x31\x17\xe0\x6a\x32\x85\x1e\xfc\xbd\x0b\x14\x9e\xf7\x20\xe8\x02\x6d\xb5\x0a\x5b\xea\x47\xcc\x3f\x16\xf2\xd0\x4f\xd0\xcb\xc1\xfe\x06\x87\x47\x4f\xbb\xe5\xa4\x82\x83\x9b\xbd\xb4\x8a\x17\x33\xcd\xf3\x60\xdf\x4c\xd7\x12\x8e\x30\x24\xa9\x44\x78\xfe\x1c\x8e\x21\xe2\x28\xd9\xae\x82\x6b\x2e\xae\x8c\x3c\x23\x3a\x01\x96\x4e\x47\x28\x24\x50\x06\xbf\x90\x39\x91\xa1\xa0\x33\x75\x72\x62\x78\xdd\xd9\x8a\xda\x0e\xd9\xa9\x89\x7a\xac\x45\x0d\x6e\x8e\x83\xee\x12\x45\xff\x66\xd4\xe0\xa0\x5b\xe0\xf5\xf9\xec\x36\x0f\x26\xe3\xc4\x65\x0b\x69\xcb\xc0\x33\x78\x7c\xd8\x05\xba\xb7\xb7\xc2\x42\x85\xc7\x8c\xa5\x16\xb1\xd9\x0e\x63\x22\xfa\x3c\xc2\x17\xca\xa5\x5e\xd7\x52\x45\xa0\x4a\x05\x83\x11\x65\x44\xdc\x1a\xef\x5f\xf2\xf7\x4a\x50\x36\x71\xc5\xdc\xeb\xee\xdc\x59\x09\x22\x50\xaa\x3e\x49\x12\x77\x46\x54\xec\x43\x44\x14\xf1\x41\xa6\x61\x88\x52\xfa\x30\x26\x34\x49\x05\x16\x82\x99\xd8\xc2\x2f\x79\x70\xfd\xeb\xd7\xf3\x37\x4a\xcd\xde\xe1\x97\x14\xa5\x72\x73\x11\x04\x7e\x69\x73\x96\x70\x12\x41\x0f\x4a\x36\x2e\xce\x95\xad\x9e\xbc\xa6\x2a\x8c\xc1\xd5\xb3\xa5\x22\x2a\x95\x36\xd5\x78\x9f\x48\x84\xc3\x20\xe8\x54\x46\x0b\x29\xf8\xe8\x33\xf4\xe0\x97\xf7\x17\x6f\xdb\x33\x22\x24\xba\x4d\xca\xd6\x32\x40\xf3\x12\x28\x67\x9c\x49\xf4\x3c\xaf\xbb\x04\x9c\xab\xed\xf2\xd1\x67\x5f\xeb\xd1\x30\x65\x24\x90\x5c\x75\x97\x05\x7d\xd2\x24\x68\x6e\x3d\xd7\x19\x91\x48\xe3\x69\x33\x39\xf7\x02\x7d\xbc\x06\x34\x65\x24\x55\x31\x17\xf4\x2b\x46\xf7\x43\x7d\xb2\x06\x95\x71\x05\x63\x9e\xb2\x4d\x21\x23\x1c\x93\x34\x51\x6b\x10\x29\x53\x28\x18\x49\x00\x85\xe0\xc2\x86\xcd\xa2\xf5\xce\x8e\x1b\x33\x67\x5d\xe0\x2c\x04\x45\x65\x32\xbc\x82\x6a\x63\xcd\x90\xb9\xce\xeb\xc1\xa5\xe3\x43\x16\xdb\x4a\xa4\x68\x05\x69\x11\x0a\xa6\xf4\xf6\xc0\x21\x3a\x48\x46\xe9\x78\x8c\xc2\x59\xcc\x92\xc8\x22\x57\x67\x45\x2d\x6f\x22\xfe\x1a\x95\xae\x14\x14\xa5\x6b\xf6\x39\x1f\x12\x22\xd5\xe0\xc6\x6c\x98\xf3\x32\x67\xca\x04\x73\x42\xb5\x3f\x3f\xd8\x9f\xa0\x6a\x61\xb6\xee\x44\x2a\x22\x54\xcf\x81\x3d\x30\x08\xb0\x07\xce\x23\x64\x91\x19\x71\x2b\x68\xd0\x82\x03\xcf\x07\x96\x26\x89\x6f\x19\x47\xa0\x4c\x93\x8a\x7d\x74\x72\x48\xad\x8e\xb3\xb0\x73\x53\xc9\xc9\x56\xb6\x73\x49\xda\x09\xb2\x89\x8a\x97\xaa\x90\x7e\x24\xec\xf5\x80\x28\x3e\x72\xab\x6b\x3e\xd2\x4f\x6d\xbc\x51\x82\x0c\x8d\x79\xb4\xf0\x7f\x30\xa7\xee\x5d\xfd\xfc\xe8\x3a\x3f\x4c\x50\x0d\xf3\x85\xc3\x0c\xc7\xf1\xda\x0a\x6f\x94\x2b\xad\x88\xd8\xff\x09\x22\xae\x8b\x77\x24\xc8\x35\xd0\x31\x4c\xb9\x40\x50\x31\x61\x70\x14\xf8\x20\x29\x0b\x11\xc8\x88\xa7\x0a\x54\x8c\x02\x21\xa6\x4a\x02\xd1\x7d\x51\x10\xc0\x8c\xde\x60\x02\x09\x9d\x52\x65\x74\xbe\xa6\x91\x8a\x75\xc9\xef\xc7\x82\x4f\x11\x7e\xda\x2f\x19\xd1\x31\xb8\x6e\xa3\x0d\xe0\x59\x0f\x8e\x02\x0f\x1e\x3d\x82\xcc\xaf\xd0\xeb\x41\xe0\xd5\xad\x72\x2a\xc8\xb5\xee\x24\xdc\xba\x72\x11\x25\x13\x41\xa6\x8e\xe7\x43\x53\x58\xf8\xa0\xfb\x16\xd3\xb6\x0c\x1b\x8c\xea\x2d\xa7\x47\xd5\xdf\x44\x72\x66\xcb\xb2\xde\xb6\x8e\xc9\x8e\x0e\xe8\x80\xca\xd7\xe6\x89\x92\x85\xf3\xfe\x3e\x32\x32\x4a\x50\xc2\x8c\x30\x46\xd9\x04\x08\x8b\x60\xca\xf5\x66\xfa\x95\xf3\xa9\x1e\x19\x23\x51\xa9\xc0\x45\xe8\x67\x4b\xfe\xc3\xf9\xf4\x37\xc2\xdc\x19\x61\x7a\x66\x21\x53\xfe\x5f\x5d\x95\x91\x29\xd7\x6b\x73\xe6\xee\x1a\xc0\xeb\x18\x31\x69\x8f\x79\x48\x92\xdd\x85\x4e\x2e\x20\xd8\xfa\x60\x7b\x26\x70\x8e\x4c\x9d\x66\x85\xc5\xb5\x0c\xa2\x43\x38\xc2\x44\x11\xe8\x01\xb6\xb3\xb7\xbf\xfe\x02\x6c\x73\x41\x27\x94\x91\x64\xa0\x17\xb6\x0d\xa3\x53\x4d\xad\x2e\xd5\x72\x5d\xa4\x0a\x7a\x39\xc8\x49\xfe\xfb\x0c\x02\xe8\x2c\xa1\x18\xda\xbf\xe1\x39\x04\x0b\x94\x85\x72\xe6\xd7\xdd\xd5\x7f\x77\xfd\x02\xd9\xaf\xc4\x08\x65\xa1\xc0\x29\x32\xd5\x81\xa0\x7d\x70\xe4\x5b\x24\xc2\xe8\x94\x28\xec\xc0\x98\x24\x12\x6d\x8a\x31\x4f\x07\x70\x11\x04\xa5\xfe\xb9\xcb\x7e\x74\x17\xe1\x50\x18\xae\x08\x02\xa9\x62\xc7\x6b\x87\x09\x0d\xaf\xac\x59\xb8\xa9\x7d\x9b\x4a\x95\x54\xf1\x89\xe9\xc3\x25\xfd\x8a\xa6\x30\xbd\x35\xad\x94\x6b\xf1\x1c\x96\x13\x1c\xaf\x3d\x27\x89\xeb\x6d\x54\xaa\x56\x95\xab\xb2\xe8\x98\x03\x00\x68\xdc\x22\x82\x4d\xaa\x94\xdc\x96\x4a\xce\x62\xa5\xe0\x3c\xeb\xb9\x2a\x2b\x65\x4c\x0e\x8f\x9e\x0e\x35\xd1\x9c\x2b\x1a\x01\x6c\xc5\x56\x57\xaa\x6f\xa4\xe5\x7a\xa0\x95\x69\x59\xf5\x78\xd9\xc9\x99\x30\x59\x24\xae\x0d\x6e\x9f\xe0\xfe\xdf\xae\xb7\x78\x0f\x4d\xad\x5b\xe3\x7e\x43\xaf\x5b\x68\x6b\xae\xd9\x09\x72\x0d\xdb\x6c\x42\x9d\xef\x1a\xde\x2d\x0b\xfe\xc4\x08\x6d\xcb\x60\x06\x16\x01\x68\x36\xbd\x47\x19\x0f\x7b\x5a\x36\x62\xcd\xdb\x34\x1d\xbe\x95\x16\xd5\x20\xcf\xb6\xa7\x5a\x92\xd4\x44\x6c\x0c\xf1\x26\x98\x5a\xc6\x64\x30\x1b\x25\x4c\x15\xed\x1b\xe4\xcc\x34\x75\xa1\xeb\x06\xdb\x80\x4f\x0e\x54\x13\x3b\x07\xfa\xfe\x72\x5b\x61\x01\x24\x8d\xa8\x32\x4d\x65\xe7\x0f\xb6\x72\xdd\x9a\xae\xcb\x02\x5b\xd7\x79\x2d\x49\xb1\xbc\xfc\x23\xfd\xb4\x56\xb7\xbb\xc6\xd1\xa6\x54\x5a\x5d\xea\xec\x47\xb7\x3d\xfd\xc5\xaa\xdf\x04\xe7\xe3\xc6\xcc\xac\xb7\x41\x0b\xdf\xfa\x59\x33\xd9\x1c\x60\x9e\xbf\x14\x0b\xf9\xfc\x15\x9e\xf5\x7c\x30\x8d\x7b\xa5\x99\xb2\x04\x69\x3a\xe7\x6d\x50\xbb\x37\xb7\xd4\xda\x5a\x5e\x72\xac\x8d\x6d\x28\xc1\x77\xe0\x7e\xf7\xb0\x3d\xeb\x81\x1c\xef\xb7\x77\xe5\x3d\xeb\x36\xfb\x96\xce\x2f\xdd\x5f\x43\xaf\xbe\x4d\x14\x0d\x71\x6d\x8b\xe8\x56\x8e\x00\xd9\x52\xdd\xdf\x6f\xb6\x33\x39\xf7\x29\xe4\x95\x23\xe2\x0a\xe9\xea\xdb\x66\xbd\xc5\xd9\x2e\x6e\xee\x79\x28\x68\x8c\x19\xc0\x44\x62\x8d\xc7\x43\x34\xd3\xa6\x5e\x3e\xdc\x58\x11\x51\x5e\x35\x0f\x67\xba\xaa\x6c\x1b\x15\x9f\x25\x67\xff\x24\x49\xaa\xcf\xf6\x0d\xf0\xf9\xcd\x71\x2e\x5f\x75\x29\x91\x17\xf5\xeb\xa5\x12\xad\x36\x75\x71\x1d\x57\xb9\x9b\xfb\x9d\xaa\xf8\x1d\x46\x24\x3b\xe2\x34\xe2\xf8\xb0\xbb\xbb\x4d\x03\x56\xd7\x67\x93\x1e\x5c\xaa\x78\x93\x40\xcf\xf4\xd0\xd5\xf5\x44\xff\x31\x9c\x91\x85\x3c\xc2\x0f\xef\xce\xfa\x7c\x3a\xe3\x4c\x9f\xed\x46\x8a\x13\xd7\xba\x2b\x6f\x62\x18\x36\x66\x86\xb6\xdc\x54\x25\x67\xda\x05\xd0\xbb\xc7\x25\xbd\x0c\x55\xbb\xf9\xa2\x7e\xb9\xe6\x9a\xd2\x80\x64\x9c\x3b\x47\xbf\x2e\xb0\x0d\x5a\x21\x42\xc3\xe2\x26\xb3\x18\x3b\xb7\x46\xb7\xad\xcd\x2c\x53\xf0\xf6\xb2\x2e\xb1\xea\x48\xa9\x62\x7b\x87\xab\xdb\xad\x74\xae\xd9\x60\xb7\xeb\x12\x17\xdf\x68\xec\xbb\xe8\x2c\xfd\xeb\x2e\xdc\xa0\x4f\x12\xa8\xf3\x7b\x8e\x11\xc8\xd0\xfa\x2e\x90\xb7\x5f\xb6\x7f\x36\x00\xb3\x64\x9b\x1a\xdf\x64\x8d\xa1\x36\x5d\x5d\xca\xd2\x9c\xdb\xf7\x72\x06\x9e\xb2\x08\x6f\x32\xfc\xaa\xb5\xdb\xf9\x87\xa5\x08\x6f\x36\x60\x55\x6f\x64\x6d\xbf\x6e\x2f\x69\x29\xd9\x16\x3d\x67\x4d\x3b\x03\x34\xd4\x40\xf7\x6a\x3d\x57\xa2\x3c\xbc\x03\xad\x57\xb0\xcd\x3b\xd0\xb3\x8a\x30\x8d\xe5\xd0\xea\x3f\x57\xba\xd7\x2f\xeb\x83\x5f\x4f\xc8\xac\xe5\x54\xf1\x37\xfa\xcd\x95\x56\xf9\x0e\x6d\xe7\x7a\xf3\xfc\xaf\xdb\xce\x2d\xb9\x3f\xa0\xed\xdc\x82\xe3\x9d\x75\xab\x69\x5e\xaa\x57\x94\x6b\xcf\x27\xe5\x65\xde\x9f\x21\x67\x8a\x50\xd6\x81\x5d\xca\xe6\x28\xd4\xee\x9d\x97\x7d\x1e\xbd\x42\x9c\x49\x08\x09\x9b\x13\x09\x94\x49\x1a\x21\x64\xf7\x9b\x10\xd1\xf9\x0a\x86\x2b\x03\x72\x7b\x86\x5a\xcd\xff\x06\x00\x00\xff\xff\x49\xda\x90\xa4\x17\x20\x00\x00")
+var _assetsStaticScriptJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x5a\xff\x73\xdb\xb6\x92\xff\x5d\x7f\xc5\x0e\x5f\x2f\x26\x9f\x69\x59\x71\x9a\x9c\x9f\x15\xc5\xd3\xda\x4e\xe2\x9e\x13\x77\x62\xe7\x7a\x77\x6d\x47\x03\x91\x2b\x11\x31\x05\x28\x00\x28\x5b\x6d\xfd\xbf\xdf\x2c\xf8\x45\x20\x45\xc9\x72\xdc\x79\x96\x5b\x2b\xc0\xe2\xb3\x8b\xfd\x0e\x90\x1f\x2f\x4f\xcf\x86\xbf\x9c\x9f\x5e\xbf\x87\x01\xbc\x38\xe8\xf5\x3b\x76\xe4\xfd\xd9\xf9\xbb\xf7\xd7\x30\x80\x57\xe5\xc8\xe5\xdb\xb7\x57\x67\x34\xf2\xbc\x1c\xf9\xf1\xfc\x9d\x33\xfa\xb2\xdf\xe9\x9c\x5c\x5e\x5c\x7e\xba\x82\x01\xfc\xd9\x01\x00\xf0\x22\x29\x34\xd7\x06\x45\xb4\xf0\x8e\xc0\xbb\xe0\x93\xc4\x9c\x48\xc5\x52\x2f\xcc\x09\xb8\x88\xd2\x4c\x73\x29\xda\xa7\x23\x96\x46\x59\xca\x0c\xc6\x34\x7f\xca\xd4\xcd\x7f\x25\xec\x86\x97\xd3\x63\xae\xb4\x19\x26\x4c\x27\x34\xfd\xc3\xc5\xf9\xc9\xd9\x8f\x17\x9f\xcf\xca\x69\x8d\x91\x14\xf1\xfa\x79\xa3\x10\xd7\xcf\xa6\xc8\xc6\x43\x2e\x66\x99\x21\xf0\x7f\x7c\x7f\x78\xf2\xaf\x1f\x7b\x5e\xd8\xb9\xef\x77\x3a\xe3\x4c\x44\x86\x4b\x01\x91\x42\x66\xf0\xd3\xdb\x93\x57\xff\x7a\x75\xf0\x01\xd5\x4d\x8a\xd7\x0a\xf1\x02\xd9\xf8\xad\x92\xd3\xcb\xd1\x17\x8c\xcc\x7b\xa6\x13\xdf\xf0\x29\x6a\xc3\xa6\xb3\x10\x64\x35\x1a\x14\x8a\xda\xdf\x87\x39\x2a\xd2\x03\xf4\xee\x7a\xbd\x72\x2c\x07\x24\xb0\xeb\xc5\x0c\x6b\x53\xd7\x25\x1c\xec\xc1\x88\x4f\xf6\x50\xc4\x9c\x89\x10\x0e\x61\xb4\x30\xa8\x4b\xb2\x0b\x39\x39\x13\x46\x2d\xec\xfa\x3d\x42\x38\xec\x3d\x2f\x27\x73\xe9\x80\x34\x00\x47\xf0\xe2\xa0\xbe\xf4\xec\xce\xa0\x20\x91\xf4\x11\xad\xeb\x15\xbc\xe7\x4c\x81\x9a\xc3\x00\x04\xde\xc2\x67\x2e\xcc\xe1\x0f\x4a\xb1\x85\xff\x1c\x76\x81\xfe\x3b\x84\x5d\x38\x80\x5d\x82\xdb\x85\x83\xa0\x4f\x50\xb1\x8c\x34\x44\x29\xe3\x53\xe0\x82\x1b\x8c\xc1\x48\xa8\xb6\x72\x85\x06\x2a\xed\x54\x3c\xa2\x4c\x5d\x93\x27\xd5\x67\xc6\x52\x81\x4f\x22\x70\x18\xc0\x7f\xf6\x81\xc3\x9b\x01\xf4\xfa\xc0\xf7\xf6\x4a\x5d\xd2\x47\xcd\x7f\x2d\xe5\xe1\xbf\xc3\xa0\x00\xfb\x0f\x38\x78\xf9\xaa\x5f\x11\x95\x1c\xfc\xfc\xcb\x5e\xf9\xc5\x92\x05\x01\xec\xd3\x5f\x2b\xff\x08\x23\x96\x69\x84\x37\x6f\xe0\x10\x62\x89\x5a\xec\x18\xb8\x95\xea\xc6\xca\x33\xe2\x13\x10\xd9\x74\x84\x4a\x03\x17\xf0\x13\x9b\x33\x1d\x29\x3e\x33\xc7\xc7\x96\xd7\xbd\xbb\x51\xd7\x20\x9d\x86\xa8\x87\x24\x6a\xef\xee\xb0\xd7\x5f\x99\xa1\xbf\xf9\x6c\xef\x79\xbf\xc4\x3b\x91\xb3\x45\xe1\x4c\xd6\x88\xab\x1a\x22\xcd\xc0\x6b\x78\x71\xd0\x07\xbe\xbb\xbb\x46\x43\xa5\xc5\xac\xa6\x96\xbe\xd9\x8d\x12\xa6\x4e\x64\x8c\x3f\x18\x9f\x07\x7d\x67\x2b\x0a\x4d\xa6\x04\x8c\xb8\x60\x6a\x61\xad\x7f\x2d\xaf\x8c\xe2\x62\xe2\xab\x79\xd0\xef\xdc\x3b\x01\xa2\x50\x9b\x13\x96\xa6\xfe\x8c\x99\x24\x84\x98\x19\x16\x82\xce\xa2\x08\xb5\x0e\x61\xcc\x78\x9a\x29\x2c\x05\x23\xb1\x15\x7e\x2d\x9c\xeb\x7f\x3e\x5c\xbc\x37\x66\xf6\x09\xbf\x66\xa8\x8d\x5f\x88\xa0\xf0\x6b\x57\x8a\x54\xb2\x18\x06\x50\xb1\xf1\x71\x6e\xdc\xed\xe9\x5b\x6e\xa2\x04\x7c\xa2\xd6\x86\x99\x4c\xbb\xb3\xf4\x89\x98\x46\x38\xe8\xf5\x8e\x6a\xa3\xa5\x14\x72\xf4\x05\x06\xf0\xd3\xd5\xe5\xc7\xee\x8c\x29\x8d\x7e\xdb\x66\x1b\x11\x40\xbc\x14\xea\x99\x14\x1a\x83\xa0\x10\xd7\xfd\x14\xdb\xf6\xe5\xe8\x4b\x08\x0a\xbf\xb6\x90\x8c\x14\xb2\x9b\xfa\xb0\x15\xf4\xfb\x36\x41\x0b\xed\xf9\xde\x88\xc5\x84\x47\x6a\xf2\x1e\x05\xfa\x62\x03\x68\x26\x58\x66\x12\xa9\xf8\x1f\x18\x3f\x0e\xf5\xfb\x0d\xa8\x42\x1a\x18\xcb\x4c\x6c\x0b\x19\xe3\x98\x65\xa9\xd9\x80\xc8\x85\x41\x25\x58\x0a\xa8\x94\x54\x2e\x6c\xee\xad\xf7\xae\xdf\x58\x9a\x4d\x8e\xb3\x14\x14\x8d\x8d\xf0\x1a\xaa\x8b\x35\x43\xe1\x7b\xef\xce\xae\xbd\x10\x72\xdf\x36\x2a\xc3\x82\xce\x75\x05\x8a\x74\x18\x80\xc7\xc8\x49\x46\xd9\x78\x8c\xca\x5b\x52\x69\x14\xb1\x4f\x51\xd1\x88\x9b\x58\xbe\x43\x43\xa9\x9b\xa3\xf6\x6d\x9d\x0b\x21\x65\xda\x9c\xdd\xd9\x82\x39\xaf\x62\xa6\x0a\x30\x2f\x32\xfb\xf3\xe7\xfb\x13\x34\x7b\x98\xaf\x3b\xd6\x86\x29\x33\xf0\x60\x17\x2c\x02\xec\x82\xf7\x0c\x45\x6c\x47\xfc\x1a\x1a\xec\xc1\xf3\x20\x04\x91\xa5\x69\xe8\x28\x47\xa1\xce\xd2\x9a\x7e\x28\x38\x34\x6d\xa7\xd8\xc3\xba\x94\x93\xaf\xec\x16\x92\x74\x53\x14\x13\x93\xac\x64\x21\xfa\xd5\xb0\x3b\x00\x66\xe4\xc8\xaf\xaf\xf9\x95\xff\xde\xc5\x3b\xa3\xd8\xd0\xaa\x87\x84\xff\x4d\x38\x5c\xef\xab\x6f\xdf\xf9\xde\x3f\x26\x68\x86\xc5\xc2\x61\x8e\xe3\x05\x5d\x83\x77\xc6\xd7\x85\x4d\xe8\x77\xff\x9f\x10\x4b\x4a\xde\xb1\x62\xb7\xc0\xc7\x30\x95\x0a\xc1\x24\x4c\xc0\xcb\x5e\x08\x9a\x8b\x08\x81\x8d\x64\x66\xc0\x24\xa8\x10\x12\x6e\x34\x30\xea\x8b\x7a\x3d\x98\xf1\x3b\x4c\x21\xe5\x53\x4e\x3e\xac\xe0\x96\xc7\x26\xa1\x94\x7f\x92\x28\x39\x45\xf8\xe7\x7e\xc5\x88\x8f\xc1\xf7\x5b\x75\x00\xaf\x07\xf0\xb2\x17\xc0\xb3\x67\x90\xdb\x15\x06\x03\xe8\x05\x4d\xad\x9c\x2a\x76\x4b\x9d\x84\xdf\xdc\x5c\xcc\xd9\x44\xb1\xa9\x17\x84\xd0\xe6\x16\x21\x50\xdf\x62\xdb\x96\x61\x8b\x52\x83\xd5\xf0\xa8\xdb\x9b\x69\x29\x5c\x59\x36\xeb\xd6\xb3\xd1\x71\x04\xe4\x50\xc5\xda\x22\x50\x72\x77\xde\xdf\x47\xc1\x46\x29\x6a\x98\x31\x21\xb8\x98\x00\x13\x31\x4c\x25\x15\xd3\x3f\xa4\x9c\xd2\xc8\x18\x99\xc9\x14\x2e\x5d\x3f\x5f\xf2\x7f\x52\x4e\x7f\x66\xc2\x9f\x31\x41\x94\xa5\x4c\xc5\x3f\x29\x2b\xa3\x30\x7e\xd0\x95\xc2\xdf\xb1\x80\xb7\x09\x62\xda\x1d\xcb\x88\xa5\x3b\xcb\x3d\xf9\x80\xe0\xee\x07\xbb\x33\x85\x73\x14\xe6\x34\x4f\x2c\x65\x59\x29\x7d\x3b\xc6\xd4\x30\x18\x00\x76\xf3\x6f\x7f\xfd\x05\xd8\x95\x8a\x4f\xb8\x60\xe9\x19\x2d\xec\x5a\x46\xa7\x34\x5b\x5f\x4a\x62\x5e\x66\x06\x06\x05\xc8\x71\xf1\xf7\x35\xf4\xe0\x68\x05\xc5\xce\xfd\x2f\xbc\x81\xa2\xda\xd7\x37\x67\xff\xfa\x3b\xf4\xff\x9d\xb0\x44\x0e\x9d\x7d\x00\x70\x11\x29\x9c\xa2\x30\x47\xd0\xeb\x3e\x7f\x19\x3a\x53\x4c\xf0\x29\x33\x78\x04\x63\x96\x6a\x74\x67\xac\x7a\x8e\x00\xab\xa1\xfb\x6a\xff\xf7\x8d\x0c\xa4\x13\x79\xfb\x01\x0d\xa3\xe8\xf3\xd7\x65\x9b\x69\x41\xe0\xad\xe6\x8e\x69\xec\xea\x9d\x14\x34\x92\xf1\x02\x06\xd6\xa5\x52\x39\x19\x96\x6b\xc1\xd0\x84\x9b\xb8\x89\x98\xc5\xf1\x27\x79\x5b\x4b\xd5\x29\x1b\x61\x1a\xc2\x9c\xa5\x59\x95\xfe\xca\x0f\x05\x5c\xeb\x04\xfd\x12\x7e\x97\xcd\x66\x94\x6b\xbf\xf3\xbd\xd7\x46\xbd\xf1\x82\xda\x40\xf2\xa6\x74\x69\xcb\x24\x08\x49\xca\xd7\x26\x76\xe8\x72\xf4\x66\x5d\x5f\x66\xa1\xfb\xba\xfc\x29\x17\x37\x35\xe9\x33\x95\x36\x65\x2b\x1a\xa9\x4c\xa5\x70\x6c\x19\x32\xcb\xcf\x18\xe5\x7b\x89\xc2\xb1\x17\x02\xad\xca\x63\x8d\xbe\xc1\x91\x55\x73\xbf\x8d\x69\xae\x30\xdf\x3b\xc5\xbc\xfb\xa4\x43\x55\x08\xd3\xb8\x1b\x2f\x07\x82\x55\xf2\xcb\x19\x2a\x66\xa4\xca\x69\x65\xf1\xaf\x16\xc2\x2b\xc3\x0c\xe6\x54\xd4\x51\xe1\x3a\x12\x88\x12\x26\x26\x18\x3b\xa4\xc3\xaa\x89\x87\x63\xdb\xda\x9d\x32\x83\xfe\xea\x6c\xd0\x35\xf2\xfc\xea\xb2\x68\xaf\xca\xdd\xb6\x30\x7a\x4b\xd1\x08\x74\x7e\x83\x04\x59\xc1\x6a\x4c\x83\x43\x6d\x12\x38\xce\xcf\x76\xa0\xf9\x1f\x68\x73\x93\x3b\xdb\xa5\xa9\xa1\x9d\xda\x05\x2f\x04\x25\x65\x71\x06\x5a\xa1\xd4\x09\x3b\x78\xf9\x6a\x48\x14\xc3\xe2\x94\xb4\x46\xa0\x53\x66\x98\x46\xe3\x85\xd6\xee\xb4\x35\xf2\x6c\x8d\x66\x48\x66\x6b\x59\x70\x21\x27\xf0\xf9\xd3\x45\x2e\x79\xa6\xda\x30\x3f\xb0\x3b\x3e\xcd\xa6\x30\x45\x35\x41\xca\x25\x6c\x91\x93\x4f\xf3\x89\xa1\x9d\x18\xda\x09\xaa\x8e\x90\x9f\x77\xb5\xd7\x82\x75\x62\xcf\xa9\x85\xa2\xf2\x43\x6b\xdc\xb0\x45\x31\xba\xb5\x0d\x7e\xce\x46\x29\x8f\xe0\x06\x0b\xa9\x6e\x70\x51\x50\x6d\x51\x52\xda\xe2\x7f\x73\x84\xc6\x6f\x36\x14\x9d\x52\xc3\x45\x0e\xdb\xdf\x87\x94\xcf\xf1\xca\x28\x64\x53\xe0\x9a\x8a\x39\xd8\xe4\x7d\x25\x33\x15\xa1\xad\xde\x44\x41\x9b\xe1\x24\xa7\x0e\xa9\x1b\xb8\x45\x60\x0a\xe1\x96\x99\x28\xe1\x62\xd2\xa1\x3c\xe4\x00\x0d\x8a\xe0\xeb\xec\xef\x83\x91\x93\x49\x8a\x17\x84\x61\xbb\x2c\x0d\x52\x81\x36\x72\xa6\x6d\xe2\xa4\xc2\x56\x94\x4c\x60\x96\xff\xc2\x42\xb3\x38\xc6\xb8\x0b\xd7\x09\xc2\x48\xc9\x5b\x8d\x0a\x14\x59\x4d\x60\x64\x2c\x19\xe8\x42\x68\xa3\x31\x1d\x87\xc4\x8a\x4a\xae\xad\x94\x6c\x6c\x50\x59\x22\x2a\xf9\x16\x7f\x01\xdc\x10\x02\xf2\x39\xc6\xdd\x65\xf2\x5e\x8a\x57\xa5\x6e\xca\x90\xcb\xcd\xb8\x06\x59\x8e\x76\xa3\x54\x6a\x74\x6b\xe2\x72\xae\xda\x7e\x39\x65\xed\xc8\xe7\x38\xcc\x99\x55\xe6\xb9\x22\x7d\x54\x4a\x74\xbd\x31\xcf\x77\x85\xa9\x3a\xab\xf0\x78\xeb\x5a\xc9\xf7\xd8\x8c\x53\x91\xc9\x55\x52\x02\x2d\x97\x74\x59\x1c\x5b\xfa\x0b\x7b\xc7\x84\xca\xf7\xb4\x49\x3c\xd7\xfb\x6a\xe5\x80\xcc\x49\xe9\xa1\x76\xb0\xc3\x6e\xd1\x7d\x6f\xea\x70\x6d\x8f\xba\xcc\x29\xb9\xef\xad\xe4\x92\x5a\x7b\x9a\x2f\xa9\x92\xcb\x72\xc9\x32\x9b\xb4\x2f\xd1\x7c\x22\x30\xce\xe9\xab\xf8\xa4\x85\x6b\xd3\x64\x13\xa7\x32\x0c\x69\xa3\xd1\x05\xdf\x6f\xa3\x45\xeb\x59\x9b\xf5\x68\x49\x1e\xd0\x64\x25\x47\x11\x09\x5e\x40\xcd\x97\x0d\xe9\x58\x46\x19\xf5\x2e\x45\xd6\xb9\xc6\x3b\xf3\x51\xc6\xe8\x13\xe5\xa2\xcb\x45\x8c\x77\x94\xd2\x72\x2d\x58\x6b\x69\x9b\x90\xf8\x78\x51\xd0\xb8\x87\x82\x60\xfd\xe6\xda\x8e\x7c\xee\x5e\xea\x51\xd1\x55\xc8\xe2\x45\x5e\xc6\x06\x03\xd7\x19\xbb\x27\x17\x97\x57\x67\xa7\xee\xd2\x75\xaa\x2e\xf3\x53\x11\xc9\x36\xa4\x62\x6f\xb5\xf1\xee\x77\x36\x47\x91\x9c\xd5\x82\xe8\xbe\xd3\xf9\xce\x5f\xdd\x44\xbd\x4d\xeb\x77\xd6\x80\x46\x29\x8f\x6e\xfc\x35\x06\xdd\xd4\x14\xbb\x99\x64\xa9\xe5\x8a\x0b\x9d\x0d\x72\x37\xfb\x66\x0e\x6d\x27\x58\x6d\x92\xe3\x2a\xb6\xec\x79\xf5\xa3\xbd\x61\xab\x8e\x43\xda\x24\xc3\x8a\xc0\x0b\xba\x73\x96\xfa\xc1\x56\x27\xd8\x75\x31\xbe\x36\xce\x8b\x13\x54\xc5\x6d\x25\x6e\xd7\x86\x7b\xb1\x72\xa5\x8d\x68\x03\x70\x37\xb6\xfe\x00\xfb\x40\x69\xdd\x0c\xd4\x52\x38\x1d\xe4\x9a\x45\x28\x4f\xe7\xa7\x87\x7a\x64\x95\xe0\xee\xc5\xfe\xbf\xdb\xf4\x0e\xef\xa1\x3d\x02\x6f\x30\xbf\x9d\x6f\x6a\xe8\xc9\x5c\xf3\x46\x6b\x03\xdb\x9c\xa0\xc9\x77\x03\xef\x3d\x07\xfe\xd8\x0a\xed\xca\x60\x07\x96\xb5\xc6\xa6\xbd\x67\x39\x0f\x97\x2c\x1f\x71\xe8\xb6\x0d\x87\x87\xc2\xa2\xfc\xc9\x9d\xdc\x4a\x03\x8d\x20\x69\x88\xd8\x1a\x23\x6d\x30\x8d\x88\xb1\x83\xdb\x05\x4c\xf9\xc9\xd1\x1e\x98\xce\x55\xd3\x14\xba\xa9\x30\xd8\xdd\x16\xa8\x21\x76\x01\xf4\xf7\xcb\xed\xb8\x05\xb0\x2c\xe6\xc6\xde\x35\x1e\xfd\x26\xd6\xae\xdb\x70\x19\xe7\x80\x6d\xba\x90\x73\x7f\xac\x14\xab\xcb\x7f\xe5\xbf\x6f\xdc\xdb\x7d\xa7\x31\x50\x4b\x71\x0e\xd0\x86\x54\xe7\x7e\xe8\x36\xec\x64\xb9\xea\x67\x25\xe5\xb8\x35\x32\x9b\xb7\x63\x4b\xdb\x86\xf9\x1d\x63\xbb\x83\x05\xe1\x8a\x2f\x14\xf4\x6b\x2c\x1b\x84\x60\xef\x73\x6b\x77\x6c\x8e\x20\x65\x47\xe2\xfe\x6c\x91\xbb\xb7\xd7\xd4\xc6\x5c\x5e\x7e\xca\xac\xfd\x48\x09\xfe\x06\xee\x2e\xe7\x2d\xb8\x3e\x91\xe3\xe3\x6a\xd7\xb2\x1b\xfd\xe6\xba\x45\x89\xd2\x9e\xc1\x06\x65\xee\x6d\x80\x37\x4b\xc4\x72\xa9\x6d\x38\x59\x79\xed\xbb\x5d\x65\xf2\x1e\x93\xc8\x6b\x4f\x0e\xd6\x48\xd7\x2c\x9b\x85\x03\x57\xce\xff\x34\xbf\x29\xb9\x3c\xc5\x67\x00\x53\x8d\x0d\x1e\xdf\xb2\x33\xb2\x92\x8b\xbb\xe2\x11\xd5\x1b\x08\xc3\x19\x65\x95\xa7\x7a\xc5\x17\x2d\xc5\x7f\xd3\x4d\x21\x0c\xda\xe0\x8b\x17\x0a\x0a\xf9\xea\x4b\x99\xbe\x6c\x3e\x75\xac\xd0\x1a\x5c\x96\x4f\x69\x6b\x8f\x6c\x7f\xe1\x26\xf9\x84\x31\xb3\x82\xfb\xad\x38\x21\xec\xec\x38\x60\x8f\x6e\x85\x9a\xfb\xa9\x08\x1d\x9d\x37\x7d\x55\x9b\x64\x1b\x47\xb7\x80\x7b\xa3\xc5\x5e\xbe\x3b\xca\xcb\xc7\xf4\x3f\xdb\xe2\xa0\x88\x64\x8c\x9f\x3f\x9d\x9f\xc8\xe9\x4c\x0a\xba\xfb\x1f\x19\xc9\xfc\xe5\xe6\x83\xbc\x2d\xaa\x4b\x5e\x3b\xa3\xaf\x4a\x56\xed\xc6\x56\x94\xa6\x90\xa5\xb6\xa7\x26\x3d\x27\xb3\xc1\xe0\x11\xef\x7b\xd4\xa1\xbb\xed\xaf\x7f\xf4\x3b\x0d\x76\xd6\xba\xf4\x04\xa7\xb0\x2d\x7d\x5d\xb2\xa1\x41\xbf\x94\x66\xcd\xe2\x75\xfd\x5b\xd1\x4f\x54\x2f\xd4\x14\x62\x38\x4d\x4c\x53\x9f\x6b\x2b\x7c\x71\xd6\x41\xa3\x38\xce\x31\x06\x1d\x39\x2f\x70\xe4\x01\xbe\x6e\xf7\x0f\x60\x2e\xdf\xf7\xa1\x5b\xcf\x9b\x14\xf3\x8e\x8d\xb4\xd0\x14\xb4\x54\xd2\x43\x62\x6e\x98\x22\x08\xb0\x77\x0d\xad\x42\x17\x2f\x00\x15\x57\x11\x1b\x70\x1e\x75\x2f\xb4\xb5\x74\x95\x34\x5b\x34\x7e\x6d\x4d\x5f\x63\x37\x16\x64\x48\xdd\xe3\x36\xbd\x9f\x95\x6e\x2d\xc2\xa6\xf6\x6f\xb5\xf5\x6b\x4b\x1b\x0f\xb7\x7d\xd4\xf2\x9d\xd7\x04\x68\xcd\x3f\x4e\xc3\xb7\xd6\x7c\xf9\x33\x51\x72\x96\xb0\x99\x10\x6c\xfb\xd6\x76\xd5\x5f\x6f\xf0\xd6\x6a\x22\xf8\xf6\x4a\xd9\xdc\xc9\x53\xaa\xe5\xc3\x5c\x9f\xc8\xf1\xbe\x79\xf7\x53\x7f\x48\xdb\xd6\xbf\x55\x96\xa9\x1e\x67\xfe\x19\x49\x61\x18\x17\x47\xb0\xc3\xc5\x1c\x95\xd9\xb9\x0f\xf2\x17\xc4\x6e\x10\x67\x1a\x22\x26\xe6\x8c\xde\xa1\xd2\x3c\x46\xc8\x9f\xf0\x42\xcc\xe7\x6b\x18\xae\x75\x85\xa7\x33\xbc\x0f\xfa\x9d\xff\x1f\x00\x3c\x81\xb7\xc3\x19\x29\x00\x00")
 
 func assetsStaticScriptJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -212,7 +212,7 @@ func assetsStaticScriptJs() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "assets/static/script.js", size: 8215, mode: os.FileMode(420), modTime: time.Unix(1539553430, 0)}
+	info := bindataFileInfo{name: "assets/static/script.js", size: 10521, mode: os.FileMode(420), modTime: time.Unix(1792340224, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -96,7 +96,73 @@ function enableZoomPan(panzoom) {
       });
 }
 
+function showMetadata() {
+    restCall("ct/v1/metadata", null, function (md) {
+        var body = $("#log_metadata tbody");
+        var addRow = function (label, value) {
+            if (value) {
+                body.append($("<tr>").append($("<th>").text(label), $("<td>").append(value)));
+            }
+        };
+        var link = function (url) {
+            return url ? $("<a>").attr("href", url).text(url) : null;
+        };
+        addRow("Description", md.description);
+        addRow("Operator", md.operator);
+        addRow("State", md.state);
+        addRow("State changed", md.state_timestamp ? new Date(md.state_timestamp).toISOString() : null);
+        addRow("Final tree head", md.final_sth ? "tree size " + md.final_sth.tree_size + ", root hash " + md.final_sth.sha256_root_hash : null);
+        addRow("Dataset", link(md.dataset_url));
+        addRow("Log URL", md.url);
+        addRow("Maximum merge delay", md.maximum_merge_delay + " seconds");
+        addRow("Created", md.created ? new Date(md.created).toISOString() : null);
+        addRow("Public key", md.key);
+    }, function (reason) {
+        $("#log_metadata tbody").append($("<tr>").append($("<td>").text("error: " + reason)));
+    });
+}
+
+// liveStream is the EventSource for live additions, if we are watching
+var liveStream = null;
+
+// toggleLive starts or stops showing entries as they are added. The browser reconnects the stream itself,
+// resuming after the last entry it received.
+function toggleLive() {
+    if (liveStream) {
+        liveStream.close();
+        liveStream = null;
+        $("#live_toggle").text("Start watching");
+        return;
+    }
+    liveStream = new EventSource("api/v1/stream");
+    liveStream.addEventListener("sth", function (e) {
+        var sth = JSON.parse(e.data);
+        var s = "";
+        s += "tree size: " + sth.tree_size + "\n";
+        s += "root hash: " + sth.root_hash + "\n";
+        s += "signed: " + new Date(sth.timestamp).toISOString() + "\n";
+        $("#live_sth").text(s);
+    });
+    liveStream.addEventListener("entry", function (e) {
+        var entry = JSON.parse(e.data);
+        $("#live_entries").prepend(document.createTextNode(entry.index + ": " + JSON.stringify(entry.data) + "\n"));
+    });
+    liveStream.onerror = function () {
+        if (liveStream.readyState == EventSource.CLOSED) {
+            $("#live_sth").text("error: stream closed");
+        }
+    };
+    $("#live_toggle").text("Stop watching");
+}
+
 $(function () {
+    showMetadata();
+
+    $("#live_toggle").click(function (e) {
+        e.preventDefault();
+        toggleLive();
+    });
+
     $("#get_sth").click(function (e) {
         e.preventDefault();
         restCall("ct/v1/get-sth?tree_size=" + Number($("#get_sth_tree_size").val()), null, function (result) {
@@ -157,27 +223,23 @@ $(function () {
         var asObj = JSON.parse(jsonValue);
         var objectHash = objectHashWithRedaction(JSON.parse(jsonValue), '');
         restCall("ct/v1/get-sth?tree_size=" + Number($("#inclusion_proof_tree_size").val()), null, function (sth) {
-            restCall("ct/v1/get-objecthash?hash=" + encodeURIComponent(btoa(objectHash)), null, function (sct) {
-                var mtlInput = createRFC6962MerkleTreeLeafFromObjectHash(sct.timestamp, objectHash);
+            restCall("ct/v1/get-proof-by-objecthash?hash=" + encodeURIComponent(btoa(objectHash)) + "&tree_size=" + sth.tree_size, null, function (inclusionProof) {
+                var mtlInput = createRFC6962MerkleTreeLeafFromObjectHash(inclusionProof.timestamp, objectHash);
                 var leafHash = leafMerkleTreeHash(mtlInput);
-                restCall("ct/v1/get-proof-by-hash?hash=" + encodeURIComponent(btoa(leafHash)) + "&tree_size=" + sth.tree_size, null, function (inclusionProof) {
-                    var s = "";
-                    s += "calculated object hash: " + btoa(objectHash) + "\n";
-                    s += "retrieved sct timestamp: " + sct.timestamp + "\n";
-                    s += "calculated merkle tree leaf hash: " + btoa(leafHash) + "\n";
-                    s += "\n";
-                    s += "leaf index: " + inclusionProof.leaf_index + "\n";
-                    s += "tree size: " + sth.tree_size + "\n";
-                    s += "\n";
-                    s += "inclusion audit path:\n\n";
-                    for (var i = 0; i < inclusionProof.audit_path.length; i++) {
-                        s += inclusionProof.audit_path[i] + "\n";
-                    }
-                    $("#inclusion_proof_result").text(s);
-                    DrawInclusionProof($("#inclusion_proof_diagram"), inclusionProof.leaf_index, leafHash, sth.tree_size, atob(sth.sha256_root_hash), array_atob(inclusionProof.audit_path));
-                }, function (reason) {
-                    $("#inclusion_proof_result").text("error: " + reason);
-                });
+                var s = "";
+                s += "calculated object hash: " + btoa(objectHash) + "\n";
+                s += "retrieved sct timestamp: " + inclusionProof.timestamp + "\n";
+                s += "calculated merkle tree leaf hash: " + btoa(leafHash) + "\n";
+                s += "\n";
+                s += "leaf index: " + inclusionProof.leaf_index + "\n";
+                s += "tree size: " + sth.tree_size + "\n";
+                s += "\n";
+                s += "inclusion audit path:\n\n";
+                for (var i = 0; i < inclusionProof.audit_path.length; i++) {
+                    s += inclusionProof.audit_path[i] + "\n";
+                }
+                $("#inclusion_proof_result").text(s);
+                DrawInclusionProof($("#inclusion_proof_diagram"), inclusionProof.leaf_index, leafHash, sth.tree_size, atob(sth.sha256_root_hash), array_atob(inclusionProof.audit_path));
             }, function (reason) {
                 $("#inclusion_proof_result").text("error: " + reason);
             });
@@ -19,8 +19,11 @@ dep ensure
 # Generate proto and assets.go
 #go generate
 
+# Run the tests
+go test github.com/govau/verifiable-logs/...
+
 # Build the things
-go install github.com/govau/verifiable-logs/cmd/{verifiable-logs-server,submit-from-external,submit-rows-to-logs,verifiable-log-tool}
+go install github.com/govau/verifiable-logs/cmd/{verifiable-logs-server,submit-from-external,submit-rows-to-logs,verifiable-log-tool,verifiable-log-monitor}
 
 # Copy artefacts to output directory for log server
 mkdir -p "${ORIG_PWD}/build/verifiable-logs-server"
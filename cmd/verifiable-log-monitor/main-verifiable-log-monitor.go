@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/certificate-transparency-go"
+
+	"github.com/govau/cf-common/env"
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+)
+
+func main() {
+	envLookup := env.NewVarSet(env.WithOSLookup())
+
+	// LOG_URLS is a comma separated list of log base URLs, e.g. https://verifiable-logs.example.com/dataset/mytable
+	urls := splitList(envLookup.MustString("LOG_URLS"))
+	if len(urls) == 0 {
+		log.Fatal("LOG_URLS must list at least one log")
+	}
+
+	interval, err := time.ParseDuration(envLookup.String("POLL_INTERVAL", "1m"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// STREAM, if "true", also checks logs as soon as they publish a new STH on their stream, rather than
+	// only every POLL_INTERVAL
+	stream := envLookup.String("STREAM", "") == "true"
+
+	mmd, err := time.ParseDuration(envLookup.String("MAX_MERGE_DELAY", "24h"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// STATE_DIR, if set, is where progress is saved so that a restart need not re-fetch every entry
+	stateDir := envLookup.String("STATE_DIR", "")
+
+	rep := &reporter{
+		WebhookURL: envLookup.String("WEBHOOK_URL", ""),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+
+	// GOSSIP_URLS optionally lists log base URLs on other servers that we also send verified STHs to
+	var gossip []*generalisedtransparency.LogClient
+	for _, u := range splitList(envLookup.String("GOSSIP_URLS", "")) {
+		gossip = append(gossip, &generalisedtransparency.LogClient{URL: u})
+	}
+
+	ctx := context.Background()
+
+	monitors := make(map[string]*logMonitor)
+	for _, u := range urls {
+		m := &logMonitor{
+			URL:      u,
+			Client:   &generalisedtransparency.LogClient{URL: u},
+			MMD:      mmd,
+			Reporter: rep,
+			Gossip:   gossip,
+		}
+		if stateDir != "" {
+			m.StatePath = statePathForLog(stateDir, u)
+		}
+		err = m.load()
+		if err != nil {
+			log.Fatal(err)
+		}
+		monitors[u] = m
+
+		go m.run(ctx, interval, stream)
+	}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "HEALTHY")
+	})
+
+	http.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		var rv []*status
+		for _, u := range urls {
+			rv = append(rv, monitors[u].status())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rv)
+	})
+
+	// /observe is for reporting an SCT seen elsewhere, with log and hash (base64) params, so that we
+	// check it is incorporated within the maximum merge delay
+	http.HandleFunc("/observe", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		m, ok := monitors[r.FormValue("log")]
+		if !ok {
+			http.Error(w, "unknown log", http.StatusBadRequest)
+			return
+		}
+
+		b, err := base64.StdEncoding.DecodeString(r.FormValue("hash"))
+		if err != nil || len(b) != len(ct.ObjectHash{}) {
+			http.Error(w, "invalid hash", http.StatusBadRequest)
+			return
+		}
+
+		var oh ct.ObjectHash
+		copy(oh[:], b)
+
+		err = m.observe(r.Context(), oh)
+		switch {
+		case err == nil:
+			w.WriteHeader(http.StatusNoContent)
+		case generalisedtransparency.IsNotFound(err):
+			http.Error(w, "hash not found in log", http.StatusNotFound)
+		case isVerificationError(err):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			log.Println(err)
+			http.Error(w, "error fetching sct", http.StatusBadGateway)
+		}
+	})
+
+	log.Println("Monitoring", len(urls), "logs...")
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", os.Getenv("PORT")), nil))
+}
+
+// splitList returns the non-empty items in a comma separated list
+func splitList(s string) []string {
+	var rv []string
+	for _, u := range strings.Split(s, ",") {
+		u = strings.TrimSpace(u)
+		if u != "" {
+			rv = append(rv, u)
+		}
+	}
+	return rv
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/certificate-transparency-go"
+
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// pendingSCT is an SCT we have observed, and are waiting to see incorporated into the log
+type pendingSCT struct {
+	Hash []byte `json:"hash"`
+
+	// Timestamp is from the SCT, in milliseconds since the epoch
+	Timestamp uint64 `json:"timestamp"`
+
+	// Reported is set once we have reported it as exceeding the maximum merge delay
+	Reported bool `json:"reported"`
+}
+
+// logState is what we have verified about a log, and is saved between runs if a state directory is configured
+type logState struct {
+	// STH is the latest STH that we have verified, including all entries up to its tree size
+	STH *ct.GetSTHResponse `json:"sth,omitempty"`
+
+	// CompactRange is for all entries up to STH.TreeSize
+	CompactRange [][]byte `json:"compact_range"`
+
+	// Pending are SCTs observed and not yet seen incorporated
+	Pending []*pendingSCT `json:"pending"`
+}
+
+// logMonitor watches a single log
+type logMonitor struct {
+	URL      string
+	Client   *generalisedtransparency.LogClient
+	MMD      time.Duration
+	Reporter *reporter
+
+	// StatePath is where state is saved, or empty if not saved
+	StatePath string
+
+	// Gossip are other logs whose servers we also report verified STHs to, so that split views can be found.
+	// We always report to the log itself.
+	Gossip []*generalisedtransparency.LogClient
+
+	mutex    sync.Mutex
+	state    *logState
+	reported map[string]bool
+	lastPoll time.Time
+	lastErr  error
+}
+
+// statePathForLog returns the file in dir to save state for the log at url
+func statePathForLog(dir, url string) string {
+	h := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(h[:])+".json")
+}
+
+// load reads saved state, if any
+func (m *logMonitor) load() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.state = &logState{}
+	m.reported = make(map[string]bool)
+
+	if m.StatePath == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(m.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var s logState
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the saved range matches the saved STH before we rely on it
+	if s.STH != nil {
+		cr := &merkle.CompactRange{Size: s.STH.TreeSize, Hashes: s.CompactRange}
+		if !cr.Valid() || !bytes.Equal(cr.Root(), s.STH.SHA256RootHash) {
+			return fmt.Errorf("saved state for %s is corrupt", m.URL)
+		}
+	}
+
+	m.state = &s
+	return nil
+}
+
+// save writes state, if configured, replacing the file atomically. Caller must hold mutex.
+func (m *logMonitor) save() error {
+	if m.StatePath == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(m.state)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(m.StatePath+".tmp", b, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(m.StatePath+".tmp", m.StatePath)
+}
+
+// violation reports a problem, once per key, so that an ongoing problem does not flood alerts
+func (m *logMonitor) violation(ctx context.Context, key, vtype, message string, evidence interface{}) {
+	m.mutex.Lock()
+	seen := m.reported[vtype+key]
+	m.reported[vtype+key] = true
+	m.mutex.Unlock()
+
+	if seen {
+		return
+	}
+
+	m.Reporter.report(ctx, &violation{
+		Time:     time.Now(),
+		Log:      m.URL,
+		Type:     vtype,
+		Message:  message,
+		Evidence: evidence,
+	})
+}
+
+// run polls the log every interval until ctx is done. If stream is set, it also polls whenever the log
+// publishes a new STH on its stream.
+func (m *logMonitor) run(ctx context.Context, interval time.Duration, stream bool) {
+	wake := make(chan struct{}, 1)
+	if stream {
+		go m.watch(ctx, wake, interval)
+	}
+
+	for {
+		err := m.poll(ctx)
+		if err != nil {
+			log.Printf("%s: %s\n", m.URL, err)
+		}
+
+		m.mutex.Lock()
+		m.lastPoll = time.Now()
+		m.lastErr = err
+		m.mutex.Unlock()
+
+		select {
+		case <-time.After(interval):
+		case <-wake:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watch signals wake whenever the log publishes a new STH on its stream, so that new entries are checked as
+// soon as they are added. If the stream fails, it reconnects after retry.
+func (m *logMonitor) watch(ctx context.Context, wake chan<- struct{}, retry time.Duration) {
+	for {
+		err := m.Client.Stream(ctx, -1, func(ev *generalisedtransparency.StreamEvent) error {
+			if ev.Event == "sth" {
+				select {
+				case wake <- struct{}{}:
+				default: // already due to poll
+				}
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("%s: stream: %s\n", m.URL, err)
+		}
+
+		select {
+		case <-time.After(retry):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll fetches the latest STH, and if it has changed, checks it and any new entries. Then it checks pending SCTs.
+// Errors returned are for failures talking to the log, misbehaviour is reported as a violation.
+func (m *logMonitor) poll(ctx context.Context) error {
+	m.mutex.Lock()
+	prev := m.state.STH
+	prevRange := m.state.CompactRange
+	m.mutex.Unlock()
+
+	raw, err := m.Client.GetRawSTH(ctx, 0)
+	if err != nil {
+		return err
+	}
+
+	sth, err := m.Client.VerifySTH(ctx, raw)
+	if err != nil {
+		if _, ok := err.(*generalisedtransparency.VerificationError); ok {
+			m.violation(ctx, string(raw.TreeHeadSignature), violationSTHSignature, err.Error(), raw)
+			return nil
+		}
+		return err
+	}
+
+	if prev != nil && sth.Timestamp < prev.Timestamp {
+		m.violation(ctx, string(raw.TreeHeadSignature), violationSTHTimestamp, "sth timestamp is earlier than previous sth", map[string]interface{}{
+			"previous": prev,
+			"current":  raw,
+		})
+	}
+
+	if prev == nil || prev.TreeSize != sth.TreeSize || !bytes.Equal(prev.SHA256RootHash, sth.SHA256RootHash[:]) {
+		ok, err := m.checkNewSTH(ctx, prev, prevRange, raw, sth)
+		if err != nil {
+			return err
+		}
+		if ok {
+			m.mutex.Lock()
+			m.state.STH = raw
+			err = m.save()
+			m.mutex.Unlock()
+			if err != nil {
+				return err
+			}
+
+			m.gossip(ctx, raw)
+		}
+	}
+
+	return m.checkPending(ctx)
+}
+
+// checkNewSTH verifies the new STH is consistent with the previous, and that the new entries are valid and
+// hash to its root, returning true if so
+func (m *logMonitor) checkNewSTH(ctx context.Context, prev *ct.GetSTHResponse, prevRange [][]byte, raw *ct.GetSTHResponse, sth *ct.SignedTreeHead) (bool, error) {
+	evidence := map[string]interface{}{
+		"previous": prev,
+		"current":  raw,
+	}
+
+	cr := &merkle.CompactRange{}
+	if prev != nil {
+		if sth.TreeSize < prev.TreeSize {
+			m.violation(ctx, string(raw.TreeHeadSignature), violationSTHInconsistent, "tree size has decreased", evidence)
+			return false, nil
+		}
+
+		prevSTH, err := prev.ToSignedTreeHead()
+		if err != nil {
+			return false, err
+		}
+
+		_, err = m.Client.GetVerifiedConsistency(ctx, prevSTH, sth)
+		if err != nil {
+			if _, ok := err.(*generalisedtransparency.VerificationError); ok {
+				m.violation(ctx, string(raw.TreeHeadSignature), violationSTHInconsistent, err.Error(), evidence)
+				return false, nil
+			}
+			return false, err
+		}
+
+		cr = &merkle.CompactRange{Size: prev.TreeSize, Hashes: append([][]byte{}, prevRange...)}
+	}
+
+	reader, err := m.Client.GetReadClientContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for cr.Size < sth.TreeSize {
+		resp, err := reader.GetRawEntries(ctx, int64(cr.Size), int64(sth.TreeSize)-1)
+		if err != nil {
+			return false, err
+		}
+		if len(resp.Entries) == 0 {
+			return false, fmt.Errorf("no entries returned from %d", cr.Size)
+		}
+
+		for _, e := range resp.Entries {
+			idx := cr.Size
+
+			// Report bad entries, but carry on, since the tree itself may be fine
+			entry, err := generalisedtransparency.DecodeEntry(int64(idx), e.LeafInput, e.ExtraData)
+			if err == nil {
+				err = entry.Check()
+			}
+			if err != nil && err != generalisedtransparency.ErrUnsupportedEntryType {
+				m.violation(ctx, fmt.Sprint(idx), violationEntryInvalid, fmt.Sprintf("entry %d: %s", idx, err), map[string]interface{}{
+					"index":      idx,
+					"leaf_input": e.LeafInput,
+					"extra_data": e.ExtraData,
+				})
+			}
+
+			cr.Append(merkle.LeafHash(e.LeafInput))
+			if cr.Size == sth.TreeSize {
+				break
+			}
+		}
+	}
+
+	if !bytes.Equal(cr.Root(), sth.SHA256RootHash[:]) {
+		m.violation(ctx, string(raw.TreeHeadSignature), violationRootMismatch, "entries do not hash to the root in the sth", map[string]interface{}{
+			"sth":                  raw,
+			"calculated_root_hash": cr.Root(),
+		})
+		return false, nil
+	}
+
+	m.mutex.Lock()
+	m.state.CompactRange = cr.Hashes
+	m.mutex.Unlock()
+
+	return true, nil
+}
+
+// gossip reports a verified STH to the log, and any other configured servers, so that they can compare it
+// with what others have seen. Failure to gossip is not fatal, since the servers may not support it.
+func (m *logMonitor) gossip(ctx context.Context, raw *ct.GetSTHResponse) {
+	md, err := m.Client.GetMetadata(ctx)
+	if err != nil {
+		log.Printf("%s: error fetching metadata for gossip: %s\n", m.URL, err)
+		return
+	}
+
+	for _, c := range append([]*generalisedtransparency.LogClient{m.Client}, m.Gossip...) {
+		evidence, err := c.GossipSTH(ctx, md.Key, raw)
+		if err != nil {
+			log.Printf("%s: error gossiping sth to %s: %s\n", m.URL, c.URL, err)
+			continue
+		}
+		for _, e := range evidence {
+			m.violation(ctx, string(raw.TreeHeadSignature)+e.Reason, violationSplitView, fmt.Sprintf("%s reported %s", c.URL, e.Reason), e)
+		}
+	}
+}
+
+// observe fetches and verifies the SCT for hash, and adds it to those we are waiting to see incorporated
+func (m *logMonitor) observe(ctx context.Context, hash ct.ObjectHash) error {
+	sct, err := m.Client.GetVerifiedSCTByObjectHash(ctx, hash)
+	if err != nil {
+		if _, ok := err.(*generalisedtransparency.VerificationError); ok {
+			m.violation(ctx, string(hash[:]), violationSCTInvalid, err.Error(), map[string]interface{}{
+				"hash": hash[:],
+			})
+		}
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, p := range m.state.Pending {
+		if bytes.Equal(p.Hash, hash[:]) {
+			return nil
+		}
+	}
+
+	m.state.Pending = append(m.state.Pending, &pendingSCT{
+		Hash:      hash[:],
+		Timestamp: sct.Timestamp,
+	})
+
+	return m.save()
+}
+
+// checkPending removes those pending SCTs that are now included in the latest STH,
+// and reports those that should have been by now
+func (m *logMonitor) checkPending(ctx context.Context) error {
+	m.mutex.Lock()
+	pending := append([]*pendingSCT{}, m.state.Pending...)
+	raw := m.state.STH
+	m.mutex.Unlock()
+
+	if raw == nil || len(pending) == 0 {
+		return nil
+	}
+
+	sth, err := raw.ToSignedTreeHead()
+	if err != nil {
+		return err
+	}
+
+	// Use the later of now, and the STH time, so that a log that stops issuing STHs is caught too
+	now := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	if sth.Timestamp > now {
+		now = sth.Timestamp
+	}
+
+	var remaining []*pendingSCT
+	for _, p := range pending {
+		var oh ct.ObjectHash
+		copy(oh[:], p.Hash)
+
+		_, err := m.Client.GetVerifiedInclusionByObjectHash(ctx, oh, sth)
+		switch {
+		case err == nil:
+			continue // incorporated, so we're done with it
+		case generalisedtransparency.IsNotFound(err):
+			// not yet included
+		case isVerificationError(err):
+			m.violation(ctx, string(p.Hash), violationSTHInconsistent, "inclusion proof failed: "+err.Error(), map[string]interface{}{
+				"hash": p.Hash,
+				"sth":  raw,
+			})
+		default:
+			return err
+		}
+
+		if !p.Reported && now > p.Timestamp+uint64(m.MMD/time.Millisecond) {
+			m.violation(ctx, string(p.Hash), violationMMDExceeded, "sct not incorporated within maximum merge delay", map[string]interface{}{
+				"hash":          p.Hash,
+				"sct_timestamp": p.Timestamp,
+				"sth":           raw,
+			})
+			p.Reported = true
+		}
+		remaining = append(remaining, p)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Keep anything observed while we were checking
+	for _, p := range m.state.Pending[len(pending):] {
+		remaining = append(remaining, p)
+	}
+	m.state.Pending = remaining
+
+	return m.save()
+}
+
+func isVerificationError(err error) bool {
+	_, ok := err.(*generalisedtransparency.VerificationError)
+	return ok
+}
+
+// status summarises the monitor's view of the log
+type status struct {
+	URL      string             `json:"url"`
+	STH      *ct.GetSTHResponse `json:"sth,omitempty"`
+	Pending  int                `json:"pending_scts"`
+	LastPoll time.Time          `json:"last_poll"`
+	LastErr  string             `json:"last_error,omitempty"`
+}
+
+func (m *logMonitor) status() *status {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	rv := &status{
+		URL:      m.URL,
+		STH:      m.state.STH,
+		Pending:  len(m.state.Pending),
+		LastPoll: m.lastPoll,
+	}
+	if m.lastErr != nil {
+		rv.LastErr = m.lastErr.Error()
+	}
+	return rv
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Types of violation that we report
+const (
+	// violationSTHSignature means an STH was returned with an invalid signature
+	violationSTHSignature = "sth_signature_invalid"
+
+	// violationSTHInconsistent means a new STH is not consistent with the previous one we verified
+	violationSTHInconsistent = "sth_inconsistent"
+
+	// violationSTHTimestamp means a new STH has an earlier timestamp than the previous one
+	violationSTHTimestamp = "sth_timestamp_regressed"
+
+	// violationEntryInvalid means an entry failed to decode, or its data does not match its objecthash or signature
+	violationEntryInvalid = "entry_invalid"
+
+	// violationRootMismatch means the entries returned do not hash to the root in the STH
+	violationRootMismatch = "root_mismatch"
+
+	// violationSCTInvalid means an SCT returned by get-objecthash did not verify
+	violationSCTInvalid = "sct_invalid"
+
+	// violationSplitView means a server we gossip STHs to has found conflicting heads from the log
+	violationSplitView = "sth_split_view"
+
+	// violationMMDExceeded means an SCT was not incorporated into the log within the maximum merge delay
+	violationMMDExceeded = "mmd_exceeded"
+)
+
+// violation is emitted as JSON when a log is observed misbehaving
+type violation struct {
+	Time    time.Time `json:"time"`
+	Log     string    `json:"log"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+
+	// Evidence holds whatever was received from the log that demonstrates the problem
+	Evidence interface{} `json:"evidence,omitempty"`
+}
+
+// reporter writes violations to stdout, and posts them to a webhook if configured
+type reporter struct {
+	WebhookURL string
+	HTTPClient *http.Client
+
+	mutex sync.Mutex
+	out   *json.Encoder
+}
+
+func (r *reporter) report(ctx context.Context, v *violation) {
+	r.mutex.Lock()
+	if r.out == nil {
+		r.out = json.NewEncoder(os.Stdout)
+	}
+	err := r.out.Encode(v)
+	r.mutex.Unlock()
+	if err != nil {
+		log.Println("error writing violation:", err)
+	}
+
+	if r.WebhookURL != "" {
+		go r.postWebhook(ctx, v)
+	}
+}
+
+// postWebhook posts the violation as JSON, retrying a few times, since these should not be missed
+func (r *reporter) postWebhook(ctx context.Context, v *violation) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Println("error encoding violation for webhook:", err)
+		return
+	}
+
+	wait := time.Second
+	for attempt := 1; attempt <= 5; attempt++ {
+		err = r.postOnce(ctx, b)
+		if err == nil {
+			return
+		}
+		log.Printf("error posting violation to webhook (attempt %d): %s\n", attempt, err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+		wait *= 2
+	}
+}
+
+func (r *reporter) postOnce(ctx context.Context, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, r.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("bad http status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/google/certificate-transparency-go"
+
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// duplicate describes an entry whose key has been seen before
+type duplicate struct {
+	// Key is the value of the key field, or a list of values if there is more than one
+	Key interface{} `json:"key"`
+
+	// Index is the index of the duplicate entry
+	Index uint64 `json:"index"`
+
+	// FirstIndex is the index of the entry where the key was first seen
+	FirstIndex uint64 `json:"first_index"`
+}
+
+type auditResult struct {
+	STH *ct.GetSTHResponse `json:"sth"`
+
+	// ResumedFrom is the number of entries already audited in a previous run
+	ResumedFrom uint64 `json:"resumed_from"`
+
+	// Entries is the number of entries audited in this run
+	Entries uint64 `json:"entries"`
+
+	// EntryTypes counts the entries audited in this run by type
+	EntryTypes map[string]uint64 `json:"entry_types"`
+
+	// Unchecked is the number of entries of a type whose contents could not be verified
+	Unchecked uint64 `json:"unchecked"`
+
+	// KeySpec lists the key fields or paths used to detect duplicates
+	KeySpec []string `json:"key_spec"`
+
+	// MissingKey is the number of entries that did not have all of the key fields, and so were not checked for duplicates
+	MissingKey uint64 `json:"missing_key"`
+
+	Duplicates []*duplicate `json:"duplicates"`
+	Verified   bool         `json:"verified"`
+}
+
+// cmdAudit fetches every entry in the log, checks each objecthash (or CMS signature), and that the root hash calculated
+// matches that in the latest STH. Entries are fetched concurrently, and processed in order.
+// If a state file is given, progress is checkpointed to it, and subsequent runs will
+// resume from where the last left off, so that once complete only new entries are fetched.
+// Duplicates are detected by the key fields given, which for CMS entries are looked up in the signed content.
+func cmdAudit(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	workers := fs.Int("workers", 4, "number of concurrent requests")
+	batchSize := fs.Uint64("batch", 100, "number of entries per request")
+	statePath := fs.String("state", "", "file to checkpoint progress to, and resume from (optional)")
+	checkpointEvery := fs.Uint64("checkpoint", 10000, "number of entries between checkpoints")
+	keyFields := fs.String("key-fields", "key", "comma separated list of top-level fields that together must be unique, empty to disable")
+	var keyPaths keyPathsFlag
+	fs.Var(&keyPaths, "key-path", "JSONPath-style expression, e.g. $.owner.vin, selecting a value that forms part of the unique key (may be repeated, and replaces the default key-fields)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *workers <= 0 || *batchSize == 0 || *checkpointEvery == 0 {
+		return nil, &usageError{msg: "workers, batch and checkpoint must be positive"}
+	}
+
+	var keys keySpec
+	keyFieldsSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "key-fields" {
+			keyFieldsSet = true
+		}
+	})
+	if keyFieldsSet || len(keyPaths) == 0 {
+		for _, name := range strings.Split(*keyFields, ",") {
+			if name != "" {
+				keys = append(keys, fieldKeyPath(name))
+			}
+		}
+	}
+	keys = append(keys, keyPaths...)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Stop cleanly on interrupt, so that we checkpoint what we have
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+	go func() {
+		select {
+		case <-interrupted:
+			log.Println("interrupted, saving progress")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	md, err := lc.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logID := sha256.Sum256(md.Key)
+
+	state := &auditState{}
+	keysPath := ""
+	if *statePath != "" {
+		state, err = loadAuditState(*statePath)
+		if err != nil {
+			return nil, err
+		}
+		if state.LogID == nil {
+			state.LogID = logID[:]
+			state.KeySpec = keys.String()
+		} else if !bytes.Equal(state.LogID, logID[:]) {
+			return nil, &usageError{msg: "state file is for a different log"}
+		} else if state.KeySpec != keys.String() {
+			return nil, &usageError{msg: "state file was created with different key fields: " + state.KeySpec}
+		}
+		keysPath = *statePath + ".keys"
+	}
+
+	seen, err := openKeySet(keysPath, state.KeysFileSize)
+	if err != nil {
+		return nil, err
+	}
+	defer seen.close()
+
+	sth, err := lc.GetSTHAtSize(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if sth.TreeSize < state.Size {
+		return nil, verificationFailed("log has %d entries, fewer than the %d previously audited", sth.TreeSize, state.Size)
+	}
+
+	// Check the log is consistent with what we verified last time before fetching anything
+	if state.VerifiedSTH != nil {
+		prev, err := state.VerifiedSTH.ToSignedTreeHead()
+		if err != nil {
+			return nil, err
+		}
+		_, err = lc.GetVerifiedConsistency(ctx, prev, sth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rv := &auditResult{
+		ResumedFrom: state.Size,
+		EntryTypes:  make(map[string]uint64),
+		KeySpec:     []string{},
+		Duplicates:  []*duplicate{},
+	}
+	for _, p := range keys {
+		rv.KeySpec = append(rv.KeySpec, p.expr)
+	}
+	rv.STH, err = sthResult(sth)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := lc.GetReadClientContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &merkle.CompactRange{Size: state.Size, Hashes: state.CompactRange}
+
+	checkpoint := func() error {
+		if *statePath == "" {
+			return nil
+		}
+		keysFileSize, err := seen.flush()
+		if err != nil {
+			return err
+		}
+		state.Size = cr.Size
+		state.CompactRange = cr.Hashes
+		state.KeysFileSize = keysFileSize
+		return state.save(*statePath)
+	}
+
+	err = auditEntries(ctx, reader, cr, keys, seen, sth.TreeSize, *batchSize, *workers, *checkpointEvery, checkpoint, rv)
+	cerr := checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	if cerr != nil {
+		return nil, cerr
+	}
+
+	if !bytes.Equal(sth.SHA256RootHash[:], cr.Root()) {
+		return nil, verificationFailed("received root hash: %s, calculated root hash: %s", sth.SHA256RootHash.Base64String(), base64.StdEncoding.EncodeToString(cr.Root()))
+	}
+
+	log.Println("verified root hash in sth matches that calculated by get-entries")
+
+	state.VerifiedSTH = rv.STH
+	err = checkpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	rv.Verified = true
+	return rv, nil
+}
+
+// auditEntries fetches entries from cr.Size up to treeSize, adding each to cr in order, and recording duplicates in rv
+func auditEntries(ctx context.Context, reader generalisedtransparency.AuditClient, cr *merkle.CompactRange, keys keySpec, seen *keySet, treeSize, batchSize uint64, workers int, checkpointEvery uint64, checkpoint func() error, rv *auditResult) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lastCheckpoint := cr.Size
+	for res := range fetchInOrder(ctx, reader, keys, cr.Size, treeSize, batchSize, workers) {
+		var batch *batchResult
+		select {
+		case batch = <-res:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		for _, ae := range batch.entries {
+			cr.Append(ae.LeafHash)
+			rv.Entries++
+			rv.EntryTypes[ae.Entry.EntryType]++
+			if ae.Unchecked {
+				rv.Unchecked++
+			}
+
+			if len(keys) == 0 {
+				continue
+			}
+			if !ae.HasKey {
+				rv.MissingKey++
+				continue
+			}
+
+			unique, first, err := seen.add(ae.Key, uint64(ae.Entry.Index))
+			if err != nil {
+				return err
+			}
+			if !unique {
+				log.Println("duplicate:", ae.Key, ae.Entry.Index, "first seen at", first)
+				rv.Duplicates = append(rv.Duplicates, &duplicate{
+					Key:        ae.KeyValues,
+					Index:      uint64(ae.Entry.Index),
+					FirstIndex: first,
+				})
+			}
+		}
+
+		if batch.err != nil {
+			return batch.err
+		}
+
+		if cr.Size-lastCheckpoint >= checkpointEvery {
+			err := checkpoint()
+			if err != nil {
+				return err
+			}
+			lastCheckpoint = cr.Size
+			log.Printf("audited %d of %d entries\n", cr.Size, treeSize)
+		}
+	}
+
+	// The producer stops early if cancelled
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return nil
+}
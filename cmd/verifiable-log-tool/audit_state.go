@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/certificate-transparency-go"
+
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// auditState is checkpointed to the state file so that an audit can be resumed after failure,
+// and later re-run to audit only the entries added since
+type auditState struct {
+	// LogID is the log the state belongs to
+	LogID []byte `json:"log_id"`
+
+	// Size is the number of entries audited, that are included in CompactRange
+	Size uint64 `json:"size"`
+
+	// CompactRange is the compact range for entries [0, Size), largest subtree first
+	CompactRange [][]byte `json:"compact_range"`
+
+	// VerifiedSTH is the latest STH whose root hash has been verified against the entries, if any
+	VerifiedSTH *ct.GetSTHResponse `json:"verified_sth,omitempty"`
+
+	// KeySpec describes the fields used to detect duplicates, which must not change when resuming
+	KeySpec string `json:"key_spec"`
+
+	// KeysFileSize is the length of the keys file as at this checkpoint
+	KeysFileSize int64 `json:"keys_file_size"`
+}
+
+// loadAuditState reads the state file at path, returning a new state if it does not exist
+func loadAuditState(path string) (*auditState, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &auditState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rv auditState
+	err = json.Unmarshal(b, &rv)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure it is self-consistent before we trust it
+	cr := &merkle.CompactRange{Size: rv.Size, Hashes: rv.CompactRange}
+	if !cr.Valid() {
+		return nil, verificationFailed("state file compact range is the wrong length for its size")
+	}
+	if rv.VerifiedSTH != nil && rv.VerifiedSTH.TreeSize == rv.Size && !bytes.Equal(cr.Root(), rv.VerifiedSTH.SHA256RootHash) {
+		return nil, verificationFailed("state file compact range does not match verified sth")
+	}
+
+	return &rv, nil
+}
+
+// save writes the state to path, replacing it atomically
+func (s *auditState) save(path string) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path+".tmp", b, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// keySet records the keys seen so far, and the index at which each was first seen, optionally persisting
+// them to a file alongside the state file, so that duplicates are still found when an audit is resumed
+type keySet struct {
+	seen map[string]uint64
+	file *os.File
+	w    *bufio.Writer
+	size int64
+}
+
+// openKeySet loads the keys in path, ignoring any written after the checkpoint that recorded validSize.
+// If path is empty, the set is held only in memory.
+func openKeySet(path string, validSize int64) (*keySet, error) {
+	rv := &keySet{seen: make(map[string]uint64)}
+	if path == "" {
+		return rv, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.Truncate(validSize)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// Each line is the base64 hash of the key, and the index it was first seen at
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var k []byte
+		var idx uint64
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			k, err = base64.StdEncoding.DecodeString(fields[0])
+			if err == nil {
+				idx, err = strconv.ParseUint(fields[1], 10, 64)
+			}
+		} else {
+			err = errors.New("malformed line in keys file")
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		rv.seen[string(k)] = idx
+	}
+	err = scanner.Err()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	_, err = f.Seek(validSize, io.SeekStart)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	rv.file = f
+	rv.w = bufio.NewWriter(f)
+	rv.size = validSize
+	return rv, nil
+}
+
+// add records key as seen at index. If it has been seen before, it returns false and the index it was first seen at.
+func (k *keySet) add(key string, index uint64) (bool, uint64, error) {
+	h := sha256.Sum256([]byte(key))
+	first, ok := k.seen[string(h[:])]
+	if ok {
+		return false, first, nil
+	}
+	k.seen[string(h[:])] = index
+
+	if k.w != nil {
+		n, err := fmt.Fprintf(k.w, "%s %d\n", base64.StdEncoding.EncodeToString(h[:]), index)
+		k.size += int64(n)
+		if err != nil {
+			return true, index, err
+		}
+	}
+
+	return true, index, nil
+}
+
+// flush ensures all keys are written, returning the file size to record in the checkpoint
+func (k *keySet) flush() (int64, error) {
+	if k.w == nil {
+		return 0, nil
+	}
+
+	err := k.w.Flush()
+	if err != nil {
+		return 0, err
+	}
+
+	err = k.file.Sync()
+	if err != nil {
+		return 0, err
+	}
+
+	return k.size, nil
+}
+
+func (k *keySet) close() error {
+	if k.file == nil {
+		return nil
+	}
+	return k.file.Close()
+}
@@ -0,0 +1,785 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+)
+
+// sthResult converts a verified STH into the same JSON form as used by get-sth
+func sthResult(sth *ct.SignedTreeHead) (*ct.GetSTHResponse, error) {
+	sig, err := tls.Marshal(sth.TreeHeadSignature)
+	if err != nil {
+		return nil, err
+	}
+	return &ct.GetSTHResponse{
+		TreeSize:          sth.TreeSize,
+		Timestamp:         sth.Timestamp,
+		SHA256RootHash:    sth.SHA256RootHash[:],
+		TreeHeadSignature: sig,
+	}, nil
+}
+
+// readArgument returns the value of an argument that may be given inline, as @path to read a file, or as - to read stdin
+func readArgument(s string) ([]byte, error) {
+	switch {
+	case s == "-":
+		return ioutil.ReadAll(os.Stdin)
+	case strings.HasPrefix(s, "@"):
+		return ioutil.ReadFile(s[1:])
+	default:
+		return []byte(s), nil
+	}
+}
+
+// readRow parses a row, as it would be submitted to the log, and returns it along with its objecthash
+func readRow(s string) (map[string]interface{}, ct.ObjectHash, error) {
+	b, err := readArgument(s)
+	if err != nil {
+		return nil, ct.ObjectHash{}, err
+	}
+
+	var row map[string]interface{}
+	err = json.Unmarshal(b, &row)
+	if err != nil {
+		return nil, ct.ObjectHash{}, &usageError{msg: "row must be a JSON object: " + err.Error()}
+	}
+
+	_, oh, err := generalisedtransparency.FilterAndHash(row)
+	if err != nil {
+		return nil, ct.ObjectHash{}, err
+	}
+
+	return row, oh, nil
+}
+
+type getSTHResult struct {
+	STH      *ct.GetSTHResponse `json:"sth"`
+	Verified bool               `json:"verified"`
+}
+
+// parseTime parses a time given as milliseconds since the epoch, or in RFC3339 format, returning milliseconds
+func parseTime(s string) (uint64, error) {
+	ms, err := strconv.ParseUint(s, 10, 64)
+	if err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, &usageError{msg: fmt.Sprintf("invalid time %q, must be milliseconds since the epoch or RFC3339", s)}
+	}
+	return uint64(t.UnixNano() / int64(time.Millisecond)), nil
+}
+
+func cmdGetSTH(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	size := fs.Uint64("size", 0, "tree size (optional, defaults to latest)")
+	at := fs.String("at", "", "time (optional), to fetch the largest tree head signed by then, as milliseconds since the epoch or RFC3339")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var sth *ct.SignedTreeHead
+	if *at != "" {
+		if *size != 0 {
+			return nil, &usageError{msg: "only one of size and at may be specified"}
+		}
+		timestamp, err := parseTime(*at)
+		if err != nil {
+			return nil, err
+		}
+		sth, err = lc.GetSTHAtTime(ctx, timestamp)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		sth, err = lc.GetSTHAtSize(ctx, *size)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := sthResult(sth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &getSTHResult{STH: res, Verified: true}, nil
+}
+
+type sthHistoryResult struct {
+	STHs     []*ct.GetSTHResponse `json:"sths"`
+	Next     *int64               `json:"next,omitempty"`
+	Verified bool                 `json:"verified"`
+}
+
+func cmdSTHHistory(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	q := &generalisedtransparency.STHHistoryQuery{}
+	fs.Int64Var(&q.Start, "start", 0, "position in the history to start from")
+	fs.IntVar(&q.Count, "count", 0, "most tree heads to return (optional, defaults to the server's page size)")
+	fs.Uint64Var(&q.MinTreeSize, "min-size", 0, "smallest tree size to include")
+	fs.Uint64Var(&q.MaxTreeSize, "max-size", 0, "largest tree size to include")
+	since := fs.String("since", "", "earliest time to include, as milliseconds since the epoch or RFC3339")
+	until := fs.String("until", "", "latest time to include, as milliseconds since the epoch or RFC3339")
+	all := fs.Bool("all", false, "fetch all pages, rather than just one")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *since != "" {
+		q.MinTimestamp, err = parseTime(*since)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if *until != "" {
+		q.MaxTimestamp, err = parseTime(*until)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rv := &sthHistoryResult{STHs: []*ct.GetSTHResponse{}}
+	for {
+		resp, err := lc.GetSTHHistory(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sth := range resp.STHs {
+			_, err = lc.VerifySTH(ctx, sth)
+			if err != nil {
+				return nil, err
+			}
+			rv.STHs = append(rv.STHs, sth)
+		}
+
+		rv.Next = resp.Next
+		if resp.Next == nil || !*all {
+			break
+		}
+		q.Start = *resp.Next
+	}
+
+	rv.Verified = true
+	return rv, nil
+}
+
+type consistencyResult struct {
+	First       *ct.GetSTHResponse `json:"first"`
+	Second      *ct.GetSTHResponse `json:"second"`
+	Consistency [][]byte           `json:"consistency"`
+	Verified    bool               `json:"verified"`
+}
+
+func cmdConsistency(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	first := fs.Uint64("first", 0, "first tree size")
+	second := fs.Uint64("second", 0, "second tree size (optional, defaults to latest)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *first == 0 {
+		return nil, &usageError{msg: "first must be specified"}
+	}
+	if *second != 0 && *first > *second {
+		return nil, &usageError{msg: "first must not be greater than second"}
+	}
+
+	secondSTH, err := lc.GetSTHAtSize(ctx, *second)
+	if err != nil {
+		return nil, err
+	}
+	if *first > secondSTH.TreeSize {
+		return nil, &usageError{msg: "first is greater than the current tree size"}
+	}
+
+	firstSTH, err := lc.GetSTHAtSize(ctx, *first)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := lc.GetVerifiedConsistency(ctx, firstSTH, secondSTH)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &consistencyResult{Consistency: proof, Verified: true}
+	rv.First, err = sthResult(firstSTH)
+	if err != nil {
+		return nil, err
+	}
+	rv.Second, err = sthResult(secondSTH)
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+type inclusionResult struct {
+	LeafIndex  int64              `json:"leaf_index"`
+	Timestamp  uint64             `json:"timestamp"`
+	ObjectHash []byte             `json:"object_hash,omitempty"`
+	AuditPath  [][]byte           `json:"audit_path"`
+	STH        *ct.GetSTHResponse `json:"sth"`
+	Verified   bool               `json:"verified"`
+}
+
+func cmdInclusion(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	row := fs.String("row", "", "row as JSON, @file or - for stdin, which is canonicalised and hashed as it would be on submission")
+	hash := fs.String("hash", "", "base64 objecthash")
+	index := fs.Int64("index", -1, "leaf index")
+	size := fs.Uint64("size", 0, "tree size (optional, defaults to latest)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	given := 0
+	for _, b := range []bool{*row != "", *hash != "", *index >= 0} {
+		if b {
+			given++
+		}
+	}
+	if given != 1 {
+		return nil, &usageError{msg: "exactly one of row, hash or index must be specified"}
+	}
+
+	var oh ct.ObjectHash
+	switch {
+	case *row != "":
+		_, oh, err = readRow(*row)
+		if err != nil {
+			return nil, err
+		}
+	case *hash != "":
+		b, err := base64.StdEncoding.DecodeString(*hash)
+		if err != nil || len(b) != sha256.Size {
+			return nil, &usageError{msg: "hash must be a base64 encoded objecthash"}
+		}
+		copy(oh[:], b)
+	}
+
+	sth, err := lc.GetSTHAtSize(ctx, *size)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &inclusionResult{Verified: true}
+	rv.STH, err = sthResult(sth)
+	if err != nil {
+		return nil, err
+	}
+
+	if *index >= 0 {
+		if uint64(*index) >= sth.TreeSize {
+			return nil, &usageError{msg: "index is not within the tree size"}
+		}
+		resp, err := lc.GetVerifiedEntryAndProof(ctx, uint64(*index), sth)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := generalisedtransparency.DecodeEntry(*index, resp.LeafInput, resp.ExtraData)
+		if err != nil {
+			return nil, verificationFailed("unable to decode entry: %s", err)
+		}
+		rv.LeafIndex = entry.Index
+		rv.Timestamp = entry.Timestamp
+		rv.ObjectHash = entry.ObjectHash
+		rv.AuditPath = resp.AuditPath
+		return rv, nil
+	}
+
+	resp, err := lc.GetVerifiedInclusionByObjectHash(ctx, oh, sth)
+	if err != nil {
+		return nil, err
+	}
+	rv.LeafIndex = resp.LeafIndex
+	rv.Timestamp = resp.Timestamp
+	rv.ObjectHash = oh[:]
+	rv.AuditPath = resp.AuditPath
+	return rv, nil
+}
+
+type getEntryResult struct {
+	Entry     *generalisedtransparency.DecodedEntry `json:"entry"`
+	AuditPath [][]byte                              `json:"audit_path"`
+	STH       *ct.GetSTHResponse                    `json:"sth"`
+	Verified  bool                                  `json:"verified"`
+}
+
+func cmdGetEntry(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	index := fs.Int64("index", -1, "leaf index")
+	size := fs.Uint64("size", 0, "tree size (optional, defaults to latest)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *index < 0 {
+		return nil, &usageError{msg: "index must be specified"}
+	}
+
+	sth, err := lc.GetSTHAtSize(ctx, *size)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(*index) >= sth.TreeSize {
+		return nil, &usageError{msg: "index is not within the tree size"}
+	}
+
+	resp, err := lc.GetVerifiedEntryAndProof(ctx, uint64(*index), sth)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := generalisedtransparency.DecodeEntry(*index, resp.LeafInput, resp.ExtraData)
+	if err != nil {
+		return nil, verificationFailed("unable to decode entry: %s", err)
+	}
+
+	err = entry.Check()
+	if err != nil && err != generalisedtransparency.ErrUnsupportedEntryType {
+		return nil, verificationFailed("entry %d: %s", entry.Index, err)
+	}
+
+	rv := &getEntryResult{Entry: entry, AuditPath: resp.AuditPath, Verified: true}
+	rv.STH, err = sthResult(sth)
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+func cmdDump(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	start := fs.Int64("start", 0, "first index to write")
+	end := fs.Int64("end", 0, "index to stop before (optional, defaults to the latest tree size)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *start < 0 || *end < 0 {
+		return nil, &usageError{msg: "start and end must not be negative"}
+	}
+
+	sth, err := lc.GetSTHAtSize(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	if *end == 0 || uint64(*end) > sth.TreeSize {
+		*end = int64(sth.TreeSize)
+	}
+
+	reader, err := lc.GetReadClientContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	idx := *start
+	for idx < *end {
+		resp, err := reader.GetRawEntries(ctx, idx, *end-1)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Entries) == 0 {
+			return nil, verificationFailed("no entries returned from %d", idx)
+		}
+
+		for _, e := range resp.Entries {
+			entry, err := generalisedtransparency.DecodeEntry(idx, e.LeafInput, e.ExtraData)
+			if err != nil {
+				return nil, verificationFailed("entry %d: unable to decode: %s", idx, err)
+			}
+			err = out.Encode(entry)
+			if err != nil {
+				return nil, err
+			}
+
+			idx++
+			if idx == *end {
+				break
+			}
+		}
+	}
+
+	log.Printf("wrote %d entries\n", *end-*start)
+
+	// Entries have been written already, so that output is pure NDJSON
+	return nil, nil
+}
+
+type verifySCTResult struct {
+	ObjectHash []byte `json:"object_hash"`
+	LogID      []byte `json:"log_id"`
+	Timestamp  uint64 `json:"timestamp"`
+	Verified   bool   `json:"verified"`
+}
+
+type searchResult struct {
+	Entries []*generalisedtransparency.DecodedEntry `json:"entries"`
+	STH     *ct.GetSTHResponse                      `json:"sth"`
+
+	// IndexedTreeSize is the tree size up to which the log had indexed the field
+	IndexedTreeSize int64 `json:"indexed_tree_size"`
+
+	// Next is the -start to fetch the next page, if there may be more
+	Next     *int64 `json:"next,omitempty"`
+	Verified bool   `json:"verified"`
+}
+
+func cmdSearch(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	field := fs.String("field", "", "indexed field to search")
+	value := fs.String("value", "", "value of the field to find")
+	start := fs.Int64("start", 0, "position in the results to start from")
+	count := fs.Int("count", 0, "most entries to return (optional, defaults to the server's page size)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *field == "" {
+		return nil, &usageError{msg: "field must be specified"}
+	}
+	if *start < 0 || *count < 0 {
+		return nil, &usageError{msg: "start and count must not be negative"}
+	}
+
+	resp, sth, err := lc.GetVerifiedSearch(ctx, &generalisedtransparency.SearchQuery{
+		Field: *field,
+		Value: *value,
+		Start: *start,
+		Count: *count,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &searchResult{
+		Entries:         []*generalisedtransparency.DecodedEntry{},
+		IndexedTreeSize: resp.IndexedTreeSize,
+		Next:            resp.Next,
+		Verified:        true,
+	}
+	for _, r := range resp.Results {
+		entry, err := generalisedtransparency.DecodeEntry(r.LeafIndex, r.LeafInput, r.ExtraData)
+		if err != nil {
+			return nil, verificationFailed("unable to decode entry: %s", err)
+		}
+		rv.Entries = append(rv.Entries, entry)
+	}
+	rv.STH, err = sthResult(sth)
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+func cmdVerifySCT(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	rowArg := fs.String("row", "", "row as JSON, @file or - for stdin")
+	sctArg := fs.String("sct", "", "base64 TLS encoded SCT (optional, defaults to the signed_certificate_timestamp field of the row)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *rowArg == "" {
+		return nil, &usageError{msg: "row must be specified"}
+	}
+
+	row, oh, err := readRow(*rowArg)
+	if err != nil {
+		return nil, err
+	}
+
+	sctB64 := *sctArg
+	if sctB64 == "" {
+		sctB64, _ = row["signed_certificate_timestamp"].(string)
+		if sctB64 == "" {
+			return nil, verificationFailed("row has no signed_certificate_timestamp")
+		}
+	}
+
+	sctBytes, err := base64.StdEncoding.DecodeString(sctB64)
+	if err != nil {
+		return nil, verificationFailed("sct is not valid base64: %s", err)
+	}
+
+	sct, err := lc.VerifyObjectHashSCT(ctx, oh, sctBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifySCTResult{
+		ObjectHash: oh[:],
+		LogID:      sct.LogID.KeyID[:],
+		Timestamp:  sct.Timestamp,
+		Verified:   true,
+	}, nil
+}
+
+type metadataResult struct {
+	*generalisedtransparency.MetadataResponse
+	LogID []byte `json:"log_id"`
+}
+
+func cmdMetadata(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := lc.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logID := sha256.Sum256(md.Key)
+	return &metadataResult{
+		MetadataResponse: md,
+		LogID:            logID[:],
+	}, nil
+}
+
+// metadataFlags adds flags for the fields in a SetMetadataRequest, and returns a function to
+// build the request from those that were given once fs has been parsed
+func metadataFlags(fs *flag.FlagSet) func() *generalisedtransparency.SetMetadataRequest {
+	names := map[string]string{
+		"description": "description",
+		"operator":    "operator",
+		"log-url":     "url",
+		"dataset-url": "dataset URL",
+	}
+	for name, desc := range names {
+		fs.String(name, "", desc+" to set")
+	}
+	fs.Int64("mmd", 0, "maximum merge delay in seconds to set, 0 for the server default")
+	fs.Bool("private", false, "whether only authenticated readers may read the log")
+	fs.String("cors-origins", "", "comma separated origins allowed for CORS requests, empty for the default")
+	fs.String("index-fields", "", "comma separated top-level fields of entries to index for searching, empty for none")
+	fs.String("map-key-field", "", "top-level field of entries to key the log's verifiable map by, which cannot be changed once set")
+
+	return func() *generalisedtransparency.SetMetadataRequest {
+		req := &generalisedtransparency.SetMetadataRequest{}
+
+		// Only flags that are given are changed
+		strs := map[string]**string{
+			"description": &req.Description,
+			"operator":    &req.Operator,
+			"log-url":     &req.URL,
+			"dataset-url": &req.DatasetURL,
+		}
+		fs.Visit(func(f *flag.Flag) {
+			if p, ok := strs[f.Name]; ok {
+				v := f.Value.String()
+				*p = &v
+			}
+			switch f.Name {
+			case "mmd":
+				mmd := f.Value.(flag.Getter).Get().(int64)
+				req.MaximumMergeDelay = &mmd
+			case "private":
+				private := f.Value.(flag.Getter).Get().(bool)
+				req.Private = &private
+			case "cors-origins":
+				origins := []string{}
+				if f.Value.String() != "" {
+					origins = strings.Split(f.Value.String(), ",")
+				}
+				req.CORSOrigins = &origins
+			case "index-fields":
+				fields := []string{}
+				if f.Value.String() != "" {
+					fields = strings.Split(f.Value.String(), ",")
+				}
+				req.IndexFields = &fields
+			case "map-key-field":
+				field := f.Value.String()
+				req.MapKeyField = &field
+			}
+		})
+		return req
+	}
+}
+
+// parseAdminFlags parses args into fs, and checks that an admin key has been given
+func parseAdminFlags(lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) error {
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if lc.AdminAPIKey == "" {
+		return &usageError{msg: "admin-key must be specified"}
+	}
+	return nil
+}
+
+func cmdSetMetadata(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	req := metadataFlags(fs)
+	err := parseAdminFlags(lc, fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := lc.SetMetadata(ctx, req())
+	if err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+func cmdCreateLog(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	req := metadataFlags(fs)
+	err := parseAdminFlags(lc, fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := lc.CreateLog(ctx, req())
+	if err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+func cmdFreeze(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	err := parseAdminFlags(lc, fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := lc.FreezeLog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return md, verifyFinalSTH(ctx, lc, md)
+}
+
+func cmdRetire(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	err := parseAdminFlags(lc, fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := lc.RetireLog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return md, verifyFinalSTH(ctx, lc, md)
+}
+
+// verifyFinalSTH checks that the final STH returned for a frozen log is signed by the log
+func verifyFinalSTH(ctx context.Context, lc *generalisedtransparency.LogClient, md *generalisedtransparency.MetadataResponse) error {
+	if md.FinalSTH == nil {
+		return verificationFailed("no final STH returned")
+	}
+	_, err := lc.VerifySTH(ctx, md.FinalSTH)
+	return err
+}
+
+type gossipResult struct {
+	STH      *ct.GetSTHResponse                           `json:"sth"`
+	Evidence []*generalisedtransparency.STHGossipEvidence `json:"evidence"`
+}
+
+func cmdGossip(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	to := fs.String("to", "", "base URL of the log whose server to report to (optional, defaults to this log)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := lc.GetRawSTH(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	_, err = lc.VerifySTH(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := lc.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	target := lc
+	if *to != "" {
+		target = &generalisedtransparency.LogClient{URL: *to}
+	}
+
+	evidence, err := target.GossipSTH(ctx, md.Key, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &gossipResult{STH: raw, Evidence: evidence}
+	if len(evidence) != 0 {
+		return rv, verificationFailed("server found %d conflicts for this sth", len(evidence))
+	}
+	return rv, nil
+}
+
+type verifyReceiptResult struct {
+	ObjectHash []byte `json:"object_hash"`
+	LeafIndex  int64  `json:"leaf_index"`
+	TreeSize   uint64 `json:"tree_size"`
+	Verified   bool   `json:"verified"`
+}
+
+func cmdVerifyReceipt(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	receiptPath := fs.String("receipt", "", "path to receipt JSON file")
+	trustedKey := fs.String("pubkey", "", "base64 ASN.1 DER public key that receipts must be issued by (optional)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *receiptPath == "" {
+		return nil, &usageError{msg: "receipt must be specified"}
+	}
+
+	var trustedKeyDER []byte
+	if *trustedKey != "" {
+		trustedKeyDER, err = base64.StdEncoding.DecodeString(*trustedKey)
+		if err != nil {
+			return nil, &usageError{msg: "pubkey must be base64"}
+		}
+	}
+
+	receiptBytes, err := ioutil.ReadFile(*receiptPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipt generalisedtransparency.Receipt
+	err = json.Unmarshal(receiptBytes, &receipt)
+	if err != nil {
+		return nil, verificationFailed("unable to parse receipt: %s", err)
+	}
+
+	err = receipt.Verify(trustedKeyDER)
+	if err != nil {
+		return nil, &generalisedtransparency.VerificationError{Err: err}
+	}
+
+	return &verifyReceiptResult{
+		ObjectHash: receipt.ObjectHash,
+		LeafIndex:  receipt.LeafIndex,
+		TreeSize:   receipt.STH.TreeSize,
+		Verified:   true,
+	}, nil
+}
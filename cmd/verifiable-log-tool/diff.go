@@ -0,0 +1,498 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+)
+
+// Row statuses reported by diff
+const (
+	// rowOK means the row is included in the STH, and any SCT it carries is valid for it
+	rowOK = "ok"
+
+	// rowMissing means the row is not in the log, and has no SCT
+	rowMissing = "missing"
+
+	// rowTampered means the row carries an SCT from this log that is not valid for its contents,
+	// and its contents are not in the log, so it has most likely been changed since it was logged
+	rowTampered = "tampered"
+
+	// rowInvalidSCT means the row carries an SCT that is malformed, from another log, or is not valid
+	// for its contents even though they are in the log
+	rowInvalidSCT = "invalid_sct"
+
+	// rowNotIncluded means the row carries a valid SCT, but is not included in the STH,
+	// either as it was added since, or as the log has failed to incorporate it
+	rowNotIncluded = "not_included"
+
+	// rowUnreadable means the row could not be parsed or hashed
+	rowUnreadable = "unreadable"
+)
+
+type diffRow struct {
+	// Row is the 1-based number of the row in the file, not counting any CSV header
+	Row int `json:"row"`
+
+	Status     string `json:"status"`
+	ObjectHash []byte `json:"object_hash,omitempty"`
+	LeafIndex  *int64 `json:"leaf_index,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type diffResult struct {
+	STH    *ct.GetSTHResponse `json:"sth"`
+	Rows   int                `json:"rows"`
+	Counts map[string]int     `json:"counts"`
+
+	// Problems lists every row not ok, or every row if requested
+	Problems []*diffRow `json:"rows_reported"`
+
+	Verified bool `json:"verified"`
+}
+
+type diffJob struct {
+	row  int
+	data map[string]interface{}
+	err  error
+}
+
+// cmdDiff checks which rows in a local copy of a dataset are backed by the log
+func cmdDiff(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	path := fs.String("file", "", "CSV or newline delimited JSON file to check, - for stdin")
+	format := fs.String("format", "", "csv or ndjson (optional, defaults based on the file extension)")
+	size := fs.Uint64("size", 0, "tree size of the STH to check inclusion in (optional, defaults to latest)")
+	sthArg := fs.String("sth", "", "STH to check inclusion in, as JSON in get-sth form, @file or - for stdin (optional, overrides size)")
+	infer := fs.Bool("infer", true, "convert text values that look like times (and for CSV, numbers, booleans and empty values) to their types before hashing")
+	stringColumns := fs.String("string-columns", "", "comma separated list of columns never to convert")
+	workers := fs.Int("workers", 8, "number of concurrent requests")
+	all := fs.Bool("all", false, "report every row, not only those with problems")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *path == "" {
+		return nil, &usageError{msg: "file must be specified"}
+	}
+	if *workers <= 0 {
+		return nil, &usageError{msg: "workers must be positive"}
+	}
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(*path)) {
+		case ".csv":
+			*format = "csv"
+		case ".ndjson", ".jsonl", ".json":
+			*format = "ndjson"
+		default:
+			return nil, &usageError{msg: "unable to determine format from file name, please specify"}
+		}
+	}
+
+	conv := &valueConverter{
+		infer:         *infer,
+		stringColumns: make(map[string]bool),
+	}
+	for _, c := range strings.Split(*stringColumns, ",") {
+		if c != "" {
+			conv.stringColumns[c] = true
+		}
+	}
+
+	var in io.Reader = os.Stdin
+	if *path != "-" {
+		f, err := os.Open(*path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var sth *ct.SignedTreeHead
+	if *sthArg != "" {
+		sth, err = readSTH(ctx, lc, *sthArg)
+	} else {
+		sth, err = lc.GetSTHAtSize(ctx, *size)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := lc.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logID := sha256.Sum256(md.Key)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan *diffJob)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		readErr <- readRows(ctx, in, *format, conv, jobs)
+	}()
+
+	rv := &diffResult{Counts: make(map[string]int), Problems: []*diffRow{}}
+	rv.STH, err = sthResult(sth)
+	if err != nil {
+		return nil, err
+	}
+
+	var mutex sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				dr, err := diffOne(ctx, lc, logID, sth, job)
+
+				mutex.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					rv.Rows++
+					rv.Counts[dr.Status]++
+					if *all || dr.Status != rowOK {
+						rv.Problems = append(rv.Problems, dr)
+					}
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	err = <-readErr
+	if err != nil {
+		return nil, err
+	}
+
+	// Workers finish out of order
+	sort.Slice(rv.Problems, func(i, j int) bool {
+		return rv.Problems[i].Row < rv.Problems[j].Row
+	})
+
+	if rv.Counts[rowOK] != rv.Rows {
+		// Return the report as well, so that it is still written
+		return rv, verificationFailed("%d of %d rows are not backed by the log", rv.Rows-rv.Counts[rowOK], rv.Rows)
+	}
+
+	rv.Verified = true
+	return rv, nil
+}
+
+// diffOne determines the status of a single row. Errors are returned only for failures talking to the log.
+func diffOne(ctx context.Context, lc *generalisedtransparency.LogClient, logID [sha256.Size]byte, sth *ct.SignedTreeHead, job *diffJob) (*diffRow, error) {
+	rv := &diffRow{Row: job.row}
+	if job.err != nil {
+		rv.Status = rowUnreadable
+		rv.Error = job.err.Error()
+		return rv, nil
+	}
+
+	_, oh, err := generalisedtransparency.FilterAndHash(job.data)
+	if err != nil {
+		rv.Status = rowUnreadable
+		rv.Error = err.Error()
+		return rv, nil
+	}
+	rv.ObjectHash = oh[:]
+
+	// Check the SCT first
+	sctState, sctErr, err := checkRowSCT(ctx, lc, logID, oh, job.data)
+	if err != nil {
+		return nil, err
+	}
+
+	included := true
+	proof, err := lc.GetVerifiedInclusionByObjectHash(ctx, oh, sth)
+	switch {
+	case err == nil:
+		rv.LeafIndex = &proof.LeafIndex
+	case generalisedtransparency.IsNotFound(err):
+		included = false
+	default:
+		// A verification error here means the log is misbehaving, so that is not the row's fault
+		return nil, err
+	}
+
+	switch {
+	case included && sctState != sctInvalid && sctState != sctMismatch:
+		rv.Status = rowOK
+	case included:
+		rv.Status = rowInvalidSCT
+	case sctState == sctNone:
+		rv.Status = rowMissing
+	case sctState == sctValid:
+		rv.Status = rowNotIncluded
+	case sctState == sctMismatch:
+		rv.Status = rowTampered
+	default:
+		rv.Status = rowInvalidSCT
+	}
+
+	if sctErr != nil {
+		rv.Error = sctErr.Error()
+	}
+
+	return rv, nil
+}
+
+type sctState int
+
+const (
+	sctNone     sctState = iota // no SCT in the row
+	sctValid                    // SCT is valid for the row
+	sctInvalid                  // SCT is malformed or from another log
+	sctMismatch                 // SCT is from this log, but is not valid for the row's contents
+)
+
+// checkRowSCT verifies the signed_certificate_timestamp in a row, if any. The error returned second
+// describes why the SCT is not valid, and the last is for failures talking to the log.
+func checkRowSCT(ctx context.Context, lc *generalisedtransparency.LogClient, logID [sha256.Size]byte, oh ct.ObjectHash, row map[string]interface{}) (sctState, error, error) {
+	s, _ := row["signed_certificate_timestamp"].(string)
+	if s == "" {
+		return sctNone, nil, nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return sctInvalid, err, nil
+	}
+
+	// Check it's well-formed and ours, so that we can tell tampering from rubbish
+	var sct ct.SignedCertificateTimestamp
+	remaining, err := tls.Unmarshal(b, &sct)
+	if err != nil {
+		return sctInvalid, err, nil
+	}
+	if len(remaining) != 0 {
+		return sctInvalid, verificationFailed("trailing bytes in sct"), nil
+	}
+	if sct.LogID.KeyID != logID {
+		return sctInvalid, verificationFailed("sct was issued by a different log"), nil
+	}
+
+	_, err = lc.VerifyObjectHashSCT(ctx, oh, b)
+	switch err.(type) {
+	case nil:
+		return sctValid, nil, nil
+	case *generalisedtransparency.VerificationError:
+		return sctMismatch, err, nil
+	default:
+		return sctNone, nil, err
+	}
+}
+
+// readSTH parses an STH given as an argument, either in get-sth form or as output by our get-sth command, and verifies it
+func readSTH(ctx context.Context, lc *generalisedtransparency.LogClient, arg string) (*ct.SignedTreeHead, error) {
+	b, err := readArgument(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapped struct {
+		STH *ct.GetSTHResponse `json:"sth"`
+	}
+	err = json.Unmarshal(b, &wrapped)
+	if err != nil {
+		return nil, &usageError{msg: "sth must be JSON: " + err.Error()}
+	}
+	resp := wrapped.STH
+	if resp == nil {
+		resp = &ct.GetSTHResponse{}
+		err = json.Unmarshal(b, resp)
+		if err != nil {
+			return nil, &usageError{msg: "sth must be JSON: " + err.Error()}
+		}
+	}
+
+	sth, err := resp.ToSignedTreeHead()
+	if err != nil {
+		return nil, &usageError{msg: "invalid sth: " + err.Error()}
+	}
+
+	verifier, err := lc.GetVerifierContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifier.VerifySTHSignature(*sth)
+	if err != nil {
+		return nil, &generalisedtransparency.VerificationError{Err: err}
+	}
+
+	return sth, nil
+}
+
+// readRows reads rows from in, sending each to jobs. Rows that cannot be parsed are sent with an error,
+// whereas errors reading the file are returned.
+func readRows(ctx context.Context, in io.Reader, format string, conv *valueConverter, jobs chan<- *diffJob) error {
+	send := func(job *diffJob) bool {
+		select {
+		case jobs <- job:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	switch format {
+	case "csv":
+		r := csv.NewReader(in)
+		header, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Strip any byte order mark, as written by some spreadsheet software
+		if len(header) != 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+
+		for n := 1; ; n++ {
+			rec, err := r.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				if _, ok := err.(*csv.ParseError); !ok {
+					return err
+				}
+				if !send(&diffJob{row: n, err: err}) {
+					return ctx.Err()
+				}
+				continue
+			}
+
+			row := make(map[string]interface{})
+			for i, v := range rec {
+				if i < len(header) {
+					row[header[i]] = conv.csvValue(header[i], v)
+				}
+			}
+			if !send(&diffJob{row: n, data: row}) {
+				return ctx.Err()
+			}
+		}
+
+	case "ndjson":
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(nil, 16*1024*1024)
+		for n := 0; scanner.Scan(); {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			n++
+
+			var row map[string]interface{}
+			dec := json.NewDecoder(bytes.NewReader(line))
+			dec.UseNumber()
+			err := dec.Decode(&row)
+			if err == nil {
+				for k, v := range row {
+					row[k] = conv.jsonValue(k, v)
+				}
+			}
+			if !send(&diffJob{row: n, data: row, err: err}) {
+				return ctx.Err()
+			}
+		}
+		return scanner.Err()
+
+	default:
+		return &usageError{msg: "unknown format: " + format}
+	}
+}
+
+// jsonNumber matches the JSON number grammar, so that we only treat as numbers those values that would be written the same way
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// timeLayouts are those that we recognise as times when inferring types. Those without a zone are taken as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// valueConverter turns text values from a file back into the types they were in the dataset,
+// since these affect the objecthash
+type valueConverter struct {
+	infer         bool
+	stringColumns map[string]bool
+}
+
+func (c *valueConverter) parseTime(v string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, v)
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// csvValue converts a CSV cell, where everything is text, and an empty cell is most likely a null
+func (c *valueConverter) csvValue(column, v string) interface{} {
+	if !c.infer || c.stringColumns[column] || column == "signed_certificate_timestamp" {
+		return v
+	}
+	switch {
+	case v == "":
+		return nil
+	case v == "true":
+		return true
+	case v == "false":
+		return false
+	case jsonNumber.MatchString(v):
+		return json.Number(v)
+	}
+	if t, ok := c.parseTime(v); ok {
+		return t
+	}
+	return v
+}
+
+// jsonValue converts a top-level JSON value, where only times are ambiguous
+func (c *valueConverter) jsonValue(column string, v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok || !c.infer || c.stringColumns[column] || column == "signed_certificate_timestamp" {
+		return v
+	}
+	if t, ok := c.parseTime(s); ok {
+		return t
+	}
+	return v
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+)
+
+type indexAtTimeResult struct {
+	// LeafIndex is the index of the first entry added at or after the time, or TreeSize if there is none
+	LeafIndex int64  `json:"leaf_index"`
+	Timestamp uint64 `json:"timestamp,omitempty"`
+	TreeSize  int64  `json:"tree_size"`
+}
+
+func cmdIndexAtTime(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	at := fs.String("at", "", "time, as milliseconds since the epoch or RFC3339")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *at == "" {
+		return nil, &usageError{msg: "at must be specified"}
+	}
+	timestamp, err := parseTime(*at)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := lc.GetIndexAtTime(ctx, timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &indexAtTimeResult{
+		LeafIndex: resp.LeafIndex,
+		Timestamp: resp.Timestamp,
+		TreeSize:  resp.TreeSize,
+	}, nil
+}
+
+func cmdEntriesByTime(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	q := &generalisedtransparency.EntriesByTimeQuery{}
+	since := fs.String("since", "", "earliest time to include, as milliseconds since the epoch or RFC3339")
+	until := fs.String("until", "", "latest time to include, as milliseconds since the epoch or RFC3339")
+	fs.Int64Var(&q.Start, "start", 0, "first index to consider")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if q.Start < 0 {
+		return nil, &usageError{msg: "start must not be negative"}
+	}
+	if *since != "" {
+		q.MinTimestamp, err = parseTime(*since)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if *until != "" {
+		q.MaxTimestamp, err = parseTime(*until)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	written := 0
+	for {
+		resp, err := lc.GetEntriesByTime(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range resp.Entries {
+			entry, err := generalisedtransparency.DecodeEntry(e.LeafIndex, e.LeafInput, e.ExtraData)
+			if err == nil {
+				err = entry.Check()
+			}
+			if err != nil {
+				return nil, verificationFailed("entry %d: %s", e.LeafIndex, err)
+			}
+			err = out.Encode(entry)
+			if err != nil {
+				return nil, err
+			}
+			written++
+		}
+
+		if resp.Next == nil {
+			log.Printf("wrote %d entries from a tree size of %d\n", written, resp.TreeSize)
+			break
+		}
+		q.Start = *resp.Next
+	}
+
+	// Entries have been written already, so that output is pure NDJSON
+	return nil, nil
+}
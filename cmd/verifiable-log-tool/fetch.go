@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// auditedEntry is a fetched, decoded and checked entry, with its leaf hash and uniqueness key
+type auditedEntry struct {
+	LeafHash []byte
+	Entry    *generalisedtransparency.DecodedEntry
+
+	// Unchecked is set if the entry is of a type whose contents we cannot verify
+	Unchecked bool
+
+	// Key is set if the entry has all of the fields in the key spec
+	HasKey    bool
+	Key       string
+	KeyValues interface{}
+}
+
+// batchResult is the outcome of fetching a range of entries. If err is set, entries holds
+// those before the one that failed, so that they can still be processed.
+type batchResult struct {
+	entries []*auditedEntry
+	err     error
+}
+
+type batchJob struct {
+	start, end uint64
+	result     chan *batchResult
+}
+
+// fetchInOrder fetches the entries in [start, end) in batches, using workers concurrent requests.
+// The returned channel yields a channel per batch, in order, each of which will receive exactly one result.
+// At most a small multiple of workers batches are held in memory at any time.
+// Callers should cancel ctx if they stop reading before the returned channel is closed.
+func fetchInOrder(ctx context.Context, reader generalisedtransparency.AuditClient, keys keySpec, start, end, batchSize uint64, workers int) <-chan chan *batchResult {
+	rv := make(chan chan *batchResult, workers*2)
+	jobs := make(chan *batchJob)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				job.result <- fetchBatch(ctx, reader, keys, job.start, job.end)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(rv)
+		defer close(jobs)
+
+		for s := start; s < end; s += batchSize {
+			e := s + batchSize
+			if e > end {
+				e = end
+			}
+
+			// Queue the result first, so that the consumer sees batches in order
+			res := make(chan *batchResult, 1)
+			select {
+			case rv <- res:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- &batchJob{start: s, end: e, result: res}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return rv
+}
+
+// fetchBatch fetches, decodes and checks [start, end), making as many requests as needed since
+// the server may return fewer entries than asked for
+func fetchBatch(ctx context.Context, reader generalisedtransparency.AuditClient, keys keySpec, start, end uint64) *batchResult {
+	rv := &batchResult{}
+	idx := start
+	for idx < end {
+		resp, err := reader.GetRawEntries(ctx, int64(idx), int64(end)-1)
+		if err != nil {
+			rv.err = err
+			return rv
+		}
+		if len(resp.Entries) == 0 {
+			rv.err = verificationFailed("no entries returned from %d", idx)
+			return rv
+		}
+
+		for _, e := range resp.Entries {
+			entry, err := generalisedtransparency.DecodeEntry(int64(idx), e.LeafInput, e.ExtraData)
+			if err != nil {
+				rv.err = verificationFailed("entry %d: unable to decode: %s", idx, err)
+				return rv
+			}
+
+			err = entry.Check()
+			if err != nil && err != generalisedtransparency.ErrUnsupportedEntryType {
+				rv.err = verificationFailed("entry %d: %s", idx, err)
+				return rv
+			}
+
+			ae := &auditedEntry{
+				LeafHash:  merkle.LeafHash(e.LeafInput),
+				Entry:     entry,
+				Unchecked: err == generalisedtransparency.ErrUnsupportedEntryType,
+			}
+			ae.Key, ae.KeyValues, ae.HasKey = keys.extract(entry.Data)
+			rv.entries = append(rv.entries, ae)
+
+			idx++
+			if idx == end {
+				break
+			}
+		}
+	}
+	return rv
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// keyPath selects a single value from a JSON document. Steps are either a string (object member) or an int (array index).
+type keyPath struct {
+	expr  string
+	steps []interface{}
+}
+
+// fieldKeyPath selects a top-level field, which may contain any characters
+func fieldKeyPath(name string) *keyPath {
+	return &keyPath{expr: name, steps: []interface{}{name}}
+}
+
+// parseKeyPath parses a JSONPath-style expression that selects a single value, such as
+// $.owner.name, $.items[0] or $['odd.name'].vin. The leading $ is optional.
+func parseKeyPath(expr string) (*keyPath, error) {
+	rv := &keyPath{expr: expr}
+
+	s := expr
+	if strings.HasPrefix(s, "$") {
+		s = s[1:]
+	} else if s != "" && s[0] != '[' {
+		s = "." + s
+	}
+	if s == "" {
+		return nil, errors.New("key path must select a value within the entry")
+	}
+
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end == -1 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, errors.New("empty member name in key path: " + expr)
+			}
+			rv.steps = append(rv.steps, s[:end])
+			s = s[end:]
+
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end == -1 {
+				return nil, errors.New("unterminated [ in key path: " + expr)
+			}
+			inner := s[1:end]
+			switch {
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				rv.steps = append(rv.steps, inner[1:len(inner)-1])
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil || idx < 0 {
+					return nil, errors.New("invalid index in key path: " + expr)
+				}
+				rv.steps = append(rv.steps, idx)
+			}
+			s = s[end+1:]
+
+		default:
+			return nil, errors.New("unexpected character in key path: " + expr)
+		}
+	}
+
+	return rv, nil
+}
+
+// lookup returns the value selected, and false if it is not present or is null
+func (p *keyPath) lookup(data interface{}) (interface{}, bool) {
+	cur := data
+	for _, step := range p.steps {
+		switch s := step.(type) {
+		case string:
+			m, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			cur, ok = m[s]
+			if !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := cur.([]interface{})
+			if !ok || s >= len(a) {
+				return nil, false
+			}
+			cur = a[s]
+		}
+	}
+	if cur == nil {
+		return nil, false
+	}
+	return cur, true
+}
+
+// keySpec describes how to derive the uniqueness key for an entry from its data.
+// The key is the combination of the values selected by each path, all of which must be present.
+type keySpec []*keyPath
+
+// String returns a description of the spec, recorded in the state file so that a resumed audit uses the same keys
+func (ks keySpec) String() string {
+	var exprs []string
+	for _, p := range ks {
+		exprs = append(exprs, p.expr)
+	}
+	return strings.Join(exprs, ",")
+}
+
+// extract returns the key for data, in a canonical form suitable for comparison, along with the values
+// themselves for reporting. If any value is missing, ok is false.
+func (ks keySpec) extract(data json.RawMessage) (key string, values interface{}, ok bool) {
+	if len(ks) == 0 || len(data) == 0 {
+		return "", nil, false
+	}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	err := dec.Decode(&doc)
+	if err != nil {
+		return "", nil, false
+	}
+
+	var vals []interface{}
+	for _, p := range ks {
+		v, ok := p.lookup(doc)
+		if !ok {
+			return "", nil, false
+		}
+		vals = append(vals, v)
+	}
+
+	// Map keys are sorted when marshalled, so this is canonical
+	b, err := json.Marshal(vals)
+	if err != nil {
+		return "", nil, false
+	}
+
+	if len(vals) == 1 {
+		return string(b), vals[0], true
+	}
+	return string(b), vals, true
+}
+
+// keyPathsFlag collects repeated -key-path flags
+type keyPathsFlag []*keyPath
+
+func (f *keyPathsFlag) String() string {
+	return keySpec(*f).String()
+}
+
+func (f *keyPathsFlag) Set(s string) error {
+	p, err := parseKeyPath(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, p)
+	return nil
+}
@@ -2,122 +2,296 @@ package main
 
 import (
 	"context"
-	"crypto/sha256"
+	"crypto/tls"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
-
-	"github.com/benlaurie/objecthash/go/objecthash"
-	"github.com/google/certificate-transparency-go"
-	"github.com/google/certificate-transparency-go/tls"
+	"sort"
 
 	"github.com/govau/verifiable-logs/generalisedtransparency"
 )
 
+// Exit codes, so that audits can be scripted
+const (
+	exitOK                 = 0
+	exitVerificationFailed = 1
+	exitUsage              = 2
+	exitError              = 3
+)
+
+// usageError is returned by a command when it has been invoked incorrectly
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// verificationFailed is returned by a command for failures detected by the tool itself,
+// as opposed to those returned by LogClient as a *generalisedtransparency.VerificationError
+func verificationFailed(format string, args ...interface{}) error {
+	return &generalisedtransparency.VerificationError{Err: fmt.Errorf(format, args...)}
+}
+
+type command struct {
+	// Usage is a one line description of the arguments and purpose
+	Usage string
+
+	// Offline commands do not need a URL
+	Offline bool
+
+	// Run executes the command, returning a result to be written to stdout as JSON (or nil to write nothing).
+	// If both a result and an error are returned, the result is written before exiting as per the error.
+	Run func(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error)
+}
+
+var commands = map[string]*command{
+	"get-sth": {
+		Usage: "[-size N | -at TIME] - fetch and verify the signed tree head, latest unless a size or time is given",
+		Run:   cmdGetSTH,
+	},
+	"sth-history": {
+		Usage: "[-start N] [-count N] [-min-size N] [-max-size N] [-since TIME] [-until TIME] [-all] - list and verify signed tree heads in the order the log signed them",
+		Run:   cmdSTHHistory,
+	},
+	"consistency": {
+		Usage: "-first N [-second M] - fetch and verify a consistency proof between two tree sizes, second defaults to latest",
+		Run:   cmdConsistency,
+	},
+	"inclusion": {
+		Usage: "(-row JSON | -hash B64 | -index N) [-size N] - fetch and verify an inclusion proof for an entry",
+		Run:   cmdInclusion,
+	},
+	"get-entry": {
+		Usage: "-index N [-size N] - fetch an entry, verify its inclusion and objecthash or CMS signature, and decode it",
+		Run:   cmdGetEntry,
+	},
+	"dump": {
+		Usage: "[-start N] [-end M] - write decoded entries in [start, end) to stdout as newline delimited JSON",
+		Run:   cmdDump,
+	},
+	"index-at-time": {
+		Usage: "-at TIME - find the index of the first entry added at or after a time",
+		Run:   cmdIndexAtTime,
+	},
+	"entries-by-time": {
+		Usage: "[-since TIME] [-until TIME] [-start N] - write decoded entries added within a time range to stdout as newline delimited JSON, checking each is in the range",
+		Run:   cmdEntriesByTime,
+	},
+	"search": {
+		Usage: "-field F -value V [-start N] [-count N] - find entries with a value for an indexed field, verifying the inclusion of each in the latest signed tree head",
+		Run:   cmdSearch,
+	},
+	"map-head": {
+		Usage: "[-size N] - fetch and verify the signed head of the log's verifiable map, latest unless a tree size is given",
+		Run:   cmdMapHead,
+	},
+	"map-get": {
+		Usage: "-key K [-size N] - fetch the latest entry for a key from the log's verifiable map, verifying its inclusion in the map and log, or that the key is not in the map",
+		Run:   cmdMapGet,
+	},
+	"verify-sct": {
+		Usage: "-row JSON [-sct B64] - verify an SCT for a row, defaulting to the row's signed_certificate_timestamp",
+		Run:   cmdVerifySCT,
+	},
+	"diff": {
+		Usage: "-file FILE [-format csv|ndjson] [-size N | -sth JSON] [-infer=false] [-string-columns a,b] [-all] - report rows in a local copy of a dataset that are missing from the log, tampered with, or carry an invalid SCT",
+		Run:   cmdDiff,
+	},
+	"gossip": {
+		Usage: "[-to URL] - fetch and verify the latest signed tree head, and report it to the server for another log (default this log) to check for split views",
+		Run:   cmdGossip,
+	},
+	"metadata": {
+		Usage: "- fetch the log metadata",
+		Run:   cmdMetadata,
+	},
+	"set-metadata": {
+		Usage: "[-description S] [-operator S] [-log-url URL] [-mmd SECONDS] [-dataset-url URL] [-private] [-cors-origins a,b] [-index-fields a,b] [-map-key-field F] - change the log's descriptive metadata (requires -admin-key)",
+		Run:   cmdSetMetadata,
+	},
+	"create-log": {
+		Usage: "[-description S] [-operator S] [-log-url URL] [-mmd SECONDS] [-dataset-url URL] [-private] [-cors-origins a,b] [-index-fields a,b] [-map-key-field F] - create the log before any entries are added, with optional metadata (requires -admin-key)",
+		Run:   cmdCreateLog,
+	},
+	"freeze": {
+		Usage: "- stop further entries being added to the log, and publish its final signed tree head (requires -admin-key)",
+		Run:   cmdFreeze,
+	},
+	"retire": {
+		Usage: "- mark the log as retired, freezing it first if needed (requires -admin-key)",
+		Run:   cmdRetire,
+	},
+	"webhooks": {
+		Usage: "- list webhook subscribers and the status of notifications sent to them (requires -admin-key)",
+		Run:   cmdWebhooks,
+	},
+	"add-webhook": {
+		Usage: "-to URL [-secret S] - notify URL when entries are added, returning the secret for verifying notifications (requires -admin-key)",
+		Run:   cmdAddWebhook,
+	},
+	"remove-webhook": {
+		Usage: "-id ID - stop notifying a webhook subscriber (requires -admin-key)",
+		Run:   cmdRemoveWebhook,
+	},
+	"receive-webhooks": {
+		Usage:   "-secret S [-listen ADDR] - listen for webhook notifications, writing those correctly signed to stdout, and verifying their STH if -url is given",
+		Offline: true,
+		Run:     cmdReceiveWebhooks,
+	},
+	"entries": {
+		Usage: "[-workers N] [-batch N] [-state FILE] [-checkpoint N] [-key-fields a,b | -key-path EXPR...] - fetch all entries (or those added since the last run with the state file), verifying each entry, the root hash of the latest tree head, and reporting duplicate keys",
+		Run:   cmdAudit,
+	},
+	"verify-receipt": {
+		Usage:   "-receipt FILE [-pubkey B64] - verify an inclusion receipt offline",
+		Offline: true,
+		Run:     cmdVerifyReceipt,
+	},
+}
+
+func init() {
+	// audit is an alias for entries
+	commands["audit"] = commands["entries"]
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s [-url URL] [-key KEY] [-admin-key KEY] [-read-key KEY] [-cert FILE -cert-key FILE] <command> [flags]\n\nglobal flags:\n", os.Args[0])
+	flag.PrintDefaults()
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", name, commands[name].Usage)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nexit codes: %d ok, %d verification failed, %d usage error, %d network or server error\n", exitOK, exitVerificationFailed, exitUsage, exitError)
+}
+
 func main() {
 	var url string
 	var addAPIKey string
+	var adminAPIKey string
+	var readAuthorization string
+	var certFile string
+	var keyFile string
 	var action string
-	var treeSize int
 
 	flag.StringVar(&url, "url", "", "base URL for log")
-	flag.StringVar(&action, "action", "", "base URL for log")
 	flag.StringVar(&addAPIKey, "key", "", "API key for adding (optional)")
-	flag.IntVar(&treeSize, "size", 0, "tree size (optional)")
+	flag.StringVar(&adminAPIKey, "admin-key", "", "API key for admin commands (optional)")
+	flag.StringVar(&readAuthorization, "read-key", "", "API key, or \"Bearer \" followed by a token, for reading a private log (optional)")
+	flag.StringVar(&certFile, "cert", "", "PEM client certificate file for reading a private log (optional)")
+	flag.StringVar(&keyFile, "cert-key", "", "PEM private key file for -cert")
+	flag.StringVar(&action, "action", "", "command to run (deprecated, pass the command as the first argument instead)")
+	flag.Usage = usage
 	flag.Parse()
 
-	if url == "" {
-		log.Println("url must be specified")
-		os.Exit(1)
+	// Results go to stdout, everything else to stderr
+	log.SetOutput(os.Stderr)
+
+	args := flag.Args()
+	if action == "" {
+		if len(args) == 0 {
+			usage()
+			os.Exit(exitUsage)
+		}
+		action, args = args[0], args[1:]
 	}
 
-	vlog := &generalisedtransparency.LogClient{
-		URL:       url,
-		AddAPIKey: addAPIKey,
+	cmd, ok := commands[action]
+	if !ok {
+		exit(&usageError{msg: "unrecognized command: " + action})
 	}
 
-	switch action {
-	case "":
-		log.Println("action must be specified")
-		os.Exit(1)
+	if url == "" && !cmd.Offline {
+		exit(&usageError{msg: "url must be specified"})
+	}
 
-	case "entries":
-		reader, err := vlog.GetReadClient()
+	lc := &generalisedtransparency.LogClient{
+		URL:               url,
+		AddAPIKey:         addAPIKey,
+		AdminAPIKey:       adminAPIKey,
+		ReadAuthorization: readAuthorization,
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 		if err != nil {
-			log.Fatal(err)
+			exit(&usageError{msg: "cannot load client certificate: " + err.Error()})
 		}
-
-		sth, err := reader.GetSTH(context.Background())
-		if err != nil {
-			log.Fatal(err)
+		lc.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+			},
 		}
+	}
 
-		// Verify STH using https://tools.ietf.org/html/draft-ietf-trans-rfc6962-bis-28#section-2.1.2
-		var stack [][sha256.Size]byte
-
-		numberHandled := uint64(0)
-		keys := make(map[string]bool)
-		for numberHandled < sth.TreeSize {
-			entries, err := reader.GetEntries(context.Background(), int64(numberHandled), int64(sth.TreeSize)-1)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			for _, entry := range entries {
-				leafData, err := tls.Marshal(entry.Leaf)
-				if err != nil {
-					log.Fatal(err)
-				}
-
-				stack = append(stack, sha256.Sum256(append([]byte{0}, leafData...)))
-				for i := numberHandled; (i % 2) == 1; i >>= 1 {
-					stack = append(stack[:len(stack)-2], sha256.Sum256(append(append([]byte{1}, stack[len(stack)-2][:]...), stack[len(stack)-1][:]...)))
-				}
-
-				// Verify the object hash
-				if entry.Leaf.TimestampedEntry.EntryType != ct.XObjectHashLogEntryType {
-					log.Fatal("log entry not of type object hash")
-				}
-				//log.Println("verified object hash for entry matches that in leaf")
-
-				expectedObjectHash, err := objecthash.ObjectHash(entry.ObjectData)
-				if err != nil {
-					log.Fatal(err)
-				}
-
-				if expectedObjectHash != entry.ObjectHash {
-					log.Fatal("wrong object hash for data")
-				}
-
-				// dataAsText, err := json.Marshal(entry.ObjectData)
-				// if err != nil {
-				// 	log.Fatal(err)
-				// }
-
-				// log.Printf("#%d: %s\n", idx, dataAsText[:40])
-				_, ok := keys[entry.ObjectData["key"].(string)]
-				if ok {
-					log.Println("duplicate:", entry.ObjectData["key"], numberHandled)
-				} else {
-					keys[entry.ObjectData["key"].(string)] = true
-				}
-
-				numberHandled++
-			}
-		}
+	fs := flag.NewFlagSet(action, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
 
-		for len(stack) != 1 {
-			stack = append(stack[:len(stack)-2], sha256.Sum256(append(append([]byte{1}, stack[len(stack)-2][:]...), stack[len(stack)-1][:]...)))
-		}
+	result, err := cmd.Run(context.Background(), lc, fs, args)
 
-		if sth.SHA256RootHash != stack[0] {
-			log.Fatalf("received root hash: %s, calculated root hash: %s\n", sth.SHA256RootHash.Base64String(), ct.SHA256Hash(stack[0]).Base64String())
+	// A command may return a result along with an error, such as a report of what failed verification
+	if result != nil {
+		werr := json.NewEncoder(os.Stdout).Encode(result)
+		if werr != nil {
+			exit(werr)
+		}
+		if err != nil {
+			log.Println(err)
+			code, _ := exitCode(err)
+			os.Exit(code)
 		}
+		return
+	}
 
-		log.Println("verified root hash in sth matches that calculated by get-entries")
+	if err != nil {
+		exit(err)
+	}
+}
 
+// parseFlags parses args into fs, and converts any failure into a usage error
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil {
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "unexpected arguments"}
+	}
+	return nil
+}
+
+// exitCode returns the exit code and status to report for err
+func exitCode(err error) (int, string) {
+	switch err.(type) {
+	case *usageError:
+		return exitUsage, "usage_error"
+	case *generalisedtransparency.VerificationError:
+		return exitVerificationFailed, "verification_failed"
 	default:
-		log.Println("unrecognized action")
-		os.Exit(1)
+		return exitError, "error"
 	}
 }
+
+// exit writes err as a JSON object to stdout and exits with the appropriate code
+func exit(err error) {
+	code, status := exitCode(err)
+
+	log.Println(err)
+	json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+		"status": status,
+		"error":  err.Error(),
+	})
+	os.Exit(code)
+}
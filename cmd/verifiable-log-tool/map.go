@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+
+	"github.com/google/certificate-transparency-go"
+
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+)
+
+type mapHeadResult struct {
+	SMH      *generalisedtransparency.SignedMapHead `json:"smh"`
+	STH      *ct.GetSTHResponse                     `json:"sth"`
+	Verified bool                                   `json:"verified"`
+}
+
+type mapGetResult struct {
+	Key string `json:"key"`
+
+	// Entry is the latest entry with the key, or nil if the key is not in the map
+	Entry    *generalisedtransparency.DecodedEntry  `json:"entry"`
+	SMH      *generalisedtransparency.SignedMapHead `json:"smh"`
+	STH      *ct.GetSTHResponse                     `json:"sth"`
+	Verified bool                                   `json:"verified"`
+}
+
+// verifyMapHeadSTH fetches and verifies the STH for the tree size of smh, and checks it has the same root
+// hash, so that the map head is for the same tree that everyone else sees
+func verifyMapHeadSTH(ctx context.Context, lc *generalisedtransparency.LogClient, smh *generalisedtransparency.SignedMapHead) (*ct.GetSTHResponse, error) {
+	sth, err := lc.GetSTHAtSize(ctx, smh.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(sth.SHA256RootHash[:], smh.LogRootHash) {
+		return nil, verificationFailed("map head log root hash does not match sth for tree size %d", smh.TreeSize)
+	}
+	return sthResult(sth)
+}
+
+func cmdMapHead(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	size := fs.Uint64("size", 0, "tree size the map head is for (optional, defaults to latest)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	smh, err := lc.GetVerifiedMapHead(ctx, *size)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &mapHeadResult{SMH: smh, Verified: true}
+	rv.STH, err = verifyMapHeadSTH(ctx, lc, smh)
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+func cmdMapGet(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	key := fs.String("key", "", "value of the map key field to look up")
+	size := fs.Uint64("size", 0, "tree size the map head is for (optional, defaults to latest)")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, entry, err := lc.GetVerifiedMapValue(ctx, *key, *size)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &mapGetResult{Key: *key, Entry: entry, SMH: resp.SMH, Verified: true}
+	rv.STH, err = verifyMapHeadSTH(ctx, lc, resp.SMH)
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/google/certificate-transparency-go"
+
+	"github.com/govau/verifiable-logs/generalisedtransparency"
+)
+
+func cmdWebhooks(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	err := parseAdminFlags(lc, fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := lc.Webhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func cmdAddWebhook(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	to := fs.String("to", "", "URL to post notifications to")
+	secret := fs.String("secret", "", "key for the HMAC of payloads (optional, generated if not given)")
+	err := parseAdminFlags(lc, fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *to == "" {
+		return nil, &usageError{msg: "to must be specified"}
+	}
+
+	sub, err := lc.AddWebhook(ctx, &generalisedtransparency.AddWebhookRequest{
+		URL:    *to,
+		Secret: *secret,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func cmdRemoveWebhook(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	id := fs.String("id", "", "ID of the subscriber to remove")
+	err := parseAdminFlags(lc, fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *id == "" {
+		return nil, &usageError{msg: "id must be specified"}
+	}
+
+	resp, err := lc.RemoveWebhook(ctx, *id)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// receivedWebhook is written to stdout for each notification received
+type receivedWebhook struct {
+	Delivery string                                  `json:"delivery"`
+	Payload  *generalisedtransparency.WebhookPayload `json:"payload"`
+
+	// STHVerified is set if the STH in the payload was verified against the log's key
+	STHVerified bool `json:"sth_verified"`
+}
+
+// cmdReceiveWebhooks listens for webhook notifications, such as to test a subscriber locally, writing those with
+// a valid signature to stdout. If -url is given, the STH in each is verified too.
+func cmdReceiveWebhooks(ctx context.Context, lc *generalisedtransparency.LogClient, fs *flag.FlagSet, args []string) (interface{}, error) {
+	listen := fs.String("listen", ":8081", "address to listen on")
+	secret := fs.String("secret", "", "key for the HMAC of payloads, as returned by add-webhook")
+	err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if *secret == "" {
+		return nil, &usageError{msg: "secret must be specified"}
+	}
+
+	var mutex sync.Mutex
+	out := json.NewEncoder(os.Stdout)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !generalisedtransparency.VerifyWebhookSignature([]byte(*secret), body, r.Header.Get(generalisedtransparency.WebhookSignatureHeader)) {
+			log.Println("rejected notification with invalid signature")
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		rv := &receivedWebhook{Delivery: r.Header.Get(generalisedtransparency.WebhookDeliveryHeader)}
+		err = json.Unmarshal(body, &rv.Payload)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if lc.URL != "" && rv.Payload.STH != nil {
+			_, err = lc.VerifySTH(r.Context(), &ct.GetSTHResponse{
+				TreeSize:          rv.Payload.STH.TreeSize,
+				Timestamp:         rv.Payload.STH.Timestamp,
+				SHA256RootHash:    rv.Payload.STH.RootHash,
+				TreeHeadSignature: rv.Payload.STH.Signature,
+			})
+			if err != nil {
+				log.Println("notification has invalid sth:", err)
+				http.Error(w, "invalid sth", http.StatusUnprocessableEntity)
+				return
+			}
+			rv.STHVerified = true
+		}
+
+		mutex.Lock()
+		err = out.Encode(rv)
+		mutex.Unlock()
+		if err != nil {
+			log.Println(err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Println("Listening for notifications on", *listen)
+	return nil, http.ListenAndServe(*listen, handler)
+}
@@ -1,16 +1,20 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 
 	cfenv "github.com/cloudfoundry-community/go-cfenv"
-	"github.com/continusec/verifiabledatastructures/pb"
 	"github.com/continusec/verifiabledatastructures/storage/postgres"
 	"github.com/jackc/pgx"
 
@@ -50,6 +54,11 @@ func main() {
 		log.Fatal(err)
 	}
 
+	gossipLogKeys, err := parseKeys(envLookup.String("VERIFIABLE_GOSSIP_LOG_KEYS", ""))
+	if err != nil {
+		log.Fatalf("invalid VERIFIABLE_GOSSIP_LOG_KEYS: %s", err)
+	}
+
 	// Prepare a shutdown function
 	shutdown := func() {
 		pgxPool.Close()
@@ -74,33 +83,44 @@ func main() {
 		Pool: pgxPool,
 	}
 
+	accounts, err := parseAccounts(envLookup.String("VERIFIABLE_ACCOUNTS", ""))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var readers []*generalisedtransparency.Reader
+	if s := envLookup.String("VERIFIABLE_READERS", ""); s != "" {
+		err = json.Unmarshal([]byte(s), &readers)
+		if err != nil {
+			log.Fatalf("invalid VERIFIABLE_READERS: %s", err)
+		}
+	}
+
+	cacheSize, err := strconv.Atoi(envLookup.String("VERIFIABLE_CACHE_SIZE", "0"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gtServer := &generalisedtransparency.Server{
+		Account:            "data.gov.au",
+		ReadAPIKey:         "read",
+		WriteAPIKey:        "write",
+		AdminAPIKey:        envLookup.String("VERIFIABLE_ADMIN_API_KEY", ""),
+		RequireCreate:      envLookup.String("VERIFIABLE_REQUIRE_CREATE", "") == "true",
+		Reader:             db,
+		Writer:             db,
+		InputValidator:     generalisedtransparency.APIKeyValidator(envLookup.MustString("VDB_SECRET")),
+		TableNameValidator: tableValidator,
+		ReadAllowedFields:  allowedFields(envLookup.String("VERIFIABLE_READ_ALLOWED_FIELDS", "")),
+		Readers:            readers,
+		Accounts:           accounts,
+		GossipLogKeys:      gossipLogKeys,
+		CacheSize:          cacheSize,
+	}
+
 	service := &verifiable.Service{
 		AccessPolicy: &policy.Static{
-			Policy: []*pb.ResourceAccount{
-				{
-					Id: "data.gov.au",
-					Policy: []*pb.AccessPolicy{
-						{
-							NameMatch: "*",
-							Permissions: []pb.Permission{
-								pb.Permission_PERM_LOG_PROVE_INCLUSION,
-								pb.Permission_PERM_LOG_READ_ENTRY,
-								pb.Permission_PERM_LOG_READ_HASH,
-							},
-							ApiKey:        "read",
-							AllowedFields: []string{"*"},
-						},
-						{
-							NameMatch: "*",
-							Permissions: []pb.Permission{
-								pb.Permission_PERM_LOG_RAW_ADD,
-							},
-							ApiKey:        "write",
-							AllowedFields: []string{"*"},
-						},
-					},
-				},
-			},
+			Policy: gtServer.AccessPolicy(),
 		},
 		Mutator: &instant.Mutator{
 			Writer: db,
@@ -112,18 +132,124 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	gtServer.Service = &verifiable.Client{
+		Service: server,
+	}
 
-	log.Println("Started up... waiting for ctrl-C.")
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", envLookup.String("PORT", "8080")), (&generalisedtransparency.Server{
-		Service: &verifiable.Client{
-			Service: server,
-		},
-		Account:            "data.gov.au",
-		ReadAPIKey:         "read",
-		WriteAPIKey:        "write",
-		Reader:             db,
-		Writer:             db,
-		InputValidator:     generalisedtransparency.APIKeyValidator(envLookup.MustString("VDB_SECRET")),
-		TableNameValidator: tableValidator,
-	}).CreateRESTHandler()))
+	// Notify webhook subscribers of new entries
+	go gtServer.RunWebhooks(context.Background())
+
+	// Index the timestamps of entries, for finding them by time, as entries are added
+	go gtServer.RunEntryTimeIndex(context.Background())
+
+	// Index fields configured for searching, as entries are added
+	go gtServer.RunFieldIndex(context.Background())
+
+	// Keep verifiable maps of the latest entry per key up to date
+	go gtServer.RunMaps(context.Background())
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%s", envLookup.String("PORT", "8080")),
+		Handler: gtServer.CreateRESTHandler(),
+	}
+
+	// Serving TLS ourselves allows readers to authenticate with client certificates
+	tlsCert := envLookup.String("VERIFIABLE_TLS_CERT", "")
+	if tlsCert == "" {
+		log.Println("Started up... waiting for ctrl-C.")
+		log.Fatal(httpServer.ListenAndServe())
+	}
+
+	cert, err := tls.X509KeyPair([]byte(tlsCert), []byte(envLookup.MustString("VERIFIABLE_TLS_KEY")))
+	if err != nil {
+		log.Fatal(err)
+	}
+	httpServer.TLSConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+
+		// Readers are identified by certificate fingerprint, so we need not verify the chain
+		ClientAuth: tls.RequestClientCert,
+	}
+
+	log.Println("Started up with TLS... waiting for ctrl-C.")
+	log.Fatal(httpServer.ListenAndServeTLS("", ""))
+}
+
+// accountConfig is the configuration for each additional account in VERIFIABLE_ACCOUNTS
+type accountConfig struct {
+	// VDBSecret is the API key required to add entries, as per VDB_SECRET for the default account
+	VDBSecret string `json:"vdb_secret"`
+
+	// TableNameValidator and TableNameValidatorParam are as per VERIFIABLE_TABLENAME_VALIDATOR and VERIFIABLE_TABLENAME_VALIDATOR_PARAM
+	TableNameValidator      string `json:"tablename_validator"`
+	TableNameValidatorParam string `json:"tablename_validator_param"`
+
+	// AdminAPIKey is as per VERIFIABLE_ADMIN_API_KEY
+	AdminAPIKey string `json:"admin_api_key"`
+
+	// ReadAllowedFields is as per VERIFIABLE_READ_ALLOWED_FIELDS
+	ReadAllowedFields string `json:"read_allowed_fields"`
+
+	// Readers are as per VERIFIABLE_READERS
+	Readers []*generalisedtransparency.Reader `json:"readers"`
+}
+
+// allowedFields parses a comma separated list of fields, where empty means all fields
+func allowedFields(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// parseAccounts parses a JSON object of account ID to accountConfig
+func parseAccounts(s string) (map[string]*generalisedtransparency.AccountConfig, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var configs map[string]*accountConfig
+	err := json.Unmarshal([]byte(s), &configs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VERIFIABLE_ACCOUNTS: %s", err)
+	}
+
+	rv := make(map[string]*generalisedtransparency.AccountConfig)
+	for name, c := range configs {
+		if c.VDBSecret == "" {
+			return nil, fmt.Errorf("account %s: vdb_secret must be set", name)
+		}
+		tnv, err := generalisedtransparency.CreateNamedValidator(c.TableNameValidator, c.TableNameValidatorParam)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %s", name, err)
+		}
+		rv[name] = &generalisedtransparency.AccountConfig{
+			// Keys for Service are only used internally, and the policy for each account is separate
+			ReadAPIKey:         "read",
+			WriteAPIKey:        "write",
+			AdminAPIKey:        c.AdminAPIKey,
+			InputValidator:     generalisedtransparency.APIKeyValidator(c.VDBSecret),
+			TableNameValidator: tnv,
+			ReadAllowedFields:  allowedFields(c.ReadAllowedFields),
+			Readers:            c.Readers,
+		}
+	}
+	return rv, nil
+}
+
+// parseKeys parses a comma separated list of base64 encoded keys
+func parseKeys(s string) ([][]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var rv [][]byte
+	for _, k := range strings.Split(s, ",") {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(k))
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, b)
+	}
+	return rv, nil
 }
@@ -0,0 +1,136 @@
+package generalisedtransparency
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+// AccountConfig is the configuration for an account hosted by a Server. Each account has its own logs,
+// served under /account/{account}/dataset/{logname}, and its own keys and validators.
+type AccountConfig struct {
+	// ReadAPIKey is the API key to use to read from Service for this account
+	ReadAPIKey string
+
+	// WriteAPIKey is the API key to use to write to Service for this account (used by /add-objecthash only)
+	WriteAPIKey string
+
+	// AdminAPIKey is the Authorization header value required for the admin API. If empty, the admin API is disabled.
+	AdminAPIKey string
+
+	// InputValidator checks if it is valid request to accept input from
+	InputValidator SubmissionValidator
+
+	// TableNameValidator only allows logs to be created for the specified tables
+	TableNameValidator TableNameValidator
+
+	// ReadAllowedFields are the top-level fields of entries that readers without credentials may see. Other fields
+	// are redacted. If nil, all fields may be seen.
+	ReadAllowedFields []string
+
+	// Readers may read private logs, and see the fields of entries allowed for them
+	Readers []*Reader
+}
+
+// callAccess is the kind of access an API call needs, which determines the API key used with Service
+type callAccess int
+
+const (
+	readAccess callAccess = iota
+	writeAccess
+	adminAccess
+)
+
+// isAdmin returns true if r has the admin API key for the account
+func (acc *AccountConfig) isAdmin(r *http.Request) bool {
+	return acc.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(acc.AdminAPIKey)) == 1
+}
+
+// getAccount returns the account ID and configuration for the named account. An empty name is the default account,
+// as configured by the fields on Server itself.
+func (cts *Server) getAccount(name string) (string, *AccountConfig, error) {
+	if name == "" || name == cts.Account {
+		return cts.Account, &AccountConfig{
+			ReadAPIKey:         cts.ReadAPIKey,
+			WriteAPIKey:        cts.WriteAPIKey,
+			AdminAPIKey:        cts.AdminAPIKey,
+			InputValidator:     cts.InputValidator,
+			TableNameValidator: cts.TableNameValidator,
+			ReadAllowedFields:  cts.ReadAllowedFields,
+			Readers:            cts.Readers,
+		}, nil
+	}
+
+	acc, ok := cts.Accounts[name]
+	if !ok {
+		return "", nil, verifiable.ErrNotFound
+	}
+	return name, acc, nil
+}
+
+// readPermissions are those needed to read a log, and its verifiable map
+var readPermissions = []pb.Permission{
+	pb.Permission_PERM_LOG_PROVE_INCLUSION,
+	pb.Permission_PERM_LOG_READ_ENTRY,
+	pb.Permission_PERM_LOG_READ_HASH,
+	pb.Permission_PERM_MAP_GET_VALUE,
+	pb.Permission_PERM_MAP_MUTATION_READ_HASH,
+}
+
+// accountPolicy returns the access policy for an account, allowing the read and write API keys the permissions
+// we use, and each reader to read the logs it may. AllowedFields are applied by us rather than Service, as
+// extra data is not in the redactable form Service expects, so all fields are allowed here.
+func accountPolicy(account string, acc *AccountConfig) *pb.ResourceAccount {
+	rv := &pb.ResourceAccount{
+		Id: account,
+		Policy: []*pb.AccessPolicy{
+			{
+				NameMatch:     "*",
+				Permissions:   readPermissions,
+				ApiKey:        acc.ReadAPIKey,
+				AllowedFields: []string{"*"},
+			},
+			{
+				NameMatch: "*",
+				Permissions: []pb.Permission{
+					pb.Permission_PERM_LOG_RAW_ADD,
+					pb.Permission_PERM_MAP_SET_VALUE,
+				},
+				ApiKey:        acc.WriteAPIKey,
+				AllowedFields: []string{"*"},
+			},
+		},
+	}
+	for _, rd := range acc.Readers {
+		for _, pattern := range rd.Logs {
+			rv.Policy = append(rv.Policy, &pb.AccessPolicy{
+				NameMatch:     pattern,
+				Permissions:   readPermissions,
+				ApiKey:        readerAPIKey(rd.ID),
+				AllowedFields: []string{"*"},
+			})
+		}
+	}
+	return rv
+}
+
+// AccessPolicy returns the policy to use for the Service's policy.Static, with an entry for the
+// default account and for each of Accounts
+func (cts *Server) AccessPolicy() []*pb.ResourceAccount {
+	account, acc, _ := cts.getAccount("")
+	rv := []*pb.ResourceAccount{accountPolicy(account, acc)}
+
+	var names []string
+	for name := range cts.Accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rv = append(rv, accountPolicy(name, cts.Accounts[name]))
+	}
+
+	return rv
+}
@@ -17,7 +17,12 @@ func (cts *Server) handleAdd(vlog *verifiable.Log, r *http.Request) (interface{}
 		return nil, verifiable.ErrInvalidRequest
 	}
 
-	dupKey, mtl, extraData, err := cts.InputValidator.ValidateSubmission(vlog, r)
+	_, acc, err := cts.getAccount(vlog.Log.Account.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	dupKey, mtl, extraData, err := acc.InputValidator.ValidateSubmission(vlog, r)
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +37,15 @@ func (cts *Server) handleAdd(vlog *verifiable.Log, r *http.Request) (interface{}
 		return nil, err
 	}
 
+	// Make sure the log is not frozen, and hold off freezing it until we are done
+	cts.lifecycleMutex.RLock()
+	defer cts.lifecycleMutex.RUnlock()
+
+	err = cts.checkWritable(r.Context(), vlog)
+	if err != nil {
+		return nil, err
+	}
+
 	// Now, add it
 	ts := uint64(time.Now().UnixNano() / (1000 * 1000))
 	mtl.TimestampedEntry.Timestamp = ts
@@ -95,6 +109,9 @@ func (cts *Server) handleAdd(vlog *verifiable.Log, r *http.Request) (interface{}
 		return nil, err
 	}
 
+	// Let anyone streaming the log know
+	cts.notifyUpdated(vlog)
+
 	// we're done!
 	return &ct.AddChainResponse{
 		ID:         sk.LogID[:],
@@ -0,0 +1,228 @@
+package generalisedtransparency
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// defaultCacheSize is the number of items we cache if Server.CacheSize is not set
+const defaultCacheSize = 10000
+
+// lruCache is a size-bounded cache that evicts the least recently used items. It is only used for
+// data that never changes once it exists, so items never need to be invalidated.
+type lruCache struct {
+	mutex sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List // front is most recently used
+}
+
+type lruItem struct {
+	key   string
+	value interface{}
+}
+
+func newLRUCache(size int) *lruCache {
+	return &lruCache{
+		size:  size,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+	}
+}
+
+func (c *lruCache) get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruItem).value, true
+}
+
+func (c *lruCache) add(key string, value interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	e, ok := c.items[key]
+	if ok {
+		c.order.MoveToFront(e)
+		e.Value.(*lruItem).value = value
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruItem{key: key, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruItem).key)
+	}
+}
+
+// getCache returns the cache for immutable data, or nil if caching is disabled
+func (cts *Server) getCache() *lruCache {
+	cts.cacheOnce.Do(func() {
+		size := cts.CacheSize
+		if size == 0 {
+			size = defaultCacheSize
+		}
+		if size > 0 {
+			cts.cache = newLRUCache(size)
+		}
+	})
+	return cts.cache
+}
+
+// cacheKey returns the key for an item of the given kind for vlog
+func cacheKey(vlog *verifiable.Log, kind string, args ...interface{}) string {
+	parts := []string{vlog.Log.Account.Id, vlog.Log.Name, kind}
+	for _, a := range args {
+		parts = append(parts, fmt.Sprint(a))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// getEntry returns the entry at idx
+func (cts *Server) getEntry(ctx context.Context, vlog *verifiable.Log, idx int64) (*ct.LeafEntry, error) {
+	key := cacheKey(vlog, "entry", idx)
+	if v, ok := cts.getCache().get(key); ok {
+		return v.(*ct.LeafEntry), nil
+	}
+
+	entry, err := vlog.Entry(ctx, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &ct.LeafEntry{
+		LeafInput: entry.LeafInput,
+		ExtraData: entry.ExtraData,
+	}
+	cts.getCache().add(key, rv)
+	return rv, nil
+}
+
+// getEntries returns the entries in [start, end), or fewer if the log is not that large
+func (cts *Server) getEntries(ctx context.Context, vlog *verifiable.Log, start, end int64) []*ct.LeafEntry {
+	var rv []*ct.LeafEntry
+
+	// Use what we have cached, until we find one we don't
+	idx := start
+	for ; idx < end; idx++ {
+		v, ok := cts.getCache().get(cacheKey(vlog, "entry", idx))
+		if !ok {
+			break
+		}
+		rv = append(rv, v.(*ct.LeafEntry))
+	}
+
+	if idx < end {
+		for entry := range vlog.Entries(ctx, idx, end) {
+			le := &ct.LeafEntry{
+				LeafInput: entry.LeafInput,
+				ExtraData: entry.ExtraData,
+			}
+			cts.getCache().add(cacheKey(vlog, "entry", idx), le)
+			rv = append(rv, le)
+			idx++
+		}
+	}
+
+	return rv
+}
+
+// cacheableTreeSize returns true if proofs for treeSize, which the log reported as actualSize, never change.
+// Sizes of 0 or less mean the latest tree to Service, and if the size is larger than the log, its proof
+// would be for another size.
+func cacheableTreeSize(treeSize, actualSize int64) bool {
+	return treeSize > 0 && treeSize == actualSize
+}
+
+// getInclusionProof returns the inclusion proof for the leaf hash in the tree of the given size, and whether
+// it will never change, as per cacheableTreeSize
+func (cts *Server) getInclusionProof(ctx context.Context, vlog *verifiable.Log, treeSize int64, leafHash []byte) (*ct.GetProofByHashResponse, bool, error) {
+	key := cacheKey(vlog, "proof-by-hash", treeSize, string(leafHash))
+	if treeSize > 0 {
+		if v, ok := cts.getCache().get(key); ok {
+			return v.(*ct.GetProofByHashResponse), true, nil
+		}
+	}
+
+	proof, err := vlog.InclusionProof(ctx, treeSize, leafHash)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rv := &ct.GetProofByHashResponse{
+		LeafIndex: proof.LeafIndex,
+		AuditPath: proof.AuditPath,
+	}
+	cacheable := cacheableTreeSize(treeSize, proof.TreeSize)
+	if cacheable {
+		cts.getCache().add(key, rv)
+	}
+	return rv, cacheable, nil
+}
+
+// getInclusionProofByIndex returns the inclusion proof for the leaf at idx in the tree of the given size, and
+// whether it will never change
+func (cts *Server) getInclusionProofByIndex(ctx context.Context, vlog *verifiable.Log, treeSize, idx int64) (*ct.GetProofByHashResponse, bool, error) {
+	key := cacheKey(vlog, "proof-by-index", treeSize, idx)
+	if treeSize > 0 {
+		if v, ok := cts.getCache().get(key); ok {
+			return v.(*ct.GetProofByHashResponse), true, nil
+		}
+	}
+
+	proof, err := vlog.InclusionProofByIndex(ctx, treeSize, idx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rv := &ct.GetProofByHashResponse{
+		LeafIndex: proof.LeafIndex,
+		AuditPath: proof.AuditPath,
+	}
+	cacheable := cacheableTreeSize(treeSize, proof.TreeSize)
+	if cacheable {
+		cts.getCache().add(key, rv)
+	}
+	return rv, cacheable, nil
+}
+
+// getConsistencyProof returns the consistency proof between two tree sizes, and whether it will never change
+func (cts *Server) getConsistencyProof(ctx context.Context, vlog *verifiable.Log, first, second int64) ([][]byte, bool, error) {
+	key := cacheKey(vlog, "consistency", first, second)
+	if second > 0 {
+		if v, ok := cts.getCache().get(key); ok {
+			return v.([][]byte), true, nil
+		}
+	}
+
+	proof, err := vlog.ConsistencyProof(ctx, first, second)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cacheable := cacheableTreeSize(second, proof.TreeSize)
+	if cacheable {
+		cts.getCache().add(key, proof.AuditPath)
+	}
+	return proof.AuditPath, cacheable, nil
+}
@@ -0,0 +1,62 @@
+package generalisedtransparency
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+)
+
+func TestImmutableHeadersOnlyForFixedTreeSizes(t *testing.T) {
+	cts := newServiceTestServer(t)
+
+	var leafHash [sha256.Size]byte
+	for i, extraData := range []string{`{"a":1}`, `{"a":2}`} {
+		leaf := ct.CreateObjectHashMerkleTreeLeaf(sha256.Sum256([]byte(extraData)), uint64(i))
+		addTestLeaf(t, cts, "cars", leaf, []byte(extraData))
+		if i == 0 {
+			leafInput, err := tls.Marshal(*leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leafHash = sha256.Sum256(append([]byte{0}, leafInput...))
+		}
+	}
+	hash := url.QueryEscape(base64.StdEncoding.EncodeToString(leafHash[:]))
+
+	handler := cts.CreateRESTHandler()
+	for _, tc := range []struct {
+		path      string
+		immutable bool
+	}{
+		{"/ct/v1/get-sth", false}, // the latest, which will change
+		{"/ct/v1/get-sth?tree_size=2", true},
+		{"/ct/v1/get-sth-consistency?first=1&second=2", true},
+		{"/ct/v1/get-proof-by-hash?tree_size=2&hash=" + hash, true},
+		{"/ct/v1/get-entry-and-proof?tree_size=2&leaf_index=0", true},
+		{"/api/v1/sth", false},
+		{"/api/v1/sth?tree_size=2", true},
+		{"/api/v1/entry?index=0", true},
+		{"/api/v1/entry?index=0&tree_size=2", true},
+		{"/api/v1/proof?tree_size=2&leaf_hash=" + hex.EncodeToString(leafHash[:]), true},
+		{"/api/v1/consistency?first=1&second=2", true},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/dataset/cars"+tc.path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s got status %d: %s", tc.path, rec.Code, rec.Body.String())
+			continue
+		}
+		cc := rec.Header().Get("Cache-Control")
+		if strings.Contains(cc, "immutable") != tc.immutable {
+			t.Errorf("%s got Cache-Control %q, want immutable %t", tc.path, cc, tc.immutable)
+		}
+	}
+}
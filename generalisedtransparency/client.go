@@ -1,11 +1,13 @@
 package generalisedtransparency
 
 import (
+	"bytes"
 	"context"
 	"crypto/x509"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"sync"
 
 	ct "github.com/google/certificate-transparency-go"
@@ -18,6 +20,24 @@ type LogClient struct {
 	URL       string
 	AddAPIKey string
 
+	// AdminAPIKey is sent as the Authorization header for calls to the admin API
+	AdminAPIKey string
+
+	// ReadAuthorization, if set, is sent as the Authorization header when reading from a private log.
+	// It is either a reader's API key, or "Bearer " followed by its token. Readers authenticated by a
+	// client certificate should set it in HTTPClient instead.
+	ReadAuthorization string
+
+	// HTTPClient is used for all requests to the log. If nil, http.DefaultClient is used.
+	// In either case its transport is wrapped so that failed requests are retried as per Retry.
+	HTTPClient *http.Client
+
+	// Retry controls retries and the circuit breaker for requests to the log. The zero value gives sensible defaults.
+	Retry RetryPolicy
+
+	breakerMutex sync.Mutex
+	breaker      *circuitBreaker
+
 	verifierMutex sync.Mutex
 	verifier      *ct.SignatureVerifier
 	pubKeyDER     []byte
@@ -45,11 +65,11 @@ func (c *LogClient) GetAddClient() (AddClient, error) {
 		return c.addClient, nil
 	}
 
-	rv, err := client.New(c.URL, &http.Client{
-		Transport: &authRT{
-			Authorization: c.AddAPIKey,
-		},
-	}, jsonclient.Options{})
+	// add-objecthash is safe to retry, as the server returns the existing SCT for a duplicate
+	rv, err := client.New(c.URL, c.httpClient(&authRT{
+		Authorization: c.AddAPIKey,
+		Base:          c.baseTransport(),
+	}, true), jsonclient.Options{})
 	if err != nil {
 		return nil, err
 	}
@@ -67,8 +87,13 @@ type AuditClient interface {
 	GetRawEntries(ctx context.Context, start, end int64) (*ct.GetEntriesResponse, error)
 }
 
-// GetReadClient returns a client suitable for auditing the log
+// GetReadClient returns a client suitable for auditing the log.
 func (c *LogClient) GetReadClient() (AuditClient, error) {
+	return c.GetReadClientContext(context.Background())
+}
+
+// GetReadClientContext is as GetReadClient. ctx is only used to fetch the log metadata on first use.
+func (c *LogClient) GetReadClientContext(ctx context.Context) (AuditClient, error) {
 	c.readClientMutex.Lock()
 	defer c.readClientMutex.Unlock()
 
@@ -76,12 +101,20 @@ func (c *LogClient) GetReadClient() (AuditClient, error) {
 		return c.readClient, nil
 	}
 
-	_, publicKeyDer, err := c.getVerifierAndDER()
+	_, publicKeyDer, err := c.getVerifierAndDER(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	rv, err := client.New(c.URL, http.DefaultClient, jsonclient.Options{
+	rt := c.baseTransport()
+	if c.ReadAuthorization != "" {
+		rt = &authRT{
+			Authorization: c.ReadAuthorization,
+			Base:          rt,
+		}
+	}
+
+	rv, err := client.New(c.URL, c.httpClient(rt, false), jsonclient.Options{
 		PublicKeyDER: publicKeyDer,
 	})
 	if err != nil {
@@ -92,34 +125,29 @@ func (c *LogClient) GetReadClient() (AuditClient, error) {
 	return rv, nil
 }
 
-// GetVerifier returns a SignatureVerifier
+// GetVerifier returns a SignatureVerifier.
 func (c *LogClient) GetVerifier() (*ct.SignatureVerifier, error) {
-	rv, _, err := c.getVerifierAndDER()
+	return c.GetVerifierContext(context.Background())
+}
+
+// GetVerifierContext is as GetVerifier. ctx is only used to fetch the log metadata on first use.
+func (c *LogClient) GetVerifierContext(ctx context.Context) (*ct.SignatureVerifier, error) {
+	rv, _, err := c.getVerifierAndDER(ctx)
 	if err != nil {
 		return nil, err
 	}
 	return rv, nil
 }
 
-func (c *LogClient) getVerifierAndDER() (*ct.SignatureVerifier, []byte, error) {
+func (c *LogClient) getVerifierAndDER(ctx context.Context) (*ct.SignatureVerifier, []byte, error) {
 	c.verifierMutex.Lock()
 	defer c.verifierMutex.Unlock()
 
 	if c.verifier != nil {
 		return c.verifier, c.pubKeyDER, nil
 	}
-	resp, err := http.Get(c.URL + "/ct/v1/metadata")
-	if err != nil {
-		return nil, nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, nil, errors.New("bad http status code fetching log metadata")
-	}
 
-	var md MetadataResponse
-	err = json.NewDecoder(resp.Body).Decode(&md)
+	md, err := c.GetMetadata(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -140,11 +168,203 @@ func (c *LogClient) getVerifierAndDER() (*ct.SignatureVerifier, []byte, error) {
 	return rv, md.Key, nil
 }
 
+// GetMetadata returns the metadata for the log
+func (c *LogClient) GetMetadata(ctx context.Context) (*MetadataResponse, error) {
+	var md MetadataResponse
+	err := c.getJSON(ctx, "/ct/v1/metadata", nil, &md)
+	if err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
+
+// SetMetadata changes the descriptive metadata for the log, using the admin API, and returns the
+// updated metadata
+func (c *LogClient) SetMetadata(ctx context.Context, req *SetMetadataRequest) (*MetadataResponse, error) {
+	return c.adminCall(ctx, "/admin/v1/set-metadata", req)
+}
+
+// CreateLog creates the log with the given metadata (which may be empty), using the admin API
+func (c *LogClient) CreateLog(ctx context.Context, req *SetMetadataRequest) (*MetadataResponse, error) {
+	return c.adminCall(ctx, "/admin/v1/create", req)
+}
+
+// FreezeLog stops further entries being added to the log, and publishes its final STH, using the admin API
+func (c *LogClient) FreezeLog(ctx context.Context) (*MetadataResponse, error) {
+	return c.adminCall(ctx, "/admin/v1/freeze", struct{}{})
+}
+
+// RetireLog marks the log as retired, freezing it first if needed, using the admin API
+func (c *LogClient) RetireLog(ctx context.Context) (*MetadataResponse, error) {
+	return c.adminCall(ctx, "/admin/v1/retire", struct{}{})
+}
+
+// Webhooks lists the log's webhook subscribers and the status of deliveries to them, using the admin API
+func (c *LogClient) Webhooks(ctx context.Context) (*WebhooksResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, c.URL+"/admin/v1/webhooks", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", c.AdminAPIKey)
+
+	var rv WebhooksResponse
+	err = c.doJSON(ctx, "/admin/v1/webhooks", req, false, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// AddWebhook adds a webhook subscriber to the log, using the admin API. The secret for verifying payloads is
+// returned, and is not available later.
+func (c *LogClient) AddWebhook(ctx context.Context, req *AddWebhookRequest) (*WebhookSubscriber, error) {
+	// Not retried, as that could add the subscriber twice
+	hr, err := newJSONPost(c.URL+"/admin/v1/add-webhook", c.AdminAPIKey, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv WebhookSubscriber
+	err = c.doJSON(ctx, "/admin/v1/add-webhook", hr, false, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// RemoveWebhook removes a webhook subscriber from the log, along with deliveries not yet sent to it, using the admin API
+func (c *LogClient) RemoveWebhook(ctx context.Context, id string) (*WebhooksResponse, error) {
+	var rv WebhooksResponse
+	err := c.postJSON(ctx, "/admin/v1/remove-webhook", c.AdminAPIKey, &RemoveWebhookRequest{ID: id}, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (c *LogClient) adminCall(ctx context.Context, path string, req interface{}) (*MetadataResponse, error) {
+	var md MetadataResponse
+	err := c.postJSON(ctx, path, c.AdminAPIKey, req, &md)
+	if err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
+
+// HTTPError is returned by LogClient when the server responds with an unexpected status code
+type HTTPError struct {
+	Path       string
+	StatusCode int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("bad http status code fetching %s: %d", e.Path, e.StatusCode)
+}
+
+// IsNotFound returns true if err is an HTTPError for a 404 response
+func IsNotFound(err error) bool {
+	he, ok := err.(*HTTPError)
+	return ok && he.StatusCode == http.StatusNotFound
+}
+
+// getJSON fetches path (relative to URL) with optional params, and decodes the JSON response into rv
+func (c *LogClient) getJSON(ctx context.Context, path string, params url.Values, rv interface{}) error {
+	u := c.URL + path
+	if len(params) != 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if c.ReadAuthorization != "" {
+		req.Header.Set("Authorization", c.ReadAuthorization)
+	}
+
+	return c.doJSON(ctx, path, req, false, rv)
+}
+
+// postJSON posts body as JSON to path (relative to URL), with an optional Authorization header,
+// and decodes the JSON response into rv. Only use for requests that are safe to retry.
+func (c *LogClient) postJSON(ctx context.Context, path, authorization string, body, rv interface{}) error {
+	req, err := newJSONPost(c.URL+path, authorization, body)
+	if err != nil {
+		return err
+	}
+
+	return c.doJSON(ctx, path, req, true, rv)
+}
+
+// newJSONPost returns a request to post body as JSON to u, with an optional Authorization header
+func newJSONPost(u, authorization string, body interface{}) (*http.Request, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return req, nil
+}
+
+func (c *LogClient) doJSON(ctx context.Context, path string, req *http.Request, retryPost bool, rv interface{}) error {
+	resp, err := c.httpClient(c.baseTransport(), retryPost).Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{Path: path, StatusCode: resp.StatusCode}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(rv)
+}
+
+// baseTransport returns the transport from HTTPClient, if set, else the default
+func (c *LogClient) baseTransport() http.RoundTripper {
+	if c.HTTPClient != nil && c.HTTPClient.Transport != nil {
+		return c.HTTPClient.Transport
+	}
+	return http.DefaultTransport
+}
+
+// httpClient returns a copy of HTTPClient that sends requests via rt, retrying as per our policy.
+// All clients share a single circuit breaker, since they all talk to the same server.
+func (c *LogClient) httpClient(rt http.RoundTripper, retryPost bool) *http.Client {
+	c.breakerMutex.Lock()
+	if c.breaker == nil {
+		c.breaker = &circuitBreaker{}
+	}
+	breaker := c.breaker
+	c.breakerMutex.Unlock()
+
+	rv := http.Client{}
+	if c.HTTPClient != nil {
+		rv = *c.HTTPClient
+	}
+	rv.Transport = &retryRT{
+		Base:      rt,
+		Policy:    c.Retry,
+		Breaker:   breaker,
+		RetryPost: retryPost,
+	}
+	return &rv
+}
+
 type authRT struct {
 	Authorization string
+	Base          http.RoundTripper
 }
 
 func (a *authRT) RoundTrip(req *http.Request) (*http.Response, error) {
 	req.Header.Set("Authorization", a.Authorization)
-	return http.DefaultTransport.RoundTrip(req)
+	return a.Base.RoundTrip(req)
 }
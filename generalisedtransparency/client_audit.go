@@ -0,0 +1,415 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// GetSTHAtSize fetches the signed tree head for the given tree size (0 for the latest),
+// and verifies its signature.
+func (c *LogClient) GetSTHAtSize(ctx context.Context, treeSize uint64) (*ct.SignedTreeHead, error) {
+	resp, err := c.GetRawSTH(ctx, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if treeSize != 0 && resp.TreeSize != treeSize {
+		return nil, &VerificationError{Err: errors.New("server returned sth for wrong tree size")}
+	}
+
+	return c.VerifySTH(ctx, resp)
+}
+
+// GetRawSTH fetches the signed tree head for the given tree size (0 for the latest), without
+// verifying it, for callers that wish to keep what was received as evidence.
+func (c *LogClient) GetRawSTH(ctx context.Context, treeSize uint64) (*ct.GetSTHResponse, error) {
+	var resp ct.GetSTHResponse
+	err := c.getJSON(ctx, "/ct/v1/get-sth", url.Values{
+		"tree_size": []string{strconv.FormatUint(treeSize, 10)},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VerifySTH verifies the signature on a signed tree head, such as that returned by GetRawSTH
+func (c *LogClient) VerifySTH(ctx context.Context, resp *ct.GetSTHResponse) (*ct.SignedTreeHead, error) {
+	verifier, err := c.GetVerifierContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sth, err := resp.ToSignedTreeHead()
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+
+	err = verifier.VerifySTHSignature(*sth)
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+
+	return sth, nil
+}
+
+// STHHistoryQuery selects a page of STHs from GetSTHHistory. Zero values mean no limit.
+type STHHistoryQuery struct {
+	// Start is the position in the history to start from, usually the Next from a previous page
+	Start int64
+
+	// Count is the most STHs to return. The server applies its own maximum.
+	Count int
+
+	// Bounds, all inclusive
+	MinTreeSize, MaxTreeSize   uint64
+	MinTimestamp, MaxTimestamp uint64
+}
+
+// GetSTHHistory fetches a page of the STHs signed by the log, in the order they were signed.
+// The STHs are not verified.
+func (c *LogClient) GetSTHHistory(ctx context.Context, q *STHHistoryQuery) (*GetSTHHistoryResponse, error) {
+	params := url.Values{
+		"start": []string{strconv.FormatInt(q.Start, 10)},
+	}
+	if q.Count != 0 {
+		params.Set("count", strconv.Itoa(q.Count))
+	}
+	for name, v := range map[string]uint64{
+		"min_tree_size": q.MinTreeSize,
+		"max_tree_size": q.MaxTreeSize,
+		"min_timestamp": q.MinTimestamp,
+		"max_timestamp": q.MaxTimestamp,
+	} {
+		if v != 0 {
+			params.Set(name, strconv.FormatUint(v, 10))
+		}
+	}
+
+	var resp GetSTHHistoryResponse
+	err := c.getJSON(ctx, "/ct/v1/get-sth-history", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetSTHAtTime fetches the STH for the largest tree size that the log had signed at the given
+// time (milliseconds since the epoch), and verifies its signature.
+func (c *LogClient) GetSTHAtTime(ctx context.Context, timestamp uint64) (*ct.SignedTreeHead, error) {
+	var resp GetTreeSizeAtTimeResponse
+	err := c.getJSON(ctx, "/ct/v1/get-tree-size-at-time", url.Values{
+		"timestamp": []string{strconv.FormatUint(timestamp, 10)},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.STH == nil || resp.STH.TreeSize != resp.TreeSize || resp.STH.Timestamp > timestamp {
+		return nil, &VerificationError{Err: errors.New("server returned sth that does not match the time requested")}
+	}
+
+	return c.VerifySTH(ctx, resp.STH)
+}
+
+// GetIndexAtTime fetches the index of the first entry added at or after the given time (milliseconds since
+// the epoch). The result is not verified.
+func (c *LogClient) GetIndexAtTime(ctx context.Context, timestamp uint64) (*GetIndexAtTimeResponse, error) {
+	var resp GetIndexAtTimeResponse
+	err := c.getJSON(ctx, "/ct/v1/get-index-at-time", url.Values{
+		"timestamp": []string{strconv.FormatUint(timestamp, 10)},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.LeafIndex < 0 || resp.LeafIndex > resp.TreeSize || (resp.LeafIndex < resp.TreeSize && resp.Timestamp < timestamp) {
+		return nil, &VerificationError{Err: errors.New("server returned index that does not match the time requested")}
+	}
+	return &resp, nil
+}
+
+// EntriesByTimeQuery selects a page of entries from GetEntriesByTime. Zero values mean no limit.
+type EntriesByTimeQuery struct {
+	// Start is the leaf index to start from, usually the Next from a previous page
+	Start int64
+
+	// Bounds, both inclusive
+	MinTimestamp, MaxTimestamp uint64
+}
+
+// GetEntriesByTime fetches a page of the entries added within a time range, and checks that each is
+// after the last and has a timestamp in the range. Inclusion of the entries is not verified.
+func (c *LogClient) GetEntriesByTime(ctx context.Context, q *EntriesByTimeQuery) (*GetEntriesByTimeResponse, error) {
+	params := url.Values{
+		"start": []string{strconv.FormatInt(q.Start, 10)},
+	}
+	if q.MinTimestamp != 0 {
+		params.Set("min_timestamp", strconv.FormatUint(q.MinTimestamp, 10))
+	}
+	if q.MaxTimestamp != 0 {
+		params.Set("max_timestamp", strconv.FormatUint(q.MaxTimestamp, 10))
+	}
+
+	var resp GetEntriesByTimeResponse
+	err := c.getJSON(ctx, "/ct/v1/get-entries-by-time", params, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	last := q.Start - 1
+	for _, e := range resp.Entries {
+		if e.LeafIndex <= last || e.LeafIndex >= resp.TreeSize {
+			return nil, &VerificationError{Err: errors.New("server returned entries out of order")}
+		}
+		last = e.LeafIndex
+
+		de, err := DecodeEntry(e.LeafIndex, e.LeafInput, nil)
+		if err != nil {
+			return nil, &VerificationError{Err: fmt.Errorf("entry %d: %s", e.LeafIndex, err)}
+		}
+		if de.Timestamp < q.MinTimestamp || (q.MaxTimestamp != 0 && de.Timestamp > q.MaxTimestamp) {
+			return nil, &VerificationError{Err: fmt.Errorf("entry %d is outside the time range", e.LeafIndex)}
+		}
+	}
+	if resp.Next != nil && *resp.Next <= last {
+		return nil, &VerificationError{Err: errors.New("server returned next page that does not follow")}
+	}
+	return &resp, nil
+}
+
+// GetVerifiedConsistency fetches a consistency proof between two (already verified) signed tree heads,
+// and verifies that the second is an append-only extension of the first.
+func (c *LogClient) GetVerifiedConsistency(ctx context.Context, first, second *ct.SignedTreeHead) ([][]byte, error) {
+	var proof [][]byte
+	if first.TreeSize != 0 && first.TreeSize < second.TreeSize {
+		var resp ct.GetSTHConsistencyResponse
+		err := c.getJSON(ctx, "/ct/v1/get-sth-consistency", url.Values{
+			"first":  []string{strconv.FormatUint(first.TreeSize, 10)},
+			"second": []string{strconv.FormatUint(second.TreeSize, 10)},
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		proof = resp.Consistency
+	}
+
+	err := merkle.VerifyConsistency(first.TreeSize, second.TreeSize, first.SHA256RootHash[:], second.SHA256RootHash[:], proof)
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+
+	return proof, nil
+}
+
+// GetVerifiedSCTByObjectHash fetches the SCT issued for an objecthash that has already been added,
+// and verifies that it was issued by this log for that objecthash.
+func (c *LogClient) GetVerifiedSCTByObjectHash(ctx context.Context, hash ct.ObjectHash) (*ct.SignedCertificateTimestamp, error) {
+	var resp ct.AddChainResponse
+	err := c.getJSON(ctx, "/ct/v1/get-objecthash", url.Values{
+		"hash": []string{ct.SHA256Hash(hash).Base64String()},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	sct, err := sctFromAddChainResponse(&resp)
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+
+	tlsSCT, err := tls.Marshal(*sct)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.VerifyObjectHashSCT(ctx, hash, tlsSCT)
+}
+
+// GetVerifiedInclusionByObjectHash fetches the SCT and inclusion proof for an objecthash, and verifies
+// both the SCT and that the entry is included in the (already verified) signed tree head.
+func (c *LogClient) GetVerifiedInclusionByObjectHash(ctx context.Context, hash ct.ObjectHash, sth *ct.SignedTreeHead) (*GetProofByObjectHashResponse, error) {
+	verifier, err := c.GetVerifierContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp GetProofByObjectHashResponse
+	err = c.getJSON(ctx, "/ct/v1/get-proof-by-objecthash", url.Values{
+		"hash":      []string{ct.SHA256Hash(hash).Base64String()},
+		"tree_size": []string{strconv.FormatUint(sth.TreeSize, 10)},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.SCT == nil {
+		return nil, errors.New("no sct returned")
+	}
+
+	sct, err := sctFromAddChainResponse(resp.SCT)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := ct.CreateObjectHashMerkleTreeLeaf(hash, sct.Timestamp)
+	err = verifier.VerifySCTSignature(*sct, ct.LogEntry{Leaf: *leaf})
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+
+	leafHash, err := leafHashForLeaf(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.LeafIndex < 0 {
+		return nil, &VerificationError{Err: errors.New("negative leaf index")}
+	}
+	err = merkle.VerifyInclusion(uint64(resp.LeafIndex), sth.TreeSize, leafHash, resp.AuditPath, sth.SHA256RootHash[:])
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+
+	return &resp, nil
+}
+
+// GetVerifiedEntryAndProof fetches the entry at index along with an inclusion proof, and verifies
+// that it is included in the (already verified) signed tree head.
+func (c *LogClient) GetVerifiedEntryAndProof(ctx context.Context, index uint64, sth *ct.SignedTreeHead) (*ct.GetEntryAndProofResponse, error) {
+	var resp ct.GetEntryAndProofResponse
+	err := c.getJSON(ctx, "/ct/v1/get-entry-and-proof", url.Values{
+		"leaf_index": []string{strconv.FormatUint(index, 10)},
+		"tree_size":  []string{strconv.FormatUint(sth.TreeSize, 10)},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	err = merkle.VerifyInclusion(index, sth.TreeSize, merkle.LeafHash(resp.LeafInput), resp.AuditPath, sth.SHA256RootHash[:])
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+
+	return &resp, nil
+}
+
+// SearchQuery selects a page of the entries with a value for an indexed field from GetVerifiedSearch
+type SearchQuery struct {
+	Field string
+	Value string
+
+	// Start is the position in the results to start from, usually the Next from a previous page
+	Start int64
+
+	// Count is the most entries to return. The server applies its own maximum.
+	Count int
+}
+
+// GetVerifiedSearch fetches a page of the entries with a value for an indexed field, and verifies the STH
+// returned, that each entry is included in it, and that each entry has the value. It cannot verify that
+// no entries with the value have been left out.
+func (c *LogClient) GetVerifiedSearch(ctx context.Context, q *SearchQuery) (*SearchResponse, *ct.SignedTreeHead, error) {
+	params := url.Values{
+		"field": []string{q.Field},
+		"value": []string{q.Value},
+		"start": []string{strconv.FormatInt(q.Start, 10)},
+	}
+	if q.Count != 0 {
+		params.Set("count", strconv.Itoa(q.Count))
+	}
+
+	var resp SearchResponse
+	err := c.getJSON(ctx, "/ct/v1/search", params, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.STH == nil {
+		return nil, nil, &VerificationError{Err: errors.New("server returned no sth")}
+	}
+
+	sth, err := c.VerifySTH(ctx, resp.STH)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last := int64(-1)
+	for _, r := range resp.Results {
+		if r.LeafIndex <= last || uint64(r.LeafIndex) >= sth.TreeSize {
+			return nil, nil, &VerificationError{Err: fmt.Errorf("entry %d is out of order or not within the tree size", r.LeafIndex)}
+		}
+		last = r.LeafIndex
+
+		err = merkle.VerifyInclusion(uint64(r.LeafIndex), sth.TreeSize, merkle.LeafHash(r.LeafInput), r.AuditPath, sth.SHA256RootHash[:])
+		if err != nil {
+			return nil, nil, &VerificationError{Err: fmt.Errorf("entry %d: %s", r.LeafIndex, err)}
+		}
+
+		// The extra data must match the entry for its value to be trusted
+		entry, err := DecodeEntry(r.LeafIndex, r.LeafInput, r.ExtraData)
+		if err == nil {
+			err = entry.Check()
+		}
+		if err != nil {
+			return nil, nil, &VerificationError{Err: fmt.Errorf("entry %d: %s", r.LeafIndex, err)}
+		}
+		if v, ok := IndexValue(entry.Data, q.Field); !ok || v != q.Value {
+			return nil, nil, &VerificationError{Err: fmt.Errorf("entry %d does not have the value searched for", r.LeafIndex)}
+		}
+	}
+
+	return &resp, sth, nil
+}
+
+// VerifyObjectHashSCT verifies that tlsSCT, a TLS encoded SCT such as is saved in a row, is valid
+// for the objecthash and was issued by this log.
+func (c *LogClient) VerifyObjectHashSCT(ctx context.Context, hash ct.ObjectHash, tlsSCT []byte) (*ct.SignedCertificateTimestamp, error) {
+	var sct ct.SignedCertificateTimestamp
+	remaining, err := tls.Unmarshal(tlsSCT, &sct)
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+	if len(remaining) != 0 {
+		return nil, &VerificationError{Err: errors.New("trailing bytes")}
+	}
+
+	verifier, der, err := c.getVerifierAndDER(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logID := sha256.Sum256(der)
+	if sct.LogID.KeyID != logID {
+		return nil, &VerificationError{Err: errors.New("sct was issued by a different log")}
+	}
+
+	err = verifier.VerifySCTSignature(sct, ct.LogEntry{Leaf: *ct.CreateObjectHashMerkleTreeLeaf(hash, sct.Timestamp)})
+	if err != nil {
+		return nil, &VerificationError{Err: err}
+	}
+
+	return &sct, nil
+}
+
+// GossipSTH reports a signed tree head to this log's server, so that it can be compared with
+// what other clients have seen. key is the DER public key of the log that signed sth, or nil
+// if it is this log. Any evidence of a split view that the server finds is returned.
+func (c *LogClient) GossipSTH(ctx context.Context, key []byte, sth *ct.GetSTHResponse) ([]*STHGossipEvidence, error) {
+	var resp AddSTHGossipResponse
+	err := c.postJSON(ctx, "/ct/v1/add-sth-gossip", c.ReadAuthorization, &AddSTHGossipRequest{
+		Key: key,
+		STH: sth,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Evidence, nil
+}
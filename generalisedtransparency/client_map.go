@@ -0,0 +1,128 @@
+package generalisedtransparency
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/google/certificate-transparency-go/tls"
+
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// VerifyMapHead verifies that a map head was signed by the log
+func (c *LogClient) VerifyMapHead(ctx context.Context, smh *SignedMapHead) error {
+	verifier, err := c.GetVerifierContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var sig tls.DigitallySigned
+	remaining, err := tls.Unmarshal(smh.Signature, &sig)
+	if err != nil {
+		return &VerificationError{Err: err}
+	}
+	if len(remaining) != 0 {
+		return &VerificationError{Err: errors.New("trailing bytes")}
+	}
+
+	err = verifier.VerifySignature(smh.SignatureInput(), sig)
+	if err != nil {
+		return &VerificationError{Err: err}
+	}
+	return nil
+}
+
+// GetVerifiedMapHead fetches the head of the log's verifiable map once entries before treeSize were mapped
+// (0 for the latest), and verifies its signature.
+func (c *LogClient) GetVerifiedMapHead(ctx context.Context, treeSize uint64) (*SignedMapHead, error) {
+	params := url.Values{}
+	if treeSize != 0 {
+		params.Set("tree_size", strconv.FormatUint(treeSize, 10))
+	}
+
+	var smh SignedMapHead
+	err := c.getJSON(ctx, "/map/v1/get-smh", params, &smh)
+	if err != nil {
+		return nil, err
+	}
+	if treeSize != 0 && smh.TreeSize != treeSize {
+		return nil, &VerificationError{Err: errors.New("server returned map head for wrong tree size")}
+	}
+
+	err = c.VerifyMapHead(ctx, &smh)
+	if err != nil {
+		return nil, err
+	}
+	return &smh, nil
+}
+
+// GetVerifiedMapValue fetches the value for key in the log's verifiable map, as of the map head for treeSize
+// (0 for the latest), and verifies the map head, the map inclusion proof, and if the key is in the map,
+// that the entry it identifies is included in the log and has the key. The entry is returned, or nil if
+// the key is not in the map.
+func (c *LogClient) GetVerifiedMapValue(ctx context.Context, key string, treeSize uint64) (*GetMapValueResponse, *DecodedEntry, error) {
+	params := url.Values{
+		"key": []string{key},
+	}
+	if treeSize != 0 {
+		params.Set("tree_size", strconv.FormatUint(treeSize, 10))
+	}
+
+	var resp GetMapValueResponse
+	err := c.getJSON(ctx, "/map/v1/get-value", params, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.SMH == nil || resp.Key != key {
+		return nil, nil, &VerificationError{Err: errors.New("server returned value for wrong key")}
+	}
+	if treeSize != 0 && resp.SMH.TreeSize != treeSize {
+		return nil, nil, &VerificationError{Err: errors.New("server returned map head for wrong tree size")}
+	}
+
+	err = c.VerifyMapHead(ctx, resp.SMH)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = merkle.VerifyMapInclusion([]byte(key), resp.Value, resp.AuditPath, resp.SMH.MapRootHash)
+	if err != nil {
+		return nil, nil, &VerificationError{Err: err}
+	}
+	if len(resp.Value) == 0 {
+		return &resp, nil, nil
+	}
+
+	var v MapValue
+	err = json.Unmarshal(resp.Value, &v)
+	if err != nil {
+		return nil, nil, &VerificationError{Err: err}
+	}
+	leafHash := merkle.LeafHash(resp.LeafInput)
+	if v.LeafIndex < 0 || uint64(v.LeafIndex) >= resp.SMH.TreeSize || !bytes.Equal(leafHash, v.LeafHash) {
+		return nil, nil, &VerificationError{Err: errors.New("entry does not match map value")}
+	}
+	err = merkle.VerifyInclusion(uint64(v.LeafIndex), resp.SMH.TreeSize, leafHash, resp.LogAuditPath, resp.SMH.LogRootHash)
+	if err != nil {
+		return nil, nil, &VerificationError{Err: err}
+	}
+
+	// The extra data must match the entry for its key to be trusted
+	entry, err := DecodeEntry(v.LeafIndex, resp.LeafInput, resp.ExtraData)
+	if err == nil {
+		err = entry.Check()
+	}
+	if err != nil {
+		return nil, nil, &VerificationError{Err: fmt.Errorf("entry %d: %s", v.LeafIndex, err)}
+	}
+	if k, ok := IndexValue(entry.Data, resp.SMH.KeyField); !ok || k != key {
+		return nil, nil, &VerificationError{Err: fmt.Errorf("entry %d does not have the key", v.LeafIndex)}
+	}
+
+	return &resp, entry, nil
+}
@@ -0,0 +1,86 @@
+package generalisedtransparency
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// StreamEvent is an event from a log's stream. Event is "entry", with an APIEntry as Data and its index as ID,
+// or "sth", with an APISTH as Data.
+type StreamEvent struct {
+	Event string
+	ID    string
+	Data  []byte
+}
+
+// Stream connects to the log's stream of new entries and STHs, calling f for each event, starting with entries
+// from start, or if negative, from the current tree size. It returns when ctx is done, the connection fails,
+// or f returns an error. To resume, call again with one more than the ID of the last entry event received.
+func (c *LogClient) Stream(ctx context.Context, start int64, f func(*StreamEvent) error) error {
+	path := "/api/v1/stream"
+	u := c.URL + path
+	if start >= 0 {
+		u += "?start=" + strconv.FormatInt(start, 10)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.ReadAuthorization != "" {
+		req.Header.Set("Authorization", c.ReadAuthorization)
+	}
+
+	// The stream lasts as long as ctx, so any timeout on the client would cut it short. The retry policy's
+	// AttemptTimeout only bounds waiting for the stream to start, as it asks for text/event-stream.
+	hc := c.httpClient(c.baseTransport(), false)
+	hc.Timeout = 0
+
+	resp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{Path: path, StatusCode: resp.StatusCode}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 16*1024*1024)
+	ev := &StreamEvent{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// Blank lines end an event
+			if ev.Event != "" {
+				err = f(ev)
+				if err != nil {
+					return err
+				}
+			}
+			ev = &StreamEvent{}
+		case strings.HasPrefix(line, ":"):
+			// comment, such as a keepalive
+		case strings.HasPrefix(line, "event: "):
+			ev.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "id: "):
+			ev.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if ev.Data != nil {
+				ev.Data = append(ev.Data, '\n')
+			}
+			ev.Data = append(ev.Data, strings.TrimPrefix(line, "data: ")...)
+		}
+	}
+	if scanner.Err() != nil {
+		return scanner.Err()
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,311 @@
+package generalisedtransparency
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// The decoded API, under /dataset/{logname}/api/v1, returns the same data as the RFC6962 API, but with
+// entries decoded and hashes hex encoded, so that it is easy to use without TLS decoding.
+
+// HexBytes is bytes that are hex encoded in JSON, as hashes are commonly displayed
+type HexBytes []byte
+
+// MarshalJSON encodes as a hex string
+func (h HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+// UnmarshalJSON decodes a hex string
+func (h *HexBytes) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+	*h, err = hex.DecodeString(s)
+	return err
+}
+
+func hexList(l [][]byte) []HexBytes {
+	rv := make([]HexBytes, len(l))
+	for i, b := range l {
+		rv[i] = b
+	}
+	return rv
+}
+
+// APIEntry is an entry as returned by the decoded API
+type APIEntry struct {
+	// Index is the 0-based index of the entry in the log
+	Index int64 `json:"index"`
+
+	// Timestamp is from the MerkleTreeLeaf, in milliseconds since the epoch
+	Timestamp uint64 `json:"timestamp"`
+
+	// EntryType is "objecthash" or "cms"
+	EntryType string `json:"entry_type"`
+
+	// ObjectHash is set for objecthash entries
+	ObjectHash HexBytes `json:"object_hash,omitempty"`
+
+	// Signer is the subject common name of the certificate that signed a CMS entry
+	Signer string `json:"signer,omitempty"`
+
+	// Data is the object that was hashed for objecthash entries, or the signed content for CMS entries, if JSON
+	Data json.RawMessage `json:"data,omitempty"`
+
+	// LeafHash is the Merkle tree leaf hash of the entry
+	LeafHash HexBytes `json:"leaf_hash"`
+}
+
+// APISTH is a signed tree head as returned by the decoded API
+type APISTH struct {
+	// TreeSize is the number of entries in the tree
+	TreeSize uint64 `json:"tree_size"`
+
+	// Timestamp is when the STH was signed, in milliseconds since the epoch
+	Timestamp uint64 `json:"timestamp"`
+
+	// RootHash is the Merkle tree root hash
+	RootHash HexBytes `json:"root_hash"`
+
+	// Signature is the TLS encoded signature, as per the RFC6962 API, so it is left base64 encoded
+	Signature []byte `json:"signature"`
+}
+
+// APIEntriesResponse is returned by the decoded API entries call
+type APIEntriesResponse struct {
+	Entries []*APIEntry `json:"entries"`
+}
+
+// APIEntryResponse is returned by the decoded API entry call. The proof is only included if tree_size is given.
+type APIEntryResponse struct {
+	Entry *APIEntry `json:"entry"`
+
+	// TreeSize is the size of the tree that AuditPath proves inclusion in
+	TreeSize int64 `json:"tree_size,omitempty"`
+
+	// AuditPath is the inclusion proof for the entry
+	AuditPath []HexBytes `json:"audit_path,omitempty"`
+}
+
+// APIProofResponse is returned by the decoded API proof call
+type APIProofResponse struct {
+	// LeafIndex is the 0-based index of the entry in the log
+	LeafIndex int64 `json:"leaf_index"`
+
+	// TreeSize is the size of the tree that AuditPath proves inclusion in
+	TreeSize int64 `json:"tree_size"`
+
+	// LeafHash is the Merkle tree leaf hash of the entry
+	LeafHash HexBytes `json:"leaf_hash"`
+
+	// AuditPath is the inclusion proof for the entry
+	AuditPath []HexBytes `json:"audit_path"`
+}
+
+// APIConsistencyResponse is returned by the decoded API consistency call
+type APIConsistencyResponse struct {
+	First  int64 `json:"first"`
+	Second int64 `json:"second"`
+
+	// Consistency is the consistency proof between the two tree sizes
+	Consistency []HexBytes `json:"consistency"`
+}
+
+// apiEntry decodes an entry, with fields the caller may not see redacted
+func apiEntry(idx int64, entry *ct.LeafEntry, fields []string) (*APIEntry, error) {
+	extraData, err := redactExtraData(entry.LeafInput, entry.ExtraData, fields)
+	if err != nil {
+		return nil, err
+	}
+	de, err := DecodeEntry(idx, entry.LeafInput, extraData)
+	if err != nil {
+		return nil, err
+	}
+	return &APIEntry{
+		Index:      de.Index,
+		Timestamp:  de.Timestamp,
+		EntryType:  de.EntryType,
+		ObjectHash: de.ObjectHash,
+		Signer:     de.Signer,
+		Data:       de.Data,
+		LeafHash:   merkle.LeafHash(entry.LeafInput),
+	}, nil
+}
+
+func (cts *Server) handleAPISTH(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	treeSize, err := optionalInt64(r, "tree_size", verifiable.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	sth, err := cts.getSTH(r.Context(), vlog, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &APISTH{
+		TreeSize:  sth.TreeSize,
+		Timestamp: sth.Timestamp,
+		RootHash:  sth.SHA256RootHash,
+		Signature: sth.TreeHeadSignature,
+	}
+	if cacheableTreeSize(treeSize, int64(sth.TreeSize)) {
+		return immutable(rv), nil
+	}
+	return rv, nil
+}
+
+func (cts *Server) handleAPIEntries(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	start, err := strconv.ParseInt(r.FormValue("start"), 10, 64)
+	if err != nil || start < 0 {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	// As per get-entries, end is inclusive and we return at most maxEntriesToReturn
+	end, err := optionalInt64(r, "end", start+maxEntriesToReturn-1)
+	if err != nil {
+		return nil, err
+	}
+	if end > start+maxEntriesToReturn-1 {
+		end = start + maxEntriesToReturn - 1
+	}
+
+	fields := allowedFields(r)
+	rv := &APIEntriesResponse{}
+	for i, entry := range cts.getEntries(r.Context(), vlog, start, end+1) {
+		e, err := apiEntry(start+int64(i), entry, fields)
+		if err != nil {
+			return nil, err
+		}
+		rv.Entries = append(rv.Entries, e)
+	}
+	if len(rv.Entries) == 0 {
+		return nil, verifiable.ErrInvalidRange
+	}
+
+	if int64(len(rv.Entries)) == end-start+1 {
+		return immutable(rv), nil
+	}
+	return rv, nil
+}
+
+func (cts *Server) handleAPIEntry(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	idx, err := strconv.ParseInt(r.FormValue("index"), 10, 64)
+	if err != nil || idx < 0 {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	treeSize, err := optionalInt64(r, "tree_size", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := cts.getEntry(r.Context(), vlog, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := apiEntry(idx, entry, allowedFields(r))
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a proof, the entry never changes
+	rv := &APIEntryResponse{Entry: e}
+	cacheable := true
+	if treeSize != 0 {
+		var proof *ct.GetProofByHashResponse
+		proof, cacheable, err = cts.getInclusionProofByIndex(r.Context(), vlog, treeSize, idx)
+		if err != nil {
+			return nil, err
+		}
+		rv.TreeSize = treeSize
+		rv.AuditPath = hexList(proof.AuditPath)
+	}
+
+	if cacheable {
+		return immutable(rv), nil
+	}
+	return rv, nil
+}
+
+func (cts *Server) handleAPIProof(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	treeSize, err := strconv.ParseInt(r.FormValue("tree_size"), 10, 64)
+	if err != nil || treeSize <= 0 {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	// Either the objecthash of an entry, or a leaf hash, may be given
+	var leafHash []byte
+	switch {
+	case r.FormValue("object_hash") != "":
+		hash, err := hex.DecodeString(r.FormValue("object_hash"))
+		if err != nil {
+			return nil, verifiable.ErrInvalidRequest
+		}
+		_, leafHash, err = cts.findObjectHashLeaf(r.Context(), vlog, hash)
+		if err != nil {
+			return nil, err
+		}
+	case r.FormValue("leaf_hash") != "":
+		leafHash, err = hex.DecodeString(r.FormValue("leaf_hash"))
+		if err != nil {
+			return nil, verifiable.ErrInvalidRequest
+		}
+	default:
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	proof, cacheable, err := cts.getInclusionProof(r.Context(), vlog, treeSize, leafHash)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &APIProofResponse{
+		LeafIndex: proof.LeafIndex,
+		TreeSize:  treeSize,
+		LeafHash:  leafHash,
+		AuditPath: hexList(proof.AuditPath),
+	}
+	if cacheable {
+		return immutable(rv), nil
+	}
+	return rv, nil
+}
+
+func (cts *Server) handleAPIConsistency(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	first, err := strconv.ParseInt(r.FormValue("first"), 10, 64)
+	if err != nil || first < 0 {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	second, err := strconv.ParseInt(r.FormValue("second"), 10, 64)
+	if err != nil || second <= 0 {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	proof, cacheable, err := cts.getConsistencyProof(r.Context(), vlog, first, second)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &APIConsistencyResponse{
+		First:       first,
+		Second:      second,
+		Consistency: hexList(proof),
+	}
+	if cacheable {
+		return immutable(rv), nil
+	}
+	return rv, nil
+}
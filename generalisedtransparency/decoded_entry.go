@@ -0,0 +1,190 @@
+package generalisedtransparency
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/fullsailor/pkcs7"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+)
+
+// ErrUnsupportedEntryType is returned by Check for entry types whose contents we do not know how to verify
+var ErrUnsupportedEntryType = errors.New("unsupported entry type")
+
+// cmsEntryType is the entry type used for leaves created by ct.CreateCMSMerkleTreeLeaf
+var cmsEntryType = ct.CreateCMSMerkleTreeLeaf(nil, 0).TimestampedEntry.EntryType
+
+// DecodedEntry is a log entry with the TLS encoded MerkleTreeLeaf and extra data decoded,
+// suitable for consumers that do not wish to deal with RFC6962 encodings.
+type DecodedEntry struct {
+	// Index is the 0-based index of the entry in the log
+	Index int64 `json:"index"`
+
+	// Timestamp is from the MerkleTreeLeaf, in milliseconds since the epoch
+	Timestamp uint64 `json:"timestamp"`
+
+	// EntryType is "objecthash" for objecthash entries, "cms" for CMS signed data entries, else a description of the type
+	EntryType string `json:"entry_type"`
+
+	// ObjectHash is set for objecthash entries
+	ObjectHash []byte `json:"object_hash,omitempty"`
+
+	// CMS is the DER encoded signed data for CMS entries
+	CMS []byte `json:"cms,omitempty"`
+
+	// Signer is the subject common name of the certificate that signed a CMS entry
+	Signer string `json:"signer,omitempty"`
+
+	// Data is the extra data for objecthash entries, which is the object that was hashed,
+	// or the signed content for CMS entries, if that is JSON
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// DecodeEntry decodes an entry as returned by get-entries
+func DecodeEntry(index int64, leafInput, extraData []byte) (*DecodedEntry, error) {
+	var leaf ct.MerkleTreeLeaf
+	remaining, err := tls.Unmarshal(leafInput, &leaf)
+	if err != nil {
+		return nil, err
+	}
+	if len(remaining) != 0 {
+		return nil, errors.New("trailing bytes")
+	}
+	if leaf.TimestampedEntry == nil {
+		return nil, errors.New("leaf has no timestamped entry")
+	}
+
+	rv := &DecodedEntry{
+		Index:     index,
+		Timestamp: leaf.TimestampedEntry.Timestamp,
+		EntryType: leaf.TimestampedEntry.EntryType.String(),
+	}
+
+	switch leaf.TimestampedEntry.EntryType {
+	case ct.XObjectHashLogEntryType:
+		rv.EntryType = "objecthash"
+		rv.ObjectHash = leaf.TimestampedEntry.ObjectHash[:]
+	case cmsEntryType:
+		rv.EntryType = "cms"
+		rv.CMS, err = cmsFromLeafInput(leafInput, rv.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		sd, err := pkcs7.Parse(rv.CMS)
+		if err != nil {
+			return nil, err
+		}
+		if signer := sd.GetOnlySigner(); signer != nil {
+			rv.Signer = signer.Subject.CommonName
+		}
+		if json.Valid(sd.Content) {
+			rv.Data = sd.Content
+		}
+		// CMS entries have no extra data
+		return rv, nil
+	}
+
+	if len(extraData) != 0 {
+		if !json.Valid(extraData) {
+			return nil, errors.New("extra data is not valid JSON")
+		}
+		rv.Data = extraData
+	}
+
+	return rv, nil
+}
+
+// cmsFromLeafInput extracts the signed data from a CMS leaf. Rather than depend on how the
+// entry is represented, we find where the data is placed by creating a leaf ourselves,
+// and then check that re-creating the leaf with the data found gives the original.
+func cmsFromLeafInput(leafInput []byte, timestamp uint64) ([]byte, error) {
+	marker := []byte("\x00cms-marker\x00")
+	encoded, err := tls.Marshal(*ct.CreateCMSMerkleTreeLeaf(marker, timestamp))
+	if err != nil {
+		return nil, err
+	}
+	offset := bytes.Index(encoded, marker)
+	if offset < 0 || len(leafInput) < offset {
+		return nil, errors.New("unable to locate cms data in leaf")
+	}
+
+	// Find the width of the length prefix, which will be the widest that reads as the marker length
+	width := 0
+	for w := 1; w <= 4 && w <= offset; w++ {
+		if readUint(encoded[offset-w:offset]) == uint64(len(marker)) {
+			width = w
+		}
+	}
+	if width == 0 {
+		return nil, errors.New("unable to locate cms data length in leaf")
+	}
+
+	l := readUint(leafInput[offset-width : offset])
+	if uint64(offset)+l > uint64(len(leafInput)) {
+		return nil, errors.New("cms data length out of range")
+	}
+	rv := leafInput[offset : offset+int(l)]
+
+	check, err := tls.Marshal(*ct.CreateCMSMerkleTreeLeaf(rv, timestamp))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(check, leafInput) {
+		return nil, errors.New("cms leaf does not re-encode to the same leaf input")
+	}
+
+	return rv, nil
+}
+
+// readUint reads a big-endian unsigned integer
+func readUint(b []byte) uint64 {
+	var rv uint64
+	for _, c := range b {
+		rv = rv<<8 | uint64(c)
+	}
+	return rv
+}
+
+// Check verifies the entry is internally consistent: for objecthash entries that the data matches the hash,
+// and for CMS entries that the content is correctly signed by the certificate within.
+// Note that for CMS entries, the certificate chain is not verified, as that is the job of the log on submission.
+func (e *DecodedEntry) Check() error {
+	switch e.EntryType {
+	case "objecthash":
+		return e.CheckObjectHash()
+	case "cms":
+		sd, err := pkcs7.Parse(e.CMS)
+		if err != nil {
+			return err
+		}
+		return sd.Verify()
+	default:
+		return ErrUnsupportedEntryType
+	}
+}
+
+// CheckObjectHash verifies that the objecthash of Data matches ObjectHash, allowing for redacted fields
+func (e *DecodedEntry) CheckObjectHash() error {
+	if e.ObjectHash == nil {
+		return errors.New("not an objecthash entry")
+	}
+
+	var data interface{}
+	err := json.Unmarshal(e.Data, &data)
+	if err != nil {
+		return err
+	}
+
+	expected, err := RedactableObjectHash(data)
+	if err != nil {
+		return err
+	}
+
+	if string(expected[:]) != string(e.ObjectHash) {
+		return errors.New("object hash does not match data")
+	}
+
+	return nil
+}
@@ -0,0 +1,386 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	govpb "github.com/govau/verifiable-logs/pb"
+)
+
+// The entry time index lists the timestamp of each entry in a log, in index order, so that entries can be found
+// by when they were added without reading them all. Timestamps are assigned before entries are sequenced, so
+// they are not necessarily in order, and the least and greatest in each chunk are kept to narrow searches. It is
+// built in the background by RunEntryTimeIndex, and queries answer from what has been indexed so far.
+
+const (
+	// entryTimeIndexChunkSize is the number of timestamps stored in each chunk of the index
+	entryTimeIndexChunkSize = 1024
+
+	// entryTimeIndexBatchSize is the most entries added to the index in one update
+	entryTimeIndexBatchSize = 1000
+
+	// entryTimeIndexPollInterval is how often we check logs for entries to index, in case they were added by
+	// another server process
+	entryTimeIndexPollInterval = 10 * time.Second
+
+	// maxEntryTimeScan limits how many timestamps one get-entries-by-time request will look at, in chunks
+	// that may hold entries in the range, so that sparse matches do not scan the whole index
+	maxEntryTimeScan = 10 * entryTimeIndexChunkSize
+)
+
+var entryTimeIndexMetadataKey = []byte("entrytimeindex")
+
+// noTimestamp is indexed for an entry that cannot be decoded, and is never in range of a query
+const noTimestamp = -1
+
+func entryTimeIndexChunkKey(chunk int64) []byte {
+	return append([]byte("entrytimeindexchunk"), toIntBinary(uint64(chunk))...)
+}
+
+// GetIndexAtTimeResponse gives the index of the first indexed entry with a timestamp at or after a time
+type GetIndexAtTimeResponse struct {
+	// LeafIndex is the index of the first of the TreeSize indexed entries with a timestamp at or after the
+	// time. Entries are not necessarily in timestamp order, so a later entry may have an earlier timestamp.
+	// If there is none, it is TreeSize.
+	LeafIndex int64 `json:"leaf_index"`
+
+	// Timestamp is that of the entry at LeafIndex, if there is one
+	Timestamp uint64 `json:"timestamp,omitempty"`
+
+	// TreeSize is the number of entries that were searched
+	TreeSize int64 `json:"tree_size"`
+}
+
+// EntryByTime is an entry returned by get-entries-by-time
+type EntryByTime struct {
+	LeafIndex int64  `json:"leaf_index"`
+	LeafInput []byte `json:"leaf_input"`
+	ExtraData []byte `json:"extra_data"`
+}
+
+// GetEntriesByTimeResponse is a page of the entries added within a time range, in index order
+type GetEntriesByTimeResponse struct {
+	Entries []*EntryByTime `json:"entries"`
+
+	// TreeSize is the number of entries that were searched
+	TreeSize int64 `json:"tree_size"`
+
+	// Next is the start parameter to fetch the next page, if there may be more
+	Next *int64 `json:"next,omitempty"`
+}
+
+// appendEntryTimeIndex adds timestamps for the entries from md.Count to the index
+func appendEntryTimeIndex(ctx context.Context, kw verifiable.KeyWriter, md *govpb.EntryTimeIndexMetadata, timestamps []int64) error {
+	for len(timestamps) != 0 {
+		chunkNumber := md.Count / entryTimeIndexChunkSize
+		var chunk govpb.EntryTimeIndexChunk
+		err := kw.Get(ctx, entryTimeIndexChunkKey(chunkNumber), &chunk)
+		switch err {
+		case nil, verifiable.ErrNoSuchKey:
+			// continue
+		default:
+			return err
+		}
+
+		n := entryTimeIndexChunkSize - len(chunk.Timestamps)
+		if n > len(timestamps) {
+			n = len(timestamps)
+		}
+		for _, ts := range timestamps[:n] {
+			if int64(len(md.ChunkMin)) == chunkNumber {
+				// Until there is a timestamp, the chunk cannot be in range of a query
+				md.ChunkMin = append(md.ChunkMin, math.MaxInt64)
+				md.ChunkMax = append(md.ChunkMax, noTimestamp)
+			}
+			if ts == noTimestamp {
+				continue
+			}
+			if ts < md.ChunkMin[chunkNumber] {
+				md.ChunkMin[chunkNumber] = ts
+			}
+			if ts > md.ChunkMax[chunkNumber] {
+				md.ChunkMax[chunkNumber] = ts
+			}
+		}
+		chunk.Timestamps = append(chunk.Timestamps, timestamps[:n]...)
+		err = kw.Set(ctx, entryTimeIndexChunkKey(chunkNumber), &chunk)
+		if err != nil {
+			return err
+		}
+
+		md.Count += int64(n)
+		timestamps = timestamps[n:]
+	}
+
+	return kw.Set(ctx, entryTimeIndexMetadataKey, md)
+}
+
+// getEntryTimeIndexMetadata returns the metadata for the time index of a log, which is empty if nothing has
+// been indexed yet
+func (cts *Server) getEntryTimeIndexMetadata(ctx context.Context, vlog *verifiable.Log) (*govpb.EntryTimeIndexMetadata, error) {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	var md govpb.EntryTimeIndexMetadata
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		return kr.Get(ctx, entryTimeIndexMetadataKey, &md)
+	})
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		return &md, nil
+	default:
+		return nil, err
+	}
+}
+
+// RunEntryTimeIndex indexes the timestamps of entries in each log until ctx is done. Entries added by this server
+// are indexed straight away, and those added by others within entryTimeIndexPollInterval. It is safe to run in
+// more than one server process.
+func (cts *Server) RunEntryTimeIndex(ctx context.Context) {
+	wake := cts.newWaker()
+
+	for {
+		err := cts.forEachDirectoryLog(ctx, "entry time index", func(e *govpb.LogDirectoryEntry) bool {
+			return true
+		}, cts.updateEntryTimeIndex)
+		if err != nil {
+			log.Println("error indexing entry times:", err)
+		}
+
+		select {
+		case <-wake:
+		case <-time.After(entryTimeIndexPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// updateEntryTimeIndex adds entries to the time index, in batches, until it is up to date with the log. For a
+// log that existed before the index did, the first update reads every entry.
+func (cts *Server) updateEntryTimeIndex(ctx context.Context, vlog *verifiable.Log) error {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return err
+	}
+
+	for {
+		md, err := cts.getEntryTimeIndexMetadata(ctx, vlog)
+		if err != nil {
+			return err
+		}
+
+		root, err := vlog.TreeHead(ctx, verifiable.Head)
+		if err != nil {
+			return err
+		}
+		if md.Count >= root.TreeSize {
+			return nil
+		}
+
+		start := md.Count
+		end := start + entryTimeIndexBatchSize
+		if end > root.TreeSize {
+			end = root.TreeSize
+		}
+		entries := cts.getEntries(ctx, vlog, start, end)
+		if int64(len(entries)) != end-start {
+			return errors.New("fewer entries returned than expected")
+		}
+		timestamps := make([]int64, len(entries))
+		for i, entry := range entries {
+			// An entry that cannot be decoded is skipped rather than holding up those after it
+			de, err := DecodeEntry(start+int64(i), entry.LeafInput, nil)
+			if err != nil {
+				log.Printf("not indexing the time of entry %d of %s/%s: %s\n", start+int64(i), vlog.Log.Account.Id, vlog.Log.Name, err)
+				timestamps[i] = noTimestamp
+				continue
+			}
+			timestamps[i] = int64(de.Timestamp)
+		}
+
+		err = cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+			// Check to see if anyone else has added these
+			var md govpb.EntryTimeIndexMetadata
+			err := kw.Get(ctx, entryTimeIndexMetadataKey, &md)
+			switch err {
+			case nil, verifiable.ErrNoSuchKey:
+				// continue
+			default:
+				return err
+			}
+			if md.Count != start {
+				return nil
+			}
+			return appendEntryTimeIndex(ctx, kw, &md, timestamps)
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (cts *Server) handleIndexAtTime(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	timestamp, err := strconv.ParseInt(r.FormValue("timestamp"), 10, 64)
+	if err != nil || timestamp < 0 {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	// Entries added since the index was last updated are not searched, as TreeSize tells the caller
+	md, err := cts.getEntryTimeIndexMetadata(r.Context(), vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &GetIndexAtTimeResponse{LeafIndex: md.Count, TreeSize: md.Count}
+	err = cts.Reader.ExecuteReadOnly(r.Context(), ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		for c, max := range md.ChunkMax {
+			if max < timestamp {
+				continue
+			}
+
+			var chunk govpb.EntryTimeIndexChunk
+			err := kr.Get(ctx, entryTimeIndexChunkKey(int64(c)), &chunk)
+			if err != nil {
+				return err
+			}
+			for i, ts := range chunk.Timestamps {
+				if ts >= timestamp {
+					rv.LeafIndex = int64(c)*entryTimeIndexChunkSize + int64(i)
+					rv.Timestamp = uint64(ts)
+					return nil
+				}
+			}
+			return verifiable.ErrInternalError // index is inconsistent with its metadata
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+func (cts *Server) handleEntriesByTime(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	start, err := optionalInt64(r, "start", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bounds, both inclusive, as for get-sth-history
+	minTimestamp, err := optionalInt64(r, "min_timestamp", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxTimestamp, err := optionalInt64(r, "max_timestamp", -1)
+	if err != nil {
+		return nil, err
+	}
+	inRange := func(ts int64) bool {
+		return ts != noTimestamp && ts >= minTimestamp && (maxTimestamp == -1 || ts <= maxTimestamp)
+	}
+
+	md, err := cts.getEntryTimeIndexMetadata(r.Context(), vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	// suffixMin[c] is the least timestamp in chunk c or later, so that we can stop once no later
+	// entry can be in range
+	suffixMin := make([]int64, len(md.ChunkMin)+1)
+	suffixMin[len(md.ChunkMin)] = -1
+	for c := len(md.ChunkMin) - 1; c >= 0; c-- {
+		suffixMin[c] = md.ChunkMin[c]
+		if suffixMin[c+1] != -1 && suffixMin[c+1] < suffixMin[c] {
+			suffixMin[c] = suffixMin[c+1]
+		}
+	}
+
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &GetEntriesByTimeResponse{Entries: []*EntryByTime{}, TreeSize: md.Count}
+	var indices []int64
+	err = cts.Reader.ExecuteReadOnly(r.Context(), ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		scanned := 0
+		for pos := start; pos < md.Count; {
+			c := pos / entryTimeIndexChunkSize
+			if maxTimestamp != -1 && suffixMin[c] > maxTimestamp {
+				return nil
+			}
+			if md.ChunkMax[c] < minTimestamp || (maxTimestamp != -1 && md.ChunkMin[c] > maxTimestamp) {
+				pos = (c + 1) * entryTimeIndexChunkSize
+				continue
+			}
+
+			var chunk govpb.EntryTimeIndexChunk
+			err := kr.Get(ctx, entryTimeIndexChunkKey(c), &chunk)
+			if err != nil {
+				return err
+			}
+			for ; pos < md.Count && pos/entryTimeIndexChunkSize == c; pos++ {
+				if len(indices) == maxEntriesToReturn || scanned == maxEntryTimeScan {
+					next := pos
+					rv.Next = &next
+					return nil
+				}
+				scanned++
+
+				offset := int(pos % entryTimeIndexChunkSize)
+				if offset >= len(chunk.Timestamps) {
+					return verifiable.ErrInternalError // index is inconsistent with its metadata
+				}
+				if inRange(chunk.Timestamps[offset]) {
+					indices = append(indices, pos)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Matches are mostly contiguous, so fetch them in runs
+	fields := allowedFields(r)
+	for i := 0; i < len(indices); {
+		j := i + 1
+		for j < len(indices) && indices[j] == indices[j-1]+1 {
+			j++
+		}
+		entries := cts.getEntries(r.Context(), vlog, indices[i], indices[j-1]+1)
+		if len(entries) != j-i {
+			return nil, errors.New("fewer entries returned than expected")
+		}
+		for k, entry := range entries {
+			extraData, err := redactExtraData(entry.LeafInput, entry.ExtraData, fields)
+			if err != nil {
+				return nil, err
+			}
+			rv.Entries = append(rv.Entries, &EntryByTime{
+				LeafIndex: indices[i+k],
+				LeafInput: entry.LeafInput,
+				ExtraData: extraData,
+			})
+		}
+		i = j
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,280 @@
+package generalisedtransparency
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// exportBatchSize is the number of entries fetched from Service at a time when exporting
+const exportBatchSize = 1000
+
+// ExportHeader is the first line of an NDJSON export, with the STH the entries were exported against
+type ExportHeader struct {
+	STH *ct.GetSTHResponse `json:"sth"`
+}
+
+// ExportTrailer is the last line of a complete NDJSON export, so that a client can tell if it was cut short
+type ExportTrailer struct {
+	Count int64 `json:"count"`
+}
+
+// csvExportTrailer is the value of the first column of the last row of a complete CSV export, which has
+// the number of entries in the second
+const csvExportTrailer = "end"
+
+// entryIterator iterates over a range of entries of a log, fetching them in batches, and stopping
+// once ctx is done, so that an export stops when the client goes away
+type entryIterator struct {
+	ctx   context.Context
+	vlog  *verifiable.Log
+	next  int64 // index of the next entry to return
+	end   int64 // exclusive
+	batch []*ct.LeafEntry
+	entry *ct.LeafEntry
+	err   error
+}
+
+func newEntryIterator(ctx context.Context, vlog *verifiable.Log, start, end int64) *entryIterator {
+	return &entryIterator{
+		ctx:  ctx,
+		vlog: vlog,
+		next: start,
+		end:  end,
+	}
+}
+
+// Next advances to the next entry, returning false when there are no more, or on error
+func (it *entryIterator) Next() bool {
+	if it.err != nil || it.next >= it.end {
+		return false
+	}
+
+	if len(it.batch) == 0 {
+		it.err = it.ctx.Err()
+		if it.err != nil {
+			return false
+		}
+
+		last := it.next + exportBatchSize
+		if last > it.end {
+			last = it.end
+		}
+		for entry := range it.vlog.Entries(it.ctx, it.next, last) {
+			it.batch = append(it.batch, &ct.LeafEntry{
+				LeafInput: entry.LeafInput,
+				ExtraData: entry.ExtraData,
+			})
+		}
+		if int64(len(it.batch)) != last-it.next {
+			it.err = errors.New("fewer entries returned than expected")
+			return false
+		}
+	}
+
+	it.entry, it.batch = it.batch[0], it.batch[1:]
+	it.next++
+	return true
+}
+
+// Entry returns the index and value of the current entry
+func (it *entryIterator) Entry() (int64, *ct.LeafEntry) {
+	return it.next - 1, it.entry
+}
+
+// Err returns the error that stopped iteration, if any
+func (it *entryIterator) Err() error {
+	return it.err
+}
+
+// handleExport streams the decoded entries in a range, as NDJSON or CSV, without the limit of get-entries.
+// Entries are exported against an STH, either that for tree_size or the latest, and only entries within
+// that tree may be exported.
+func (cts *Server) handleExport(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	format := r.FormValue("format")
+	var contentType string
+	switch format {
+	case "", "ndjson":
+		format, contentType = "ndjson", "application/x-ndjson"
+	case "csv":
+		contentType = "text/csv"
+	default:
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	sizeToFetch := verifiable.Head
+	if ts := r.FormValue("tree_size"); ts != "" {
+		var err error
+		sizeToFetch, err = strconv.ParseInt(ts, 10, 64)
+		if err != nil || sizeToFetch < 0 {
+			return nil, verifiable.ErrInvalidRequest
+		}
+	}
+	sth, err := cts.getSTH(r.Context(), vlog, sizeToFetch)
+	if err != nil {
+		return nil, err
+	}
+
+	// As per get-entries, end is inclusive, and both default to the whole tree
+	start, end := int64(0), int64(sth.TreeSize)-1
+	if s := r.FormValue("start"); s != "" {
+		start, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, verifiable.ErrInvalidRequest
+		}
+	}
+	if s := r.FormValue("end"); s != "" {
+		end, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, verifiable.ErrInvalidRequest
+		}
+	}
+	// An empty tree has no range to export from, but still gets the header, so that it can be verified
+	empty := sth.TreeSize == 0 && r.FormValue("start") == "" && r.FormValue("end") == ""
+	if !empty && (start < 0 || end < start || end >= int64(sth.TreeSize)) {
+		return nil, verifiable.ErrInvalidRange
+	}
+	count := end - start + 1
+	if empty {
+		count = 0
+	}
+
+	fields := allowedFields(r)
+	header := make(http.Header)
+	header.Set("Content-Type", contentType)
+	header.Set("X-Tree-Size", strconv.FormatUint(sth.TreeSize, 10))
+	header.Set("X-Tree-Timestamp", strconv.FormatUint(sth.Timestamp, 10))
+	header.Set("X-Tree-Root-Hash", base64.StdEncoding.EncodeToString(sth.SHA256RootHash))
+	header.Set("X-Tree-Head-Signature", base64.StdEncoding.EncodeToString(sth.TreeHeadSignature))
+	header.Set("X-Entry-Count", strconv.FormatInt(count, 10))
+
+	return &streamedResponse{
+		Header: header,
+		Write: func(w http.ResponseWriter) error {
+			it := newEntryIterator(r.Context(), vlog, start, end+1)
+			if format == "csv" {
+				return writeCSVExport(w, it, fields)
+			}
+			return writeNDJSONExport(w, it, sth, fields)
+		},
+	}, nil
+}
+
+// exportEntry returns the decoded entry, with fields the caller may not see redacted
+func exportEntry(it *entryIterator, fields []string) (*DecodedEntry, error) {
+	idx, entry := it.Entry()
+	extraData, err := redactExtraData(entry.LeafInput, entry.ExtraData, fields)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeEntry(idx, entry.LeafInput, extraData)
+}
+
+// flushBatch flushes what we have written to the client, once per batch of entries
+func flushBatch(w http.ResponseWriter, idx int64, flush func() error) error {
+	if (idx+1)%exportBatchSize != 0 {
+		return nil
+	}
+	err := flush()
+	if err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// writeNDJSONExport writes an ExportHeader line, followed by a DecodedEntry per line, and an ExportTrailer line
+func writeNDJSONExport(w http.ResponseWriter, it *entryIterator, sth *ct.GetSTHResponse, fields []string) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	err := enc.Encode(&ExportHeader{STH: sth})
+	if err != nil {
+		return err
+	}
+
+	var count int64
+
+	for it.Next() {
+		de, err := exportEntry(it, fields)
+		if err != nil {
+			return err
+		}
+		err = enc.Encode(de)
+		if err != nil {
+			return err
+		}
+		count++
+		err = flushBatch(w, de.Index, bw.Flush)
+		if err != nil {
+			return err
+		}
+	}
+	if it.Err() != nil {
+		return it.Err()
+	}
+
+	err = enc.Encode(&ExportTrailer{Count: count})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeCSVExport writes a header row, followed by a row per entry, and a trailer row with the number of entries.
+// The STH is only in the response headers.
+func writeCSVExport(w http.ResponseWriter, it *entryIterator, fields []string) error {
+	cw := csv.NewWriter(w)
+	flush := func() error {
+		cw.Flush()
+		return cw.Error()
+	}
+
+	err := cw.Write([]string{"index", "timestamp", "entry_type", "object_hash", "data"})
+	if err != nil {
+		return err
+	}
+
+	var count int64
+
+	for it.Next() {
+		de, err := exportEntry(it, fields)
+		if err != nil {
+			return err
+		}
+		err = cw.Write([]string{
+			strconv.FormatInt(de.Index, 10),
+			strconv.FormatUint(de.Timestamp, 10),
+			de.EntryType,
+			base64.StdEncoding.EncodeToString(de.ObjectHash),
+			string(de.Data),
+		})
+		if err != nil {
+			return err
+		}
+		count++
+		err = flushBatch(w, de.Index, flush)
+		if err != nil {
+			return err
+		}
+	}
+	if it.Err() != nil {
+		return it.Err()
+	}
+
+	err = cw.Write([]string{csvExportTrailer, strconv.FormatInt(count, 10), "", "", ""})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
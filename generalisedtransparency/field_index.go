@@ -0,0 +1,535 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+	govpb "github.com/govau/verifiable-logs/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The field index maps the values of configured top-level fields of entries to the indices of the entries
+// with that value. It is kept per log, and built in the background by RunFieldIndex, both for entries as they
+// are added and, when a field is newly configured, for those already in the log.
+
+const (
+	// fieldIndexChunkSize is the number of entry indices stored in each chunk of the list for a value
+	fieldIndexChunkSize = 1024
+
+	// fieldIndexBatchSize is the most entries indexed in one update
+	fieldIndexBatchSize = 1000
+
+	// fieldIndexPollInterval is how often we check logs for entries to index, in case they were added by
+	// another server process
+	fieldIndexPollInterval = 10 * time.Second
+
+	// maxIndexFields limits the fields indexed per log, as each adds to the work of indexing every entry
+	maxIndexFields = 20
+
+	// defaultSearchCount and maxSearchCount limit the results returned by one search request
+	defaultSearchCount = 100
+	maxSearchCount     = 1000
+)
+
+var fieldIndexStateKey = []byte("fieldindex")
+
+// fieldIndexValueHash identifies a value of a field in the index. The generation is included so that a field
+// that is no longer indexed, and is then indexed again, does not see what was indexed before.
+func fieldIndexValueHash(idx *govpb.FieldIndex, value string) []byte {
+	b := append(toIntBinary(uint64(idx.Generation)), idx.Field...)
+	b = append(b, 0)
+	h := sha256.Sum256(append(b, value...))
+	return h[:]
+}
+
+func fieldIndexValueKey(valueHash []byte) []byte {
+	return append([]byte("fieldindexvalue"), valueHash...)
+}
+
+func fieldIndexChunkKey(valueHash []byte, chunk int64) []byte {
+	return append(append([]byte("fieldindexchunk"), valueHash...), toIntBinary(uint64(chunk))...)
+}
+
+// SearchResult is an entry matching a search, with an inclusion proof against the STH in the response
+type SearchResult struct {
+	LeafIndex int64  `json:"leaf_index"`
+	LeafInput []byte `json:"leaf_input"`
+	ExtraData []byte `json:"extra_data"`
+
+	AuditPath [][]byte `json:"audit_path"`
+}
+
+// SearchResponse is a page of the entries with a value for an indexed field, in the order they were added
+type SearchResponse struct {
+	// STH is the latest STH, which Results are proved to be included in
+	STH *ct.GetSTHResponse `json:"sth"`
+
+	// IndexedTreeSize is the tree size up to which entries have been indexed for the field. If it is less than
+	// the size of STH, entries added since may be missing from the results.
+	IndexedTreeSize int64 `json:"indexed_tree_size"`
+
+	Results []*SearchResult `json:"results"`
+
+	// Next is the start parameter to fetch the next page, if there may be more
+	Next *int64 `json:"next,omitempty"`
+}
+
+// IndexValue returns the value of the top-level field of data that is indexed, and false if there is none.
+// Strings are indexed as they are, and numbers, true and false as their JSON text. Other values,
+// including null, are not indexed.
+func IndexValue(data []byte, field string) (string, bool) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return "", false
+	}
+	raw, ok := fields[field]
+	if !ok {
+		return "", false
+	}
+
+	var v interface{}
+	if json.Unmarshal(raw, &v) != nil {
+		return "", false
+	}
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64, bool:
+		return string(raw), true
+	default:
+		return "", false
+	}
+}
+
+// validIndexFields returns true if fields are non-empty and distinct, and not too many
+func validIndexFields(fields []string) bool {
+	if len(fields) > maxIndexFields {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if f == "" || seen[f] {
+			return false
+		}
+		seen[f] = true
+	}
+	return true
+}
+
+// getFieldIndexState returns the state of the field index for a log, which is empty if no fields have been indexed
+func (cts *Server) getFieldIndexState(ctx context.Context, vlog *verifiable.Log) (*govpb.FieldIndexState, error) {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	var fis govpb.FieldIndexState
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		return kr.Get(ctx, fieldIndexStateKey, &fis)
+	})
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		return &fis, nil
+	default:
+		return nil, err
+	}
+}
+
+// setIndexFields changes the fields indexed for a log to those in fields. Fields that were already indexed keep
+// their progress, and new ones start from the beginning of the log, so that existing entries are backfilled.
+func (cts *Server) setIndexFields(ctx context.Context, vlog *verifiable.Log, fields []string) error {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return err
+	}
+
+	err = cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		var fis govpb.FieldIndexState
+		err := kw.Get(ctx, fieldIndexStateKey, &fis)
+		switch err {
+		case nil, verifiable.ErrNoSuchKey:
+			// continue
+		default:
+			return err
+		}
+
+		existing := make(map[string]*govpb.FieldIndex)
+		for _, idx := range fis.Indexes {
+			existing[idx.Field] = idx
+		}
+
+		// Values indexed for fields that are dropped are left in storage, but as they are keyed by
+		// generation, they are never seen again
+		var indexes []*govpb.FieldIndex
+		for _, f := range fields {
+			idx, ok := existing[f]
+			if !ok {
+				fis.NextGeneration++
+				idx = &govpb.FieldIndex{
+					Field:      f,
+					Generation: fis.NextGeneration,
+				}
+			}
+			indexes = append(indexes, idx)
+		}
+		fis.Indexes = indexes
+
+		return kw.Set(ctx, fieldIndexStateKey, &fis)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = cts.updateLogDirectory(ctx, vlog, func(e *govpb.LogDirectoryEntry) bool {
+		indexed := len(fields) != 0
+		if e.Indexed == indexed {
+			return false
+		}
+		e.Indexed = indexed
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// Start the backfill now, rather than on the next poll
+	cts.notifyUpdated(vlog)
+	return nil
+}
+
+// RunFieldIndex indexes entries for logs with fields configured to index until ctx is done. Entries added by
+// this server are indexed straight away, and those added by others within fieldIndexPollInterval. It is safe to
+// run in more than one server process.
+func (cts *Server) RunFieldIndex(ctx context.Context) {
+	wake := cts.newWaker()
+
+	for {
+		err := cts.forEachDirectoryLog(ctx, "field index", func(e *govpb.LogDirectoryEntry) bool {
+			return e.Indexed
+		}, cts.indexFields)
+		if err != nil {
+			log.Println("error indexing fields:", err)
+		}
+
+		select {
+		case <-wake:
+		case <-time.After(fieldIndexPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// indexFields indexes entries for each field configured for a log, in batches, until all are up to date
+func (cts *Server) indexFields(ctx context.Context, vlog *verifiable.Log) error {
+	for {
+		fis, err := cts.getFieldIndexState(ctx, vlog)
+		if err != nil {
+			return err
+		}
+		if len(fis.Indexes) == 0 {
+			return nil
+		}
+
+		root, err := vlog.TreeHead(ctx, verifiable.Head)
+		if err != nil {
+			return err
+		}
+
+		// Fields being backfilled are behind the others, so start from the one furthest behind, and
+		// catch the others up as the batch reaches them
+		start := root.TreeSize
+		for _, idx := range fis.Indexes {
+			if idx.IndexedTreeSize < start {
+				start = idx.IndexedTreeSize
+			}
+		}
+		if start >= root.TreeSize {
+			return nil
+		}
+		end := start + fieldIndexBatchSize
+		if end > root.TreeSize {
+			end = root.TreeSize
+		}
+
+		entries := cts.getEntries(ctx, vlog, start, end)
+		if int64(len(entries)) != end-start {
+			return errors.New("fewer entries returned than expected")
+		}
+		// An entry that cannot be decoded never will be, so it is left out of the index rather than
+		// holding up those after it
+		data := make([][]byte, len(entries))
+		for i, entry := range entries {
+			de, err := DecodeEntry(start+int64(i), entry.LeafInput, entry.ExtraData)
+			if err != nil {
+				log.Printf("not indexing entry %d of %s/%s: %s\n", start+int64(i), vlog.Log.Account.Id, vlog.Log.Name, err)
+				continue
+			}
+			data[i] = de.Data
+		}
+
+		err = cts.indexBatch(ctx, vlog, start, data)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// indexBatch adds the entries from start, whose decoded data is given, to the index for each field that has
+// been indexed up to somewhere within them
+func (cts *Server) indexBatch(ctx context.Context, vlog *verifiable.Log, start int64, data [][]byte) error {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return err
+	}
+	end := start + int64(len(data))
+
+	return cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		// Another server may have indexed some or all of these meanwhile, so check again
+		var fis govpb.FieldIndexState
+		err := kw.Get(ctx, fieldIndexStateKey, &fis)
+		switch err {
+		case nil:
+			// continue
+		case verifiable.ErrNoSuchKey:
+			return nil
+		default:
+			return err
+		}
+
+		// Collect the new indices for each value, in order, so that each list is updated once
+		var valueHashes []string
+		indices := make(map[string][]int64)
+		changed := false
+		for _, idx := range fis.Indexes {
+			if idx.IndexedTreeSize < start || idx.IndexedTreeSize >= end {
+				continue
+			}
+			for i := idx.IndexedTreeSize; i < end; i++ {
+				value, ok := IndexValue(data[i-start], idx.Field)
+				if !ok {
+					continue
+				}
+				h := string(fieldIndexValueHash(idx, value))
+				if _, ok := indices[h]; !ok {
+					valueHashes = append(valueHashes, h)
+				}
+				indices[h] = append(indices[h], i)
+			}
+			idx.IndexedTreeSize = end
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+
+		for _, h := range valueHashes {
+			err = appendFieldIndex(ctx, kw, []byte(h), indices[h])
+			if err != nil {
+				return err
+			}
+		}
+
+		return kw.Set(ctx, fieldIndexStateKey, &fis)
+	})
+}
+
+// appendFieldIndex adds indices to the end of the list of entries with a value
+func appendFieldIndex(ctx context.Context, kw verifiable.KeyWriter, valueHash []byte, indices []int64) error {
+	var v govpb.FieldIndexValue
+	err := kw.Get(ctx, fieldIndexValueKey(valueHash), &v)
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		// continue
+	default:
+		return err
+	}
+
+	for len(indices) != 0 {
+		chunkNumber := v.Count / fieldIndexChunkSize
+		var chunk govpb.FieldIndexChunk
+		err = kw.Get(ctx, fieldIndexChunkKey(valueHash, chunkNumber), &chunk)
+		switch err {
+		case nil, verifiable.ErrNoSuchKey:
+			// continue
+		default:
+			return err
+		}
+
+		n := fieldIndexChunkSize - len(chunk.Indices)
+		if n > len(indices) {
+			n = len(indices)
+		}
+		chunk.Indices = append(chunk.Indices, indices[:n]...)
+		err = kw.Set(ctx, fieldIndexChunkKey(valueHash, chunkNumber), &chunk)
+		if err != nil {
+			return err
+		}
+
+		v.Count += int64(n)
+		indices = indices[n:]
+	}
+
+	return kw.Set(ctx, fieldIndexValueKey(valueHash), &v)
+}
+
+// scanFieldIndex returns up to count of the indices of entries with a value, starting from position start
+// in the list, along with the number in the list
+func scanFieldIndex(ctx context.Context, kr verifiable.KeyReader, valueHash []byte, start, count int64) ([]int64, int64, error) {
+	var v govpb.FieldIndexValue
+	err := kr.Get(ctx, fieldIndexValueKey(valueHash), &v)
+	switch err {
+	case nil:
+		// continue
+	case verifiable.ErrNoSuchKey:
+		return nil, 0, nil
+	default:
+		return nil, 0, err
+	}
+
+	var rv []int64
+	var chunk govpb.FieldIndexChunk
+	chunkNumber := int64(-1)
+	for pos := start; pos < v.Count && int64(len(rv)) < count; pos++ {
+		if pos/fieldIndexChunkSize != chunkNumber {
+			chunkNumber = pos / fieldIndexChunkSize
+			chunk = govpb.FieldIndexChunk{}
+			err = kr.Get(ctx, fieldIndexChunkKey(valueHash, chunkNumber), &chunk)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+
+		offset := int(pos % fieldIndexChunkSize)
+		if offset >= len(chunk.Indices) {
+			return nil, 0, verifiable.ErrInternalError // index is inconsistent with its count
+		}
+		rv = append(rv, chunk.Indices[offset])
+	}
+
+	return rv, v.Count, nil
+}
+
+// canSearch returns true if the caller may see field, as otherwise searching would reveal redacted values
+func canSearch(r *http.Request, field string) bool {
+	fields := allowedFields(r)
+	if fields == nil {
+		return true
+	}
+	for _, f := range fields {
+		if f == allFields || f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSearch returns the entries with a value for an indexed field, each with an inclusion proof against
+// the latest STH
+func (cts *Server) handleSearch(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	field := r.FormValue("field")
+	if field == "" {
+		return nil, verifiable.ErrInvalidRequest
+	}
+	if _, ok := r.Form["value"]; !ok {
+		return nil, verifiable.ErrInvalidRequest
+	}
+	value := r.FormValue("value")
+
+	start, err := optionalInt64(r, "start", 0)
+	if err != nil {
+		return nil, err
+	}
+	count, err := optionalInt64(r, "count", defaultSearchCount)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 || count > maxSearchCount {
+		count = maxSearchCount
+	}
+
+	if !canSearch(r, field) {
+		return nil, verifiable.ErrNotAuthorized
+	}
+
+	fis, err := cts.getFieldIndexState(r.Context(), vlog)
+	if err != nil {
+		return nil, err
+	}
+	var idx *govpb.FieldIndex
+	for _, i := range fis.Indexes {
+		if i.Field == field {
+			idx = i
+			break
+		}
+	}
+	if idx == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "field is not indexed: %s", field)
+	}
+
+	sth, err := cts.getSTH(r.Context(), vlog, verifiable.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+	var indices []int64
+	var total int64
+	err = cts.Reader.ExecuteReadOnly(r.Context(), ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		var err error
+		indices, total, err = scanFieldIndex(ctx, kr, fieldIndexValueHash(idx, value), start, count)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &SearchResponse{
+		STH:             sth,
+		IndexedTreeSize: idx.IndexedTreeSize,
+		Results:         []*SearchResult{},
+	}
+	fields := allowedFields(r)
+	for _, i := range indices {
+		// Entries indexed since we fetched the STH are not included in it, nor are any after them
+		if i >= int64(sth.TreeSize) {
+			return rv, nil
+		}
+
+		entry, err := cts.getEntry(r.Context(), vlog, i)
+		if err != nil {
+			return nil, err
+		}
+		extraData, err := redactExtraData(entry.LeafInput, entry.ExtraData, fields)
+		if err != nil {
+			return nil, err
+		}
+		proof, _, err := cts.getInclusionProofByIndex(r.Context(), vlog, int64(sth.TreeSize), i)
+		if err != nil {
+			return nil, err
+		}
+
+		rv.Results = append(rv.Results, &SearchResult{
+			LeafIndex: i,
+			LeafInput: entry.LeafInput,
+			ExtraData: extraData,
+			AuditPath: proof.AuditPath,
+		})
+	}
+
+	if next := start + int64(len(indices)); next < total {
+		rv.Next = &next
+	}
+	return rv, nil
+}
@@ -29,16 +29,25 @@ func (cts *Server) handleGetEntries(vlog *verifiable.Log, r *http.Request) (inte
 		end = lastEntry
 	}
 
+	fields := allowedFields(r)
 	rv := &ct.GetEntriesResponse{}
-	for entry := range vlog.Entries(r.Context(), int64(start), int64(end+1)) { // add one, as underlying API is not inclusive
+	for _, entry := range cts.getEntries(r.Context(), vlog, int64(start), int64(end+1)) { // add one, as underlying API is not inclusive
+		extraData, err := redactExtraData(entry.LeafInput, entry.ExtraData, fields)
+		if err != nil {
+			return nil, err
+		}
 		rv.Entries = append(rv.Entries, ct.LeafEntry{
 			LeafInput: entry.LeafInput,
-			ExtraData: entry.ExtraData,
+			ExtraData: extraData,
 		})
 	}
 	if len(rv.Entries) == 0 { // typically if the size were sent in wrong
 		return nil, verifiable.ErrInvalidRange
 	}
 
+	// If we have all that was asked for, the response will never change
+	if len(rv.Entries) == end-start+1 {
+		return immutable(rv), nil
+	}
 	return rv, nil
 }
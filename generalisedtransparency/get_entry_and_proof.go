@@ -10,7 +10,7 @@ import (
 
 func (cts *Server) handleGetEntryAndProof(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
 	treeSize, err := strconv.Atoi(r.FormValue("tree_size"))
-	if err != nil {
+	if err != nil || treeSize <= 0 {
 		return nil, verifiable.ErrInvalidRequest
 	}
 
@@ -19,19 +19,28 @@ func (cts *Server) handleGetEntryAndProof(vlog *verifiable.Log, r *http.Request)
 		return nil, verifiable.ErrInvalidRequest
 	}
 
-	proof, err := vlog.InclusionProofByIndex(r.Context(), int64(treeSize), int64(leafIndex))
+	proof, cacheable, err := cts.getInclusionProofByIndex(r.Context(), vlog, int64(treeSize), int64(leafIndex))
 	if err != nil {
 		return nil, err
 	}
 
-	entry, err := vlog.Entry(r.Context(), int64(leafIndex))
+	entry, err := cts.getEntry(r.Context(), vlog, int64(leafIndex))
 	if err != nil {
 		return nil, err
 	}
 
-	return &ct.GetEntryAndProofResponse{
+	extraData, err := redactExtraData(entry.LeafInput, entry.ExtraData, allowedFields(r))
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &ct.GetEntryAndProofResponse{
 		LeafInput: entry.LeafInput,
-		ExtraData: entry.ExtraData,
+		ExtraData: extraData,
 		AuditPath: proof.AuditPath,
-	}, nil
+	}
+	if cacheable {
+		return immutable(rv), nil
+	}
+	return rv, nil
 }
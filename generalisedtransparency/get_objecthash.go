@@ -72,3 +72,26 @@ func (cts *Server) findSCT(ctx context.Context, vlog *verifiable.Log, hash []byt
 		Extensions: "",
 	}, nil
 }
+
+// findObjectHashLeaf finds the SCT for an objecthash, and uses its timestamp to rebuild the
+// MerkleTreeLeaf that was added to the log, returning its leaf hash.
+// returns verifiable.ErrNotFound if not found
+func (cts *Server) findObjectHashLeaf(ctx context.Context, vlog *verifiable.Log, hash []byte) (*ct.AddChainResponse, []byte, error) {
+	if len(hash) != sha256.Size {
+		return nil, nil, verifiable.ErrInvalidRequest
+	}
+
+	sct, err := cts.findSCT(ctx, vlog, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var oh ct.ObjectHash
+	copy(oh[:], hash)
+	leafHash, err := leafHashForLeaf(ct.CreateObjectHashMerkleTreeLeaf(oh, sct.Timestamp))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sct, leafHash, nil
+}
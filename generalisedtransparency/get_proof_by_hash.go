@@ -6,12 +6,11 @@ import (
 	"strconv"
 
 	"github.com/continusec/verifiabledatastructures/verifiable"
-	ct "github.com/google/certificate-transparency-go"
 )
 
 func (cts *Server) handleProofByHash(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
 	treeSize, err := strconv.Atoi(r.FormValue("tree_size"))
-	if err != nil {
+	if err != nil || treeSize <= 0 {
 		return nil, verifiable.ErrInvalidRequest
 	}
 
@@ -20,13 +19,13 @@ func (cts *Server) handleProofByHash(vlog *verifiable.Log, r *http.Request) (int
 		return nil, verifiable.ErrInvalidRequest
 	}
 
-	proof, err := vlog.InclusionProof(r.Context(), int64(treeSize), hash)
+	proof, cacheable, err := cts.getInclusionProof(r.Context(), vlog, int64(treeSize), hash)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ct.GetProofByHashResponse{
-		LeafIndex: proof.LeafIndex,
-		AuditPath: proof.AuditPath,
-	}, nil
+	if cacheable {
+		return immutable(proof), nil
+	}
+	return proof, nil
 }
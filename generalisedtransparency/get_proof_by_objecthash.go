@@ -0,0 +1,58 @@
+package generalisedtransparency
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// GetProofByObjectHashResponse is returned by get-proof-by-objecthash
+type GetProofByObjectHashResponse struct {
+	// LeafIndex is the 0-based index of the entry in the log
+	LeafIndex int64 `json:"leaf_index"`
+
+	// Timestamp is that used in the MerkleTreeLeaf, and is the same as that in the SCT
+	Timestamp uint64 `json:"timestamp"`
+
+	// AuditPath is the inclusion proof for the entry
+	AuditPath [][]byte `json:"audit_path"`
+
+	// SCT is the signed certificate timestamp issued when the entry was added
+	SCT *ct.AddChainResponse `json:"sct"`
+}
+
+func (cts *Server) handleProofByObjectHash(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	treeSize, err := strconv.Atoi(r.FormValue("tree_size"))
+	if err != nil || treeSize <= 0 {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(r.FormValue("hash"))
+	if err != nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	sct, leafHash, err := cts.findObjectHashLeaf(r.Context(), vlog, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, cacheable, err := cts.getInclusionProof(r.Context(), vlog, int64(treeSize), leafHash)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &GetProofByObjectHashResponse{
+		LeafIndex: proof.LeafIndex,
+		Timestamp: sct.Timestamp,
+		AuditPath: proof.AuditPath,
+		SCT:       sct,
+	}
+	if cacheable {
+		return immutable(rv), nil
+	}
+	return rv, nil
+}
@@ -0,0 +1,65 @@
+package generalisedtransparency
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+func (cts *Server) handleGetReceipt(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	hash, err := base64.StdEncoding.DecodeString(r.FormValue("hash"))
+	if err != nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	treeSize := int(verifiable.Head)
+	ts := r.FormValue("tree_size")
+	if ts != "" {
+		treeSize, err = strconv.Atoi(ts)
+		if err != nil {
+			return nil, verifiable.ErrInvalidRequest
+		}
+	}
+
+	sct, leafHash, err := cts.findObjectHashLeaf(r.Context(), vlog, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	sth, err := cts.getSTH(r.Context(), vlog, int64(treeSize))
+	if err != nil {
+		return nil, err
+	}
+
+	proof, _, err := cts.getInclusionProof(r.Context(), vlog, int64(sth.TreeSize), leafHash)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := cts.getEntry(r.Context(), vlog, proof.LeafIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	extraData, err := redactExtraData(entry.LeafInput, entry.ExtraData, allowedFields(r))
+	if err != nil {
+		return nil, err
+	}
+
+	sk, err := cts.getSigningKey(r.Context(), vlog, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Receipt{
+		ObjectHash: hash,
+		ExtraData:  extraData,
+		SCT:        sct,
+		LeafIndex:  proof.LeafIndex,
+		AuditPath:  proof.AuditPath,
+		STH:        sth,
+		PublicKey:  sk.PublicDER,
+	}, nil
+}
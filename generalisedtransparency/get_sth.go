@@ -26,43 +26,71 @@ func (cts *Server) handleSTH(vlog *verifiable.Log, r *http.Request) (interface{}
 	if ts != "" {
 		var err error
 		sizeToFetch, err = strconv.Atoi(ts)
-		if err != nil {
+		if err != nil || sizeToFetch < 0 {
 			return nil, verifiable.ErrInvalidRequest
 		}
 	}
 
-	root, err := vlog.TreeHead(r.Context(), int64(sizeToFetch))
+	sth, err := cts.getSTH(r.Context(), vlog, int64(sizeToFetch))
+	if err != nil {
+		return nil, err
+	}
+
+	// There is only ever one STH for a tree size, but the latest (asked for with 0) will change
+	if cacheableTreeSize(int64(sizeToFetch), int64(sth.TreeSize)) {
+		return immutable(sth), nil
+	}
+	return sth, nil
+}
+
+// sthKey is the key in the ctlog namespace for the STH we signed for a tree size
+func sthKey(treeSize int64) []byte {
+	return append([]byte("sth"), toIntBinary(uint64(treeSize))...)
+}
+
+// getSTH returns the signed tree head for the given tree size (or verifiable.Head for the latest),
+// signing and saving a new one if we have not previously been asked for this size.
+func (cts *Server) getSTH(ctx context.Context, vlog *verifiable.Log, sizeToFetch int64) (*ct.GetSTHResponse, error) {
+	if sizeToFetch > 0 {
+		if v, ok := cts.getCache().get(cacheKey(vlog, "sth", sizeToFetch)); ok {
+			return v.(*ct.GetSTHResponse), nil
+		}
+	}
+
+	root, err := vlog.TreeHead(ctx, sizeToFetch)
 	if err != nil {
 		return nil, err
 	}
 
+	sthCacheKey := cacheKey(vlog, "sth", root.TreeSize)
+	if v, ok := cts.getCache().get(sthCacheKey); ok {
+		return v.(*ct.GetSTHResponse), nil
+	}
+
 	// See if we have an STH, and if so, we will return that
 	ns, err := cts.getNs(vlog)
 	if err != nil {
 		return nil, err
 	}
 
-	tsKey := append([]byte("sth"), toIntBinary(uint64(root.TreeSize))...)
+	tsKey := sthKey(root.TreeSize)
 	var sth govpb.SignedTreeHead
-	err = cts.Reader.ExecuteReadOnly(r.Context(), ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
 		return kr.Get(ctx, tsKey, &sth)
 	})
 	switch err {
 	case nil:
 		// we're done!
-		return &ct.GetSTHResponse{
-			TreeSize:          uint64(sth.TreeSize),
-			Timestamp:         uint64(sth.Timestamp),
-			SHA256RootHash:    sth.Sha256RootHash,
-			TreeHeadSignature: sth.TreeHeadSignature,
-		}, nil
+		rv := sthFromPB(&sth)
+		cts.getCache().add(sthCacheKey, rv)
+		return rv, nil
 	case verifiable.ErrNoSuchKey:
 	// pass, continue, we'll make one
 	default:
 		return nil, err
 	}
 
-	sk, err := cts.getSigningKey(r.Context(), vlog, false)
+	sk, err := cts.getSigningKey(ctx, vlog, false)
 	if err != nil {
 		return nil, err
 	}
@@ -97,19 +125,47 @@ func (cts *Server) handleSTH(vlog *verifiable.Log, r *http.Request) (interface{}
 		TreeSize:          root.TreeSize,
 	}
 
-	// Save it out
-	err = cts.Writer.ExecuteUpdate(r.Context(), ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
-		return kw.Set(ctx, tsKey, &sth)
+	// Save it out, and add it to the history
+	saved := false
+	err = cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		// Check to see if another request has signed one for this size meanwhile
+		var existing govpb.SignedTreeHead
+		err := kw.Get(ctx, tsKey, &existing)
+		switch err {
+		case nil:
+			// Use that one instead, so that there is only ever one STH per tree size
+			sth = existing
+			return nil
+		case verifiable.ErrNoSuchKey:
+			// continue, we will save ours
+		default:
+			return err
+		}
+
+		err = kw.Set(ctx, tsKey, &sth)
+		if err != nil {
+			return err
+		}
+		saved = true
+
+		return appendSTHIndex(ctx, kw, &govpb.STHIndexEntry{
+			TreeSize:  sth.TreeSize,
+			Timestamp: sth.Timestamp,
+		})
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if saved {
+		err = cts.updateLatestSTH(ctx, vlog, &sth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// we're done!
-	return &ct.GetSTHResponse{
-		TreeSize:          uint64(sth.TreeSize),
-		Timestamp:         uint64(sth.Timestamp),
-		SHA256RootHash:    sth.Sha256RootHash,
-		TreeHeadSignature: sth.TreeHeadSignature,
-	}, nil
+	rv := sthFromPB(&sth)
+	cts.getCache().add(sthCacheKey, rv)
+	return rv, nil
 }
@@ -10,21 +10,25 @@ import (
 
 func (cts *Server) handleSTHConsistency(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
 	first, err := strconv.Atoi(r.FormValue("first"))
-	if err != nil {
+	if err != nil || first < 0 {
 		return nil, verifiable.ErrInvalidRequest
 	}
 
 	second, err := strconv.Atoi(r.FormValue("second"))
-	if err != nil {
+	if err != nil || second <= 0 {
 		return nil, verifiable.ErrInvalidRequest
 	}
 
-	answer, err := vlog.ConsistencyProof(r.Context(), int64(first), int64(second))
+	proof, cacheable, err := cts.getConsistencyProof(r.Context(), vlog, int64(first), int64(second))
 	if err != nil {
 		return nil, err
 	}
 
-	return &ct.GetSTHConsistencyResponse{
-		Consistency: answer.AuditPath,
-	}, nil
+	rv := &ct.GetSTHConsistencyResponse{
+		Consistency: proof,
+	}
+	if cacheable {
+		return immutable(rv), nil
+	}
+	return rv, nil
 }
@@ -1,11 +1,15 @@
 package generalisedtransparency
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 
-	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -18,15 +22,68 @@ import (
 func (cts *Server) CreateRESTHandler() http.Handler {
 	r := mux.NewRouter()
 
+	// Each account has the same API under its own prefix, with the default account also at the root
+	cts.addRoutes(r.PathPrefix("/account/{account}").Subrouter())
+	cts.addRoutes(r)
+
+	// Make sure we match preflight requests for other paths, so that our CORS middleware answers them
+	r.HandleFunc("/{thing:.*}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}).Methods("OPTIONS")
+
+	// CORS is allowed from any origin for public logs, as we do no cookie auth. POST is for browsers to
+	// gossip STHs they have seen. Logs can restrict the origins allowed, and private logs allow none by default.
+	r.Use(cts.corsMiddleware)
+
+	return r
+}
+
+// addRoutes adds the API, log list and static pages for an account to r
+func (cts *Server) addRoutes(r *mux.Router) {
 	// REST API
-	cts.addCallToRouter(r, "/metadata", cts.ReadAPIKey, true, "GET", cts.handleMetadata)
-	cts.addCallToRouter(r, "/add-objecthash", cts.WriteAPIKey, false, "POST", cts.handleAdd)
-	cts.addCallToRouter(r, "/get-objecthash", cts.ReadAPIKey, true, "GET", cts.handleGetObjectHash)
-	cts.addCallToRouter(r, "/get-sth", cts.ReadAPIKey, true, "GET", cts.handleSTH)
-	cts.addCallToRouter(r, "/get-sth-consistency", cts.ReadAPIKey, true, "GET", cts.handleSTHConsistency)
-	cts.addCallToRouter(r, "/get-proof-by-hash", cts.ReadAPIKey, true, "GET", cts.handleProofByHash)
-	cts.addCallToRouter(r, "/get-entries", cts.ReadAPIKey, true, "GET", cts.handleGetEntries)
-	cts.addCallToRouter(r, "/get-entry-and-proof", cts.ReadAPIKey, true, "GET", cts.handleGetEntryAndProof)
+	cts.addCallToRouter(r, "/metadata", readAccess, true, "GET", cts.handleMetadata)
+	cts.addCallToRouter(r, "/add-objecthash", writeAccess, false, "POST", cts.handleAdd)
+	cts.addCallToRouter(r, "/get-objecthash", readAccess, true, "GET", cts.handleGetObjectHash)
+	cts.addCallToRouter(r, "/get-sth", readAccess, true, "GET", cts.handleSTH)
+	cts.addCallToRouter(r, "/get-sth-history", readAccess, true, "GET", cts.handleSTHHistory)
+	cts.addCallToRouter(r, "/get-tree-size-at-time", readAccess, true, "GET", cts.handleTreeSizeAtTime)
+	cts.addCallToRouter(r, "/get-index-at-time", readAccess, true, "GET", cts.handleIndexAtTime)
+	cts.addCallToRouter(r, "/get-entries-by-time", readAccess, true, "GET", cts.handleEntriesByTime)
+	cts.addCallToRouter(r, "/get-sth-consistency", readAccess, true, "GET", cts.handleSTHConsistency)
+	cts.addCallToRouter(r, "/get-proof-by-hash", readAccess, true, "GET", cts.handleProofByHash)
+	cts.addCallToRouter(r, "/get-proof-by-objecthash", readAccess, true, "GET", cts.handleProofByObjectHash)
+	cts.addCallToRouter(r, "/get-entries", readAccess, true, "GET", cts.handleGetEntries)
+	cts.addCallToRouter(r, "/get-entry-and-proof", readAccess, true, "GET", cts.handleGetEntryAndProof)
+	cts.addCallToRouter(r, "/get-receipt", readAccess, true, "GET", cts.handleGetReceipt)
+	cts.addCallToRouter(r, "/export", readAccess, true, "GET", cts.handleExport)
+	cts.addCallToRouter(r, "/search", readAccess, true, "GET", cts.handleSearch)
+	cts.addCallToRouter(r, "/add-sth-gossip", readAccess, true, "POST", cts.handleAddSTHGossip)
+	cts.addCallToRouter(r, "/get-sth-gossip", readAccess, true, "GET", cts.handleGetSTHGossip)
+	cts.addCallToRouter(r, "/get-sth-gossip-evidence", readAccess, true, "GET", cts.handleGetSTHGossipEvidence)
+
+	// Decoded API, which is the same data as above, for those that don't want to decode RFC6962 structures
+	cts.addAPICallToRouter(r, "/sth", cts.handleAPISTH)
+	cts.addAPICallToRouter(r, "/entries", cts.handleAPIEntries)
+	cts.addAPICallToRouter(r, "/entry", cts.handleAPIEntry)
+	cts.addAPICallToRouter(r, "/proof", cts.handleAPIProof)
+	cts.addAPICallToRouter(r, "/consistency", cts.handleAPIConsistency)
+	cts.addAPICallToRouter(r, "/stream", cts.handleStream)
+
+	// Verifiable map of the latest entry per key
+	cts.addMapCallToRouter(r, "/get-smh", cts.handleGetMapHead)
+	cts.addMapCallToRouter(r, "/get-value", cts.handleGetMapValue)
+
+	// Admin API
+	cts.addAdminCallToRouter(r, "/create", false, "POST", cts.handleCreateLog)
+	cts.addAdminCallToRouter(r, "/set-metadata", true, "POST", cts.handleSetMetadata)
+	cts.addAdminCallToRouter(r, "/freeze", true, "POST", cts.handleFreezeLog)
+	cts.addAdminCallToRouter(r, "/retire", true, "POST", cts.handleRetireLog)
+	cts.addAdminCallToRouter(r, "/webhooks", true, "GET", cts.handleGetWebhooks)
+	cts.addAdminCallToRouter(r, "/add-webhook", true, "POST", cts.handleAddWebhook)
+	cts.addAdminCallToRouter(r, "/remove-webhook", true, "POST", cts.handleRemoveWebhook)
+
+	// Directory of logs
+	r.HandleFunc("/log_list.json", cts.handleLogList).Methods("GET")
 
 	// Static
 	r.HandleFunc("/dataset/{logname}/", cts.staticHandler("text/html", "index.html")).Methods("GET")
@@ -44,18 +101,6 @@ func (cts *Server) CreateRESTHandler() http.Handler {
 	r.HandleFunc("/dataset/{logname}", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, r.URL.RequestURI()+"/", http.StatusMovedPermanently)
 	}).Methods("GET")
-
-	// Make sure we return 200 since handlers below will fall through to us
-	r.HandleFunc("/{thing:.*}", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(200)
-	}).Methods("OPTIONS")
-
-	// Since we do NO cookie or basic auth, allow CORS
-	return handlers.CORS(
-		handlers.AllowedMethods([]string{"GET", "OPTIONS"}),
-		handlers.AllowedOrigins([]string{"*"}),
-		handlers.AllowedHeaders([]string{"Accept", "Content-Type"}),
-	)(r)
 }
 
 func (cts *Server) staticHandler(mime, name string) func(http.ResponseWriter, *http.Request) {
@@ -72,18 +117,45 @@ func (cts *Server) staticHandler(mime, name string) func(http.ResponseWriter, *h
 	}
 }
 
-func (cts *Server) wrapCall(apiKey string, ensureExists bool, f func(log *verifiable.Log, r *http.Request) (interface{}, error)) func(http.ResponseWriter, *http.Request) {
+func (cts *Server) wrapCall(access callAccess, ensureExists bool, f func(log *verifiable.Log, r *http.Request) (interface{}, error)) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Println(r.URL.String())
 
+		account, acc, err := cts.getAccount(mux.Vars(r)["account"])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
 		// Make sure table is a valid to prevent us from making an inadvertent call to the wrong path
-		canonTable, err := cts.TableNameValidator.ValidateAndCanonicaliseTableName(mux.Vars(r)["logname"])
+		canonTable, err := acc.TableNameValidator.ValidateAndCanonicaliseTableName(mux.Vars(r)["logname"])
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		vlog := cts.Service.Account(cts.Account, apiKey).VerifiableLog(canonTable)
+		var apiKey string
+		switch access {
+		case readAccess:
+			// Private logs can only be read by authenticated readers, and readers may not see all fields
+			var fields []string
+			apiKey, fields, err = cts.readAccessFor(r, account, acc, canonTable)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), allowedFieldsKey, fields))
+		case writeAccess:
+			apiKey = acc.WriteAPIKey
+		case adminAccess:
+			if !acc.isAdmin(r) {
+				writeError(w, verifiable.ErrNotAuthorized)
+				return
+			}
+			apiKey = acc.ReadAPIKey
+		}
+
+		vlog := cts.Service.Account(account, apiKey).VerifiableLog(canonTable)
 		if ensureExists {
 			// This is to make sure we don't spuriously create way too many tables in postgresql for logs that don't exists
 			_, err = cts.getSigningKey(r.Context(), vlog, false)
@@ -93,43 +165,203 @@ func (cts *Server) wrapCall(apiKey string, ensureExists bool, f func(log *verifi
 			}
 		}
 		obj, err := f(vlog, r)
-		if err == nil {
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(obj)
+		if err != nil {
+			writeError(w, err)
 			return
 		}
 
-		// Some errors are status code errors
-		s, ok := status.FromError(err)
-		if ok {
-			switch s.Code() {
-			case codes.PermissionDenied:
-				http.Error(w, err.Error(), http.StatusForbidden)
-			case codes.InvalidArgument:
-				http.Error(w, err.Error(), http.StatusBadRequest)
-			case codes.NotFound:
-				http.Error(w, err.Error(), http.StatusNotFound)
-			default:
+		switch v := obj.(type) {
+		case immutableResponse:
+			// Only responses that anyone may read can be cached by shared caches
+			shared := access == readAccess && apiKey == acc.ReadAPIKey
+			writeImmutable(w, r, v.Value, shared)
+		case *streamedResponse:
+			for k, vals := range v.Header {
+				w.Header()[k] = vals
+			}
+			w.WriteHeader(http.StatusOK)
+			err = v.Write(w)
+			if err != nil {
+				// Too late to tell the client, who will see a truncated response
 				log.Println(err)
-				http.Error(w, err.Error(), http.StatusInternalServerError)
 			}
-			return
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(obj)
 		}
+	}
+}
+
+// streamedResponse is returned by API calls whose response is too large to build in memory, and so is
+// written to the client as it is produced
+type streamedResponse struct {
+	// Header is sent before the body
+	Header http.Header
+
+	// Write writes the body, flushing it to the client as it goes
+	Write func(w http.ResponseWriter) error
+}
 
-		switch err {
-		case verifiable.ErrInvalidRequest, verifiable.ErrInvalidRange, verifiable.ErrInvalidTreeRange:
+// immutableResponse is returned by API calls for a response that will never change, such as a proof for
+// a given tree size, so that clients and caches may keep it
+type immutableResponse struct {
+	Value interface{}
+}
+
+// immutable marks v as a response that will never change
+func immutable(v interface{}) immutableResponse {
+	return immutableResponse{Value: v}
+}
+
+// writeImmutable writes v with headers allowing it to be cached forever, and a strong ETag so that a
+// client that has it already gets a 304. If shared is false, only the client may cache it.
+func writeImmutable(w http.ResponseWriter, r *http.Request, v interface{}, shared bool) {
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(v)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	h := sha256.Sum256(body.Bytes())
+	etag := `"` + base64.RawURLEncoding.EncodeToString(h[:]) + `"`
+
+	// Readers may see different fields to others, so the response depends on their credentials
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Authorization")
+	if shared {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body.Bytes())
+}
+
+// etagMatches returns true if the If-None-Match header value matches etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeError writes an error response, with the status code for err
+func writeError(w http.ResponseWriter, err error) {
+	// Some errors are status code errors
+	s, ok := status.FromError(err)
+	if ok {
+		switch s.Code() {
+		case codes.PermissionDenied:
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case codes.InvalidArgument:
 			http.Error(w, err.Error(), http.StatusBadRequest)
-		case verifiable.ErrNotFound:
+		case codes.NotFound:
 			http.Error(w, err.Error(), http.StatusNotFound)
-		case verifiable.ErrNotAuthorized:
-			http.Error(w, err.Error(), http.StatusForbidden)
+		case codes.AlreadyExists, codes.FailedPrecondition:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case codes.ResourceExhausted:
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case codes.Unavailable:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
 		default:
 			log.Println(err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
+		return
+	}
+
+	switch err {
+	case verifiable.ErrInvalidRequest, verifiable.ErrInvalidRange, verifiable.ErrInvalidTreeRange:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case verifiable.ErrNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case verifiable.ErrNotAuthorized:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-func (cts *Server) addCallToRouter(r *mux.Router, path, apiKey string, ensureExists bool, method string, f func(log *verifiable.Log, r *http.Request) (interface{}, error)) {
-	r.HandleFunc("/dataset/{logname}/ct/v1"+path, cts.wrapCall(apiKey, ensureExists, f)).Methods(method)
+// addCallToRouter adds an API call for a log, which also answers CORS preflight requests
+func (cts *Server) addCallToRouter(r *mux.Router, path string, access callAccess, ensureExists bool, method string, f func(log *verifiable.Log, r *http.Request) (interface{}, error)) {
+	r.HandleFunc("/dataset/{logname}/ct/v1"+path, cts.wrapCall(access, ensureExists, f)).Methods(method, "OPTIONS")
+}
+
+// addAPICallToRouter adds a read call for a log to the decoded API
+func (cts *Server) addAPICallToRouter(r *mux.Router, path string, f func(log *verifiable.Log, r *http.Request) (interface{}, error)) {
+	r.HandleFunc("/dataset/{logname}/api/v1"+path, cts.wrapCall(readAccess, true, f)).Methods("GET", "OPTIONS")
+}
+
+// addMapCallToRouter adds a read call for a log's verifiable map
+func (cts *Server) addMapCallToRouter(r *mux.Router, path string, f func(log *verifiable.Log, r *http.Request) (interface{}, error)) {
+	r.HandleFunc("/dataset/{logname}/map/v1"+path, cts.wrapCall(readAccess, true, f)).Methods("GET", "OPTIONS")
+}
+
+func (cts *Server) addAdminCallToRouter(r *mux.Router, path string, ensureExists bool, method string, f func(log *verifiable.Log, r *http.Request) (interface{}, error)) {
+	r.HandleFunc("/dataset/{logname}/admin/v1"+path, cts.wrapCall(adminAccess, ensureExists, f)).Methods(method)
+}
+
+// corsMiddleware adds CORS headers to responses, and answers preflight requests. For requests about a log,
+// the origins allowed are as set in its metadata, otherwise any origin is allowed.
+func (cts *Server) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origins := []string{"*"}
+		if mux.Vars(r)["logname"] != "" {
+			var err error
+			origins, err = cts.logOrigins(r)
+			if err != nil {
+				origins = nil // don't allow any, but let the handler report the error
+			}
+		}
+
+		origin := r.Header.Get("Origin")
+		if origin != "" {
+			w.Header().Add("Vary", "Origin")
+			for _, o := range origins {
+				if o == "*" {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+					break
+				}
+				if o == origin {
+					// Explicitly allowed origins may send credentials, such as a client certificate
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+					break
+				}
+			}
+		}
+
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logOrigins returns the origins allowed for CORS requests about the log named in the request
+func (cts *Server) logOrigins(r *http.Request) ([]string, error) {
+	account, acc, err := cts.getAccount(mux.Vars(r)["account"])
+	if err != nil {
+		return nil, err
+	}
+	canonTable, err := acc.TableNameValidator.ValidateAndCanonicaliseTableName(mux.Vars(r)["logname"])
+	if err != nil {
+		return nil, err
+	}
+	return cts.allowedOrigins(r.Context(), account, canonTable)
 }
@@ -0,0 +1,171 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	govpb "github.com/govau/verifiable-logs/pb"
+)
+
+// checkWritable returns an error if entries may not be added to the log, because it is frozen or
+// retired, or because it has not been created and RequireCreate is set
+func (cts *Server) checkWritable(ctx context.Context, vlog *verifiable.Log) error {
+	md, err := cts.getLogMetadata(ctx, vlog.Log)
+	switch err {
+	case nil:
+		// continue
+	case verifiable.ErrNoSuchKey:
+		if cts.RequireCreate {
+			return verifiable.ErrNotFound
+		}
+		return nil // will be created on add
+	default:
+		return err
+	}
+
+	if md.State != govpb.LogState_LOG_STATE_USABLE {
+		return verifiable.ErrNotAuthorized
+	}
+	return nil
+}
+
+func (cts *Server) handleCreateLog(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	// Metadata is optional
+	var req SetMetadataRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if (err != nil && err != io.EOF) || !req.valid() {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	_, err = cts.getLogMetadata(r.Context(), vlog.Log)
+	switch err {
+	case nil:
+		return nil, status.Error(codes.AlreadyExists, "log already exists")
+	case verifiable.ErrNoSuchKey:
+		// continue, we'll create it
+	default:
+		return nil, err
+	}
+
+	sk, err := cts.getSigningKey(r.Context(), vlog, true)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := cts.updateLogMetadata(r.Context(), vlog, func(md *govpb.LogMetadata) error {
+		req.apply(md)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IndexFields != nil {
+		err = cts.setIndexFields(r.Context(), vlog, *req.IndexFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.MapKeyField != nil {
+		err = cts.setMapKeyField(r.Context(), vlog, *req.MapKeyField)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return metadataResponse(md, sk.PublicDER), nil
+}
+
+// setLogState changes the state of a log from one of the states in from, or does nothing if it is already in to
+func (cts *Server) setLogState(ctx context.Context, vlog *verifiable.Log, to govpb.LogState, from ...govpb.LogState) (*govpb.LogMetadata, error) {
+	return cts.updateLogMetadata(ctx, vlog, func(md *govpb.LogMetadata) error {
+		if md.State == to {
+			return nil
+		}
+		for _, s := range from {
+			if md.State == s {
+				md.State = to
+				md.StateTimestamp = time.Now().UnixNano() / (1000 * 1000)
+				return nil
+			}
+		}
+		return status.Errorf(codes.FailedPrecondition, "log is %s", logStateNames[md.State])
+	})
+}
+
+// freezeLog stops further entries being added, and records the final STH. Caller must hold lifecycleMutex.
+func (cts *Server) freezeLog(ctx context.Context, vlog *verifiable.Log) (*govpb.LogMetadata, error) {
+	// Stop adds first, so that even another server process will not add after the final STH
+	md, err := cts.setLogState(ctx, vlog, govpb.LogState_LOG_STATE_READ_ONLY, govpb.LogState_LOG_STATE_USABLE)
+	if err != nil {
+		return nil, err
+	}
+	if md.FinalSth != nil {
+		return md, nil
+	}
+
+	sth, err := cts.getSTH(ctx, vlog, verifiable.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	return cts.updateLogMetadata(ctx, vlog, func(md *govpb.LogMetadata) error {
+		if md.FinalSth == nil {
+			md.FinalSth = sthToPB(sth)
+		}
+		return nil
+	})
+}
+
+func (cts *Server) handleFreezeLog(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	sk, err := cts.getSigningKey(r.Context(), vlog, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cts.lifecycleMutex.Lock()
+	defer cts.lifecycleMutex.Unlock()
+
+	md, err := cts.freezeLog(r.Context(), vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadataResponse(md, sk.PublicDER), nil
+}
+
+func (cts *Server) handleRetireLog(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	sk, err := cts.getSigningKey(r.Context(), vlog, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cts.lifecycleMutex.Lock()
+	defer cts.lifecycleMutex.Unlock()
+
+	// A retired log is frozen too, so that it has a final STH
+	md, err := cts.getLogMetadata(r.Context(), vlog.Log)
+	if err != nil {
+		return nil, err
+	}
+	if md.State != govpb.LogState_LOG_STATE_RETIRED {
+		_, err = cts.freezeLog(r.Context(), vlog)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	md, err = cts.setLogState(r.Context(), vlog, govpb.LogState_LOG_STATE_RETIRED, govpb.LogState_LOG_STATE_READ_ONLY)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadataResponse(md, sk.PublicDER), nil
+}
@@ -0,0 +1,335 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/gorilla/mux"
+	govpb "github.com/govau/verifiable-logs/pb"
+)
+
+// defaultMaximumMergeDelay is the MMD (in seconds) we advertise. Entries are incorporated as soon
+// as they are added, so this is a generous upper bound.
+const defaultMaximumMergeDelay = 24 * 60 * 60
+
+var logDirectoryKey = []byte("logdirectory")
+
+// LogListOperator is as per https://www.gstatic.com/ct/log_list/log_list_schema.json
+type LogListOperator struct {
+	Name string `json:"name"`
+	ID   int    `json:"id"`
+}
+
+// LogListLog is as per https://www.gstatic.com/ct/log_list/log_list_schema.json, with additional
+// fields describing the state of the log
+type LogListLog struct {
+	Description       string `json:"description"`
+	Key               []byte `json:"key"`
+	URL               string `json:"url"`
+	MaximumMergeDelay int64  `json:"maximum_merge_delay"`
+	OperatedBy        []int  `json:"operated_by"`
+
+	// Name is the log name, as used in /dataset/{logname}
+	Name string `json:"name"`
+
+	// Operator, State, Created and DatasetURL are as per MetadataResponse
+	Operator   string `json:"operator,omitempty"`
+	State      string `json:"state"`
+	Created    uint64 `json:"created,omitempty"`
+	DatasetURL string `json:"dataset_url,omitempty"`
+
+	// TreeSize is the number of entries in the latest STH
+	TreeSize uint64 `json:"tree_size"`
+
+	// LatestSTH is the latest STH signed for the log, if any
+	LatestSTH *ct.GetSTHResponse `json:"latest_sth,omitempty"`
+
+	// FinalSTH is the last STH for a frozen or retired log
+	FinalSTH *ct.GetSTHResponse `json:"final_sth,omitempty"`
+}
+
+// LogList is as per https://www.gstatic.com/ct/log_list/log_list_schema.json
+type LogList struct {
+	Operators []*LogListOperator `json:"operators"`
+	Logs      []*LogListLog      `json:"logs"`
+}
+
+// updateLogDirectory calls f with the directory entry for vlog, creating one if needed, and saves
+// the directory if f returns true
+func (cts *Server) updateLogDirectory(ctx context.Context, vlog *verifiable.Log, f func(e *govpb.LogDirectoryEntry) bool) error {
+	ns, err := metadataNs()
+	if err != nil {
+		return err
+	}
+
+	return cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		var dir govpb.LogDirectory
+		err := kw.Get(ctx, logDirectoryKey, &dir)
+		switch err {
+		case nil, verifiable.ErrNoSuchKey:
+			// continue
+		default:
+			return err
+		}
+
+		var entry *govpb.LogDirectoryEntry
+		for _, e := range dir.Logs {
+			if e.Account == vlog.Log.Account.Id && e.Name == vlog.Log.Name {
+				entry = e
+				break
+			}
+		}
+
+		changed := false
+		if entry == nil {
+			entry = &govpb.LogDirectoryEntry{
+				Account: vlog.Log.Account.Id,
+				Name:    vlog.Log.Name,
+			}
+			dir.Logs = append(dir.Logs, entry)
+			changed = true
+		}
+
+		if f(entry) {
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+
+		return kw.Set(ctx, logDirectoryKey, &dir)
+	})
+}
+
+// latestSTHKey is where the latest STH signed for the log with logKey is kept, in the metadata namespace.
+// It is kept apart from the directory, which would otherwise be rewritten for every STH signed for any log.
+func latestSTHKey(logKey []byte) []byte {
+	return append([]byte("lateststh"), logKey...)
+}
+
+// updateLatestSTH records sth as the latest for vlog if it is later than the one recorded
+func (cts *Server) updateLatestSTH(ctx context.Context, vlog *verifiable.Log, sth *govpb.SignedTreeHead) error {
+	ns, err := metadataNs()
+	if err != nil {
+		return err
+	}
+	logKey, err := makeKeyForLog(vlog.Log)
+	if err != nil {
+		return err
+	}
+
+	return cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		var latest govpb.SignedTreeHead
+		err := kw.Get(ctx, latestSTHKey(logKey), &latest)
+		switch err {
+		case nil:
+			if latest.TreeSize >= sth.TreeSize {
+				return nil
+			}
+		case verifiable.ErrNoSuchKey:
+			// continue
+		default:
+			return err
+		}
+
+		return kw.Set(ctx, latestSTHKey(logKey), sth)
+	})
+}
+
+// forEachDirectoryEntry calls f for each log in the directory, for all accounts, until f returns false
+func (cts *Server) forEachDirectoryEntry(ctx context.Context, f func(e *govpb.LogDirectoryEntry) bool) error {
+	ns, err := metadataNs()
+	if err != nil {
+		return err
+	}
+
+	var dir govpb.LogDirectory
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		return kr.Get(ctx, logDirectoryKey, &dir)
+	})
+	switch err {
+	case nil:
+		// continue
+	case verifiable.ErrNoSuchKey:
+		return nil
+	default:
+		return err
+	}
+
+	for _, e := range dir.Logs {
+		if !f(e) {
+			break
+		}
+	}
+	return nil
+}
+
+// forEachDirectoryLog calls f for each log in the directory that want returns true for, as is done by
+// background processes. An error from one log is logged, prefixed by what, so that it does not hold up others.
+func (cts *Server) forEachDirectoryLog(ctx context.Context, what string, want func(e *govpb.LogDirectoryEntry) bool, f func(ctx context.Context, vlog *verifiable.Log) error) error {
+	var logs []*govpb.LogDirectoryEntry
+	err := cts.forEachDirectoryEntry(ctx, func(e *govpb.LogDirectoryEntry) bool {
+		if want(e) {
+			logs = append(logs, e)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range logs {
+		_, acc, err := cts.getAccount(e.Account)
+		if err == nil {
+			err = f(ctx, cts.Service.Account(e.Account, acc.ReadAPIKey).VerifiableLog(e.Name))
+		}
+		if err != nil {
+			log.Printf("%s for %s/%s: %s\n", what, e.Account, e.Name, err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// logDirectoryEntry is a log in the directory, with its metadata and latest STH (if any)
+type logDirectoryEntry struct {
+	Entry     *govpb.LogDirectoryEntry
+	Metadata  *govpb.LogMetadata
+	LatestSTH *govpb.SignedTreeHead
+}
+
+// getLogDirectory returns the entries for all logs in account
+func (cts *Server) getLogDirectory(ctx context.Context, account string) ([]*logDirectoryEntry, error) {
+	ns, err := metadataNs()
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []*logDirectoryEntry
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		var dir govpb.LogDirectory
+		err := kr.Get(ctx, logDirectoryKey, &dir)
+		switch err {
+		case nil:
+			// continue
+		case verifiable.ErrNoSuchKey:
+			return nil
+		default:
+			return err
+		}
+
+		for _, e := range dir.Logs {
+			if e.Account != account {
+				continue
+			}
+
+			logKey, err := makeKeyForLog(&pb.LogRef{
+				Account: &pb.AccountRef{Id: e.Account},
+				Name:    e.Name,
+			})
+			if err != nil {
+				return err
+			}
+
+			var md govpb.LogMetadata
+			err = kr.Get(ctx, logKey, &md)
+			if err != nil {
+				return err
+			}
+
+			entry := &logDirectoryEntry{Entry: e, Metadata: &md}
+			var sth govpb.SignedTreeHead
+			err = kr.Get(ctx, latestSTHKey(logKey), &sth)
+			switch err {
+			case nil:
+				entry.LatestSTH = &sth
+			case verifiable.ErrNoSuchKey:
+				// continue
+			default:
+				return err
+			}
+
+			rv = append(rv, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (cts *Server) handleLogList(w http.ResponseWriter, r *http.Request) {
+	account, _, err := cts.getAccount(mux.Vars(r)["account"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Logs for the default account are linked without the account prefix, as they were before we had more than one
+	prefix := ""
+	if mux.Vars(r)["account"] != "" {
+		prefix = "/account/" + account
+	}
+
+	entries, err := cts.getLogDirectory(r.Context(), account)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rv := &LogList{
+		Operators: []*LogListOperator{{Name: account, ID: 0}},
+		Logs:      []*LogListLog{},
+	}
+	for _, e := range entries {
+		// Private logs are not listed, as their readers already know of them
+		if e.Metadata.Private {
+			continue
+		}
+
+		md := metadataResponse(e.Metadata, e.Entry.PublicKeyDer)
+
+		description := md.Description
+		if description == "" {
+			description = e.Entry.Name
+		}
+		logURL := md.URL
+		if logURL == "" {
+			logURL = r.Host + prefix + "/dataset/" + e.Entry.Name + "/"
+		} else {
+			logURL = strings.TrimPrefix(strings.TrimPrefix(logURL, "https://"), "http://")
+		}
+
+		l := &LogListLog{
+			Description:       description,
+			Key:               md.Key,
+			URL:               logURL,
+			MaximumMergeDelay: md.MaximumMergeDelay,
+			OperatedBy:        []int{0},
+			Name:              e.Entry.Name,
+			Operator:          md.Operator,
+			State:             md.State,
+			Created:           md.Created,
+			DatasetURL:        md.DatasetURL,
+			FinalSTH:          md.FinalSTH,
+		}
+		if e.LatestSTH != nil {
+			l.TreeSize = uint64(e.LatestSTH.TreeSize)
+			l.LatestSTH = sthFromPB(e.LatestSTH)
+		}
+		rv.Logs = append(rv.Logs, l)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rv)
+}
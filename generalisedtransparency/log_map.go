@@ -0,0 +1,510 @@
+package generalisedtransparency
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"github.com/google/certificate-transparency-go/tls"
+	govpb "github.com/govau/verifiable-logs/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// A log may have a verifiable map, derived from its entries, from the value of a configured top-level field
+// (the key field, with values as per IndexValue) to the latest entry with that value. It is kept in a map of
+// the same name as the log, using the verifiable maps of the continusec library, and updated in the background
+// by RunMaps. Each time a batch of entries is mapped, we sign a map head, which ties the map's root hash to
+// the log's root hash for the entries that it reflects.
+
+const (
+	// mapBatchSize is the most entries mapped before signing a map head
+	mapBatchSize = 1000
+
+	// mapPollInterval is how often we check logs for entries to map, in case they were added by another
+	// server process
+	mapPollInterval = 10 * time.Second
+
+	// mapLease is how long a batch is reserved for the server mapping it, after which another may redo it
+	mapLease = time.Minute
+)
+
+var mapStateKey = []byte("map")
+
+// errMapLeaseLost is returned if another server takes over mapping a batch, as our lease on it expired
+var errMapLeaseLost = errors.New("lease on batch expired, so another server may be mapping it")
+
+func mapHeadKey(treeSize int64) []byte {
+	return append([]byte("maphead"), toIntBinary(uint64(treeSize))...)
+}
+
+// mapHeadSignaturePrefix starts the data signed for a map head, which distinguishes it from the structures
+// signed for STHs and SCTs, as they start with a version of 0
+var mapHeadSignaturePrefix = []byte("verifiable-logs map head v1\x00")
+
+// SignedMapHead is a log's statement of the root hash of its verifiable map once the entries in the tree of
+// TreeSize, with LogRootHash, have been mapped
+type SignedMapHead struct {
+	TreeSize    uint64 `json:"tree_size"`
+	LogRootHash []byte `json:"log_root_hash"`
+
+	// KeyField is the top-level field of entries that keys the map
+	KeyField string `json:"key_field"`
+
+	// MapSize is the size of the map's mutation log, which identifies the map state
+	MapSize     uint64 `json:"map_size"`
+	MapRootHash []byte `json:"map_root_hash"`
+
+	// Timestamp is when the map head was signed, in milliseconds since the epoch
+	Timestamp uint64 `json:"timestamp"`
+
+	// Signature is the TLS encoded signature, by the log's key, of SignatureInput
+	Signature []byte `json:"signature"`
+}
+
+// SignatureInput returns the data that is signed for a map head
+func (h *SignedMapHead) SignatureInput() []byte {
+	var b bytes.Buffer
+	b.Write(mapHeadSignaturePrefix)
+	for _, v := range []uint64{h.TreeSize, h.Timestamp, h.MapSize, uint64(len(h.KeyField))} {
+		binary.Write(&b, binary.BigEndian, v)
+	}
+	b.WriteString(h.KeyField)
+	b.Write(h.LogRootHash)
+	b.Write(h.MapRootHash)
+	return b.Bytes()
+}
+
+// MapValue is the value in a log's verifiable map for a key, identifying the latest entry with that key
+type MapValue struct {
+	LeafIndex int64 `json:"leaf_index"`
+
+	// LeafHash is the Merkle tree leaf hash of the entry
+	LeafHash []byte `json:"leaf_hash"`
+}
+
+// GetMapValueResponse is the value for a key in a log's verifiable map, with a proof against SMH
+type GetMapValueResponse struct {
+	SMH *SignedMapHead `json:"smh"`
+	Key string         `json:"key"`
+
+	// Value is the JSON encoded MapValue for the key, or empty if the key is not in the map
+	Value []byte `json:"value"`
+
+	// AuditPath is the map inclusion proof for Value, which proves non-inclusion if Value is empty
+	AuditPath [][]byte `json:"audit_path"`
+
+	// LeafInput and ExtraData are those of the entry identified by Value, if any
+	LeafInput []byte `json:"leaf_input,omitempty"`
+	ExtraData []byte `json:"extra_data,omitempty"`
+
+	// LogAuditPath proves the inclusion of the entry in the log tree of SMH
+	LogAuditPath [][]byte `json:"log_audit_path,omitempty"`
+}
+
+func mapHeadFromPB(h *govpb.SignedMapHead) *SignedMapHead {
+	return &SignedMapHead{
+		TreeSize:    uint64(h.TreeSize),
+		LogRootHash: h.LogRootHash,
+		KeyField:    h.KeyField,
+		MapSize:     uint64(h.MapSize),
+		MapRootHash: h.MapRootHash,
+		Timestamp:   uint64(h.Timestamp),
+		Signature:   h.Signature,
+	}
+}
+
+// logMap returns the verifiable map for vlog, with the same credentials
+func (cts *Server) logMap(vlog *verifiable.Log) *verifiable.Map {
+	return cts.Service.Account(vlog.Log.Account.Id, vlog.Log.Account.ApiKey).VerifiableMap(vlog.Log.Name)
+}
+
+// updateMapState calls f to modify the map state for a log, and saves it if f returns nil
+func (cts *Server) updateMapState(ctx context.Context, vlog *verifiable.Log, f func(ms *govpb.MapState, kw verifiable.KeyWriter) error) error {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return err
+	}
+
+	return cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		var ms govpb.MapState
+		err := kw.Get(ctx, mapStateKey, &ms)
+		switch err {
+		case nil, verifiable.ErrNoSuchKey:
+			// continue
+		default:
+			return err
+		}
+		err = f(&ms, kw)
+		if err != nil {
+			return err
+		}
+		return kw.Set(ctx, mapStateKey, &ms)
+	})
+}
+
+// getMapState returns the map state for a log, which is empty if it has no map
+func (cts *Server) getMapState(ctx context.Context, vlog *verifiable.Log) (*govpb.MapState, error) {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms govpb.MapState
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		return kr.Get(ctx, mapStateKey, &ms)
+	})
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		return &ms, nil
+	default:
+		return nil, err
+	}
+}
+
+// setMapKeyField starts mapping a log's entries by field, from the beginning of the log. The key field of
+// a map cannot be changed, as the map's history is verifiable.
+func (cts *Server) setMapKeyField(ctx context.Context, vlog *verifiable.Log, field string) error {
+	err := cts.updateMapState(ctx, vlog, func(ms *govpb.MapState, kw verifiable.KeyWriter) error {
+		if ms.KeyField != "" && ms.KeyField != field {
+			return status.Error(codes.FailedPrecondition, "map key field cannot be changed")
+		}
+		ms.KeyField = field
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = cts.updateLogDirectory(ctx, vlog, func(e *govpb.LogDirectoryEntry) bool {
+		if e.Mapped {
+			return false
+		}
+		e.Mapped = true
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	cts.notifyUpdated(vlog)
+	return nil
+}
+
+// RunMaps maps entries into the verifiable maps of logs with a map key field until ctx is done. Entries added by
+// this server are mapped straight away, and those added by others within mapPollInterval. It is safe to run in
+// more than one server process.
+func (cts *Server) RunMaps(ctx context.Context) {
+	wake := cts.newWaker()
+
+	for {
+		err := cts.forEachDirectoryLog(ctx, "map", func(e *govpb.LogDirectoryEntry) bool {
+			return e.Mapped
+		}, cts.mapEntries)
+		if err != nil {
+			log.Println("error mapping entries:", err)
+		}
+
+		select {
+		case <-wake:
+		case <-time.After(mapPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// mapEntries maps entries for a log in batches, signing a map head after each, until it is up to date
+func (cts *Server) mapEntries(ctx context.Context, vlog *verifiable.Log) error {
+	// Setting values needs the write API key
+	account, acc, err := cts.getAccount(vlog.Log.Account.Id)
+	if err != nil {
+		return err
+	}
+	m := cts.Service.Account(account, acc.WriteAPIKey).VerifiableMap(vlog.Log.Name)
+
+	for {
+		root, err := vlog.TreeHead(ctx, verifiable.Head)
+		if err != nil {
+			return err
+		}
+
+		// Reserve the next batch, so that another server does not set values at the same time, which
+		// could leave an older entry as the latest for a key
+		var keyField string
+		var start, lease int64
+		err = cts.updateMapState(ctx, vlog, func(ms *govpb.MapState, kw verifiable.KeyWriter) error {
+			now := millis(time.Now())
+			if ms.KeyField == "" || ms.MappedTreeSize >= root.TreeSize || ms.LeaseUntil > now {
+				return nil
+			}
+			keyField, start = ms.KeyField, ms.MappedTreeSize
+			lease = millis(time.Now().Add(mapLease))
+			ms.LeaseUntil = lease
+			return nil
+		})
+		if err != nil || lease == 0 {
+			return err
+		}
+
+		end := start + mapBatchSize
+		if end > root.TreeSize {
+			end = root.TreeSize
+		}
+		err = cts.mapBatch(ctx, vlog, m, keyField, start, end, lease)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// renewMapLease extends our lease on the batch from start, which is held until lease, returning when it is now
+// held until. If another server has taken it over, errMapLeaseLost is returned.
+func (cts *Server) renewMapLease(ctx context.Context, vlog *verifiable.Log, start, lease int64) (int64, error) {
+	renewed := millis(time.Now().Add(mapLease))
+	err := cts.updateMapState(ctx, vlog, func(ms *govpb.MapState, kw verifiable.KeyWriter) error {
+		// Leases end at a later time each time they are taken, so one that has been taken over won't match
+		if ms.MappedTreeSize != start || ms.LeaseUntil != lease {
+			return errMapLeaseLost
+		}
+		ms.LeaseUntil = renewed
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return renewed, nil
+}
+
+// mapBatch sets the values for the entries in [start, end), then signs a map head and records progress. We must
+// hold the lease on the batch, which is held until lease.
+func (cts *Server) mapBatch(ctx context.Context, vlog *verifiable.Log, m *verifiable.Map, keyField string, start, end, lease int64) error {
+	entries := cts.getEntries(ctx, vlog, start, end)
+	if int64(len(entries)) != end-start {
+		return errors.New("fewer entries returned than expected")
+	}
+
+	// Only the last entry in the batch for each key needs setting
+	var keys []string
+	latest := make(map[string]*MapValue)
+	for i, entry := range entries {
+		// As when indexing fields, an entry that cannot be decoded is skipped rather than holding up those
+		// after it
+		de, err := DecodeEntry(start+int64(i), entry.LeafInput, entry.ExtraData)
+		if err != nil {
+			log.Printf("not mapping entry %d of %s/%s: %s\n", start+int64(i), vlog.Log.Account.Id, vlog.Log.Name, err)
+			continue
+		}
+		key, ok := IndexValue(de.Data, keyField)
+		if !ok {
+			continue
+		}
+		if _, ok := latest[key]; !ok {
+			keys = append(keys, key)
+		}
+		latest[key] = &MapValue{
+			LeafIndex: start + int64(i),
+			LeafHash:  merkle.LeafHash(entry.LeafInput),
+		}
+	}
+	for _, key := range keys {
+		value, err := json.Marshal(latest[key])
+		if err != nil {
+			return err
+		}
+
+		// A server that stalled until its lease expired must not overwrite values set by one that has since
+		// taken over, so renew the lease before it can expire while we are setting values
+		if lease-millis(time.Now()) < int64(mapLease/time.Millisecond)/2 {
+			lease, err = cts.renewMapLease(ctx, vlog, start, lease)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = m.Set(ctx, []byte(key), &pb.LeafData{LeafInput: value})
+		if err != nil {
+			return err
+		}
+	}
+
+	// The write API key may only set values, so read the map head as vlog reads the log
+	mapRoot, err := cts.logMap(vlog).TreeHead(ctx, verifiable.Head)
+	if err != nil {
+		return err
+	}
+	logRoot, err := vlog.TreeHead(ctx, end)
+	if err != nil {
+		return err
+	}
+	smh, err := cts.signMapHead(ctx, vlog, &SignedMapHead{
+		TreeSize:    uint64(end),
+		LogRootHash: logRoot.RootHash,
+		KeyField:    keyField,
+		MapSize:     uint64(mapRoot.MutationLog.TreeSize),
+		MapRootHash: mapRoot.RootHash,
+		Timestamp:   uint64(millis(time.Now())),
+	})
+	if err != nil {
+		return err
+	}
+
+	return cts.updateMapState(ctx, vlog, func(ms *govpb.MapState, kw verifiable.KeyWriter) error {
+		// If our lease expired, another server may have mapped these meanwhile
+		if ms.MappedTreeSize != start || ms.LeaseUntil != lease {
+			return errMapLeaseLost
+		}
+		ms.MappedTreeSize = end
+		ms.LeaseUntil = 0
+		ms.Latest = smh
+		return kw.Set(ctx, mapHeadKey(end), smh)
+	})
+}
+
+// signMapHead signs h with the log's key
+func (cts *Server) signMapHead(ctx context.Context, vlog *verifiable.Log, h *SignedMapHead) (*govpb.SignedMapHead, error) {
+	sk, err := cts.getSigningKey(ctx, vlog, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dss, err := tls.CreateSignature(*sk.PrivateKey, tls.SHA256, h.SignatureInput())
+	if err != nil {
+		return nil, verifiable.ErrInternalError // swallow crypto errs
+	}
+	sigBytes, err := tls.Marshal(dss)
+	if err != nil {
+		return nil, err
+	}
+
+	return &govpb.SignedMapHead{
+		TreeSize:    int64(h.TreeSize),
+		LogRootHash: h.LogRootHash,
+		KeyField:    h.KeyField,
+		MapSize:     int64(h.MapSize),
+		MapRootHash: h.MapRootHash,
+		Timestamp:   int64(h.Timestamp),
+		Signature:   sigBytes,
+	}, nil
+}
+
+// getMapHead returns the map head signed once entries before treeSize were mapped, or the latest if treeSize
+// is verifiable.Head. Heads are only signed at the end of each batch mapped.
+func (cts *Server) getMapHead(ctx context.Context, vlog *verifiable.Log, treeSize int64) (*govpb.SignedMapHead, error) {
+	ms, err := cts.getMapState(ctx, vlog)
+	if err != nil {
+		return nil, err
+	}
+	if ms.KeyField == "" {
+		return nil, status.Error(codes.NotFound, "log does not have a map")
+	}
+	if treeSize == verifiable.Head || (ms.Latest != nil && ms.Latest.TreeSize == treeSize) {
+		if ms.Latest == nil {
+			return nil, status.Error(codes.NotFound, "no entries have been mapped yet")
+		}
+		return ms.Latest, nil
+	}
+
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+	var smh govpb.SignedMapHead
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		return kr.Get(ctx, mapHeadKey(treeSize), &smh)
+	})
+	switch err {
+	case nil:
+		return &smh, nil
+	case verifiable.ErrNoSuchKey:
+		return nil, verifiable.ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+func (cts *Server) handleGetMapHead(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	treeSize, err := optionalInt64(r, "tree_size", verifiable.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	smh, err := cts.getMapHead(r.Context(), vlog, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if treeSize != verifiable.Head {
+		return immutable(mapHeadFromPB(smh)), nil
+	}
+	return mapHeadFromPB(smh), nil
+}
+
+func (cts *Server) handleGetMapValue(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	key := r.FormValue("key")
+	if _, ok := r.Form["key"]; !ok {
+		return nil, verifiable.ErrInvalidRequest
+	}
+	treeSize, err := optionalInt64(r, "tree_size", verifiable.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	smh, err := cts.getMapHead(r.Context(), vlog, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Whether a key is in the map would reveal a redacted value
+	if !canSearch(r, smh.KeyField) {
+		return nil, verifiable.ErrNotAuthorized
+	}
+
+	proof, err := cts.logMap(vlog).Get(r.Context(), []byte(key), smh.MapSize)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &GetMapValueResponse{
+		SMH:       mapHeadFromPB(smh),
+		Key:       key,
+		AuditPath: proof.AuditPath,
+	}
+	if proof.Value != nil && len(proof.Value.LeafInput) != 0 {
+		rv.Value = proof.Value.LeafInput
+
+		var v MapValue
+		err = json.Unmarshal(rv.Value, &v)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := cts.getEntry(r.Context(), vlog, v.LeafIndex)
+		if err != nil {
+			return nil, err
+		}
+		rv.LeafInput = entry.LeafInput
+		rv.ExtraData, err = redactExtraData(entry.LeafInput, entry.ExtraData, allowedFields(r))
+		if err != nil {
+			return nil, err
+		}
+		logProof, _, err := cts.getInclusionProofByIndex(r.Context(), vlog, smh.TreeSize, v.LeafIndex)
+		if err != nil {
+			return nil, err
+		}
+		rv.LogAuditPath = logProof.AuditPath
+	}
+
+	// Values for a given map head never change
+	if treeSize != verifiable.Head {
+		return immutable(rv), nil
+	}
+	return rv, nil
+}
@@ -0,0 +1,110 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+	govpb "github.com/govau/verifiable-logs/pb"
+)
+
+// addTestEntry adds an objecthash entry with extraData, as add-objecthash does
+func addTestEntry(t *testing.T, cts *Server, name string, extraData string) {
+	leaf := ct.CreateObjectHashMerkleTreeLeaf(sha256.Sum256([]byte(extraData)), uint64(len(extraData)))
+	addTestLeaf(t, cts, name, leaf, []byte(extraData))
+}
+
+func TestMapEntries(t *testing.T) {
+	ctx := context.Background()
+	cts := newServiceTestServer(t)
+
+	for _, extraData := range []string{`{"vin":"A"}`, `{"vin":"B"}`, `{"other":"C"}`} {
+		addTestEntry(t, cts, "cars", extraData)
+	}
+
+	// An entry that cannot be decoded is skipped
+	_, err := cts.Service.Account(cts.Account, cts.WriteAPIKey).VerifiableLog("cars").Add(ctx, &pb.LeafData{
+		LeafInput: []byte("not a leaf"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addTestEntry(t, cts, "cars", `{"vin":"A"}`)
+
+	// Background processes open logs with the read API key
+	vlog := cts.Service.Account(cts.Account, cts.ReadAPIKey).VerifiableLog("cars")
+	err = cts.setMapKeyField(ctx, vlog, "vin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cts.mapEntries(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ms, err := cts.getMapState(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms.MappedTreeSize != 5 || ms.LeaseUntil != 0 {
+		t.Fatalf("mapped to %d with lease until %d, want 5 and none", ms.MappedTreeSize, ms.LeaseUntil)
+	}
+	if ms.Latest == nil || ms.Latest.TreeSize != 5 || ms.Latest.MapSize != 2 || len(ms.Latest.Signature) == 0 {
+		t.Fatalf("latest map head %+v, want a signed head for tree size 5 with 2 keys set", ms.Latest)
+	}
+
+	resp, err := cts.logMap(vlog).Get(ctx, []byte("A"), ms.Latest.MapSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v MapValue
+	err = json.Unmarshal(resp.Value.LeafInput, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.LeafIndex != 4 {
+		t.Errorf("value for A is entry %d, want the latest, 4", v.LeafIndex)
+	}
+}
+
+func TestMapBatchLeaseLost(t *testing.T) {
+	ctx := context.Background()
+	cts := newServiceTestServer(t)
+	addTestEntry(t, cts, "cars", `{"vin":"A"}`)
+
+	vlog := cts.Service.Account(cts.Account, cts.ReadAPIKey).VerifiableLog("cars")
+	err := cts.setMapKeyField(ctx, vlog, "vin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Our lease, which is about to expire, has been taken over by another server
+	lease := millis(time.Now())
+	err = cts.updateMapState(ctx, vlog, func(ms *govpb.MapState, kw verifiable.KeyWriter) error {
+		ms.LeaseUntil = lease + 1
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := cts.Service.Account(cts.Account, cts.WriteAPIKey).VerifiableMap("cars")
+	err = cts.mapBatch(ctx, vlog, m, "vin", 0, 1, lease)
+	if err != errMapLeaseLost {
+		t.Fatalf("got %v, want the lease to be lost", err)
+	}
+
+	root, err := cts.logMap(vlog).TreeHead(ctx, verifiable.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.MutationLog.TreeSize != 0 {
+		t.Errorf("map has %d mutations, want none", root.MutationLog.TreeSize)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +30,12 @@ type LogSubmitter struct {
 	// TableNameValidator validates a table name before processing it
 	TableNameValidator TableNameValidator
 
+	// HTTPClient is passed to each LogClient we create (optional)
+	HTTPClient *http.Client
+
+	// Retry is passed to each LogClient we create (optional)
+	Retry RetryPolicy
+
 	logClientMutex sync.Mutex
 	logClients     map[string]*LogClient
 }
@@ -58,7 +65,7 @@ func (h *LogSubmitter) SubmitToLogAndUpdateRecord(ctx context.Context, tableName
 		return err
 	}
 
-	dataToSend, oh, err := filterAndHash(dataToSubmit)
+	dataToSend, oh, err := FilterAndHash(dataToSubmit)
 	if err != nil {
 		return err
 	}
@@ -70,7 +77,7 @@ func (h *LogSubmitter) SubmitToLogAndUpdateRecord(ctx context.Context, tableName
 	}
 
 	currentSCT, _ := dataToSubmit["signed_certificate_timestamp"].(string)
-	if currentSCT != "" && h.verifyIt(canonTable, currentSCT, oh) == nil {
+	if currentSCT != "" && h.verifyIt(ctx, canonTable, currentSCT, oh) == nil {
 		// If we already have a valid one, stop now
 		return nil
 	}
@@ -131,7 +138,7 @@ func (h *LogSubmitter) SubmitToLogAndUpdateRecord(ctx context.Context, tableName
 	}
 
 	// Now filter and hash it
-	_, newObjHash, err := filterAndHash(rowData)
+	_, newObjHash, err := FilterAndHash(rowData)
 	if err != nil {
 		return err
 	}
@@ -178,8 +185,10 @@ func (h *LogSubmitter) getLogClient(canonTable string) *LogClient {
 	}
 
 	rv = &LogClient{
-		URL:       h.baseURLForLog(canonTable),
-		AddAPIKey: h.APIKey,
+		URL:        h.baseURLForLog(canonTable),
+		AddAPIKey:  h.APIKey,
+		HTTPClient: h.HTTPClient,
+		Retry:      h.Retry,
 	}
 	h.logClients[canonTable] = rv
 
@@ -187,26 +196,12 @@ func (h *LogSubmitter) getLogClient(canonTable string) *LogClient {
 }
 
 // table name must already be canonical
-func (h *LogSubmitter) verifyIt(table, currentSCT string, hash ct.ObjectHash) error {
+func (h *LogSubmitter) verifyIt(ctx context.Context, table, currentSCT string, hash ct.ObjectHash) error {
 	curBytes, err := base64.StdEncoding.DecodeString(currentSCT)
 	if err != nil {
 		return err
 	}
-	var sct ct.SignedCertificateTimestamp
-	remaining, err := tls.Unmarshal(curBytes, &sct)
-	if err != nil {
-		return err
-	}
-	if len(remaining) != 0 {
-		return errors.New("trailing bytes")
-	}
-
-	verifier, err := h.getLogClient(table).GetVerifier()
-	if err != nil {
-		return err
-	}
-
-	err = verifier.VerifySCTSignature(sct, ct.LogEntry{Leaf: *ct.CreateObjectHashMerkleTreeLeaf(hash, sct.Timestamp)})
+	_, err = h.getLogClient(table).VerifyObjectHashSCT(ctx, hash, curBytes)
 	if err != nil {
 		return err
 	}
@@ -215,7 +210,11 @@ func (h *LogSubmitter) verifyIt(table, currentSCT string, hash ct.ObjectHash) er
 	return nil
 }
 
-func filterAndHash(data map[string]interface{}) (map[string]interface{}, ct.ObjectHash, error) {
+// FilterAndHash canonicalises a row in the same manner as we do before submitting it to a log,
+// and returns the data that would be sent, along with its objecthash.
+// Fields beginning with "_", the signed_certificate_timestamp field and null values are dropped,
+// and times are normalised to UTC.
+func FilterAndHash(data map[string]interface{}) (map[string]interface{}, ct.ObjectHash, error) {
 	dataToSend := make(map[string]interface{})
 	for k, v := range data {
 		// Don't count the internal fields
@@ -1,15 +1,260 @@
 package generalisedtransparency
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/continusec/verifiabledatastructures/pb"
 	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+	govpb "github.com/govau/verifiable-logs/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// Log states, as named in the CT log list schema
+const (
+	LogStateUsable   = "usable"
+	LogStateReadOnly = "readonly"
+	LogStateRetired  = "retired"
+)
+
+var logStateNames = map[govpb.LogState]string{
+	govpb.LogState_LOG_STATE_USABLE:    LogStateUsable,
+	govpb.LogState_LOG_STATE_READ_ONLY: LogStateReadOnly,
+	govpb.LogState_LOG_STATE_RETIRED:   LogStateRetired,
+}
+
 // MetadataResponse is a subset of a log as defined at: https://www.gstatic.com/ct/log_list/log_list_schema.json
 type MetadataResponse struct {
 	// Key is the ASN.1 DER encoded ECDSA public key for the log
 	Key []byte `json:"key"`
+
+	Description string `json:"description,omitempty"`
+
+	// Operator is the name of the operator or owner of the log
+	Operator string `json:"operator,omitempty"`
+
+	// URL is the base URL of the log, if set
+	URL string `json:"url,omitempty"`
+
+	// MaximumMergeDelay is in seconds
+	MaximumMergeDelay int64 `json:"maximum_merge_delay"`
+
+	// State is one of "usable", "readonly" or "retired"
+	State string `json:"state"`
+
+	// Created is when the log was created in milliseconds since the epoch, if known
+	Created uint64 `json:"created,omitempty"`
+
+	// DatasetURL links to the dataset the log is for
+	DatasetURL string `json:"dataset_url,omitempty"`
+
+	// StateTimestamp is when the state last changed in milliseconds since the epoch, if it has
+	StateTimestamp uint64 `json:"state_timestamp,omitempty"`
+
+	// FinalSTH is the last STH for the log, set when it is frozen
+	FinalSTH *ct.GetSTHResponse `json:"final_sth,omitempty"`
+
+	// Private is set if only authenticated readers may read the log
+	Private bool `json:"private,omitempty"`
+
+	// CORSOrigins are the origins allowed for CORS requests, if restricted
+	CORSOrigins []string `json:"cors_origins,omitempty"`
+
+	// IndexFields are the top-level fields of entries that can be searched
+	IndexFields []string `json:"index_fields,omitempty"`
+
+	// MapKeyField is the top-level field of entries that keys the log's verifiable map, if it has one
+	MapKeyField string `json:"map_key_field,omitempty"`
+}
+
+// SetMetadataRequest is posted to the admin API to change the descriptive metadata for a log.
+// Fields that are nil are left unchanged.
+type SetMetadataRequest struct {
+	Description       *string `json:"description,omitempty"`
+	Operator          *string `json:"operator,omitempty"`
+	URL               *string `json:"url,omitempty"`
+	MaximumMergeDelay *int64  `json:"maximum_merge_delay,omitempty"`
+	DatasetURL        *string `json:"dataset_url,omitempty"`
+
+	// Private and CORSOrigins are as per MetadataResponse. An empty CORSOrigins removes the restriction.
+	Private     *bool     `json:"private,omitempty"`
+	CORSOrigins *[]string `json:"cors_origins,omitempty"`
+
+	// IndexFields are as per MetadataResponse. Fields added are indexed for entries already in the log too.
+	IndexFields *[]string `json:"index_fields,omitempty"`
+
+	// MapKeyField is as per MetadataResponse. Once set, it cannot be changed.
+	MapKeyField *string `json:"map_key_field,omitempty"`
+}
+
+func metadataResponse(md *govpb.LogMetadata, publicDER []byte) *MetadataResponse {
+	mmd := md.MaximumMergeDelay
+	if mmd == 0 {
+		mmd = defaultMaximumMergeDelay
+	}
+	rv := &MetadataResponse{
+		Key:               publicDER,
+		Description:       md.Description,
+		Operator:          md.Operator,
+		URL:               md.Url,
+		MaximumMergeDelay: mmd,
+		State:             logStateNames[md.State],
+		Created:           uint64(md.Created),
+		DatasetURL:        md.DatasetUrl,
+		StateTimestamp:    uint64(md.StateTimestamp),
+		Private:           md.Private,
+		CORSOrigins:       md.CorsOrigins,
+		IndexFields:       md.IndexFields,
+		MapKeyField:       md.MapKeyField,
+	}
+	if md.FinalSth != nil {
+		rv.FinalSTH = sthFromPB(md.FinalSth)
+	}
+	return rv
+}
+
+// valid returns true if the fields set are acceptable
+func (req *SetMetadataRequest) valid() bool {
+	return (req.URL == nil || validURL(*req.URL)) &&
+		(req.DatasetURL == nil || validURL(*req.DatasetURL)) &&
+		(req.MaximumMergeDelay == nil || *req.MaximumMergeDelay >= 0) &&
+		(req.CORSOrigins == nil || validOrigins(*req.CORSOrigins)) &&
+		(req.IndexFields == nil || validIndexFields(*req.IndexFields)) &&
+		(req.MapKeyField == nil || *req.MapKeyField != "")
+}
+
+// apply sets the fields that are set in req on md
+func (req *SetMetadataRequest) apply(md *govpb.LogMetadata) {
+	if req.Description != nil {
+		md.Description = *req.Description
+	}
+	if req.Operator != nil {
+		md.Operator = *req.Operator
+	}
+	if req.URL != nil {
+		md.Url = *req.URL
+	}
+	if req.MaximumMergeDelay != nil {
+		md.MaximumMergeDelay = *req.MaximumMergeDelay
+	}
+	if req.DatasetURL != nil {
+		md.DatasetUrl = *req.DatasetURL
+	}
+	if req.Private != nil {
+		md.Private = *req.Private
+	}
+	if req.CORSOrigins != nil {
+		md.CorsOrigins = *req.CORSOrigins
+	}
+	if req.IndexFields != nil {
+		md.IndexFields = *req.IndexFields
+	}
+	if req.MapKeyField != nil {
+		md.MapKeyField = *req.MapKeyField
+	}
+}
+
+// getLogMetadata returns the stored metadata for a log, or verifiable.ErrNoSuchKey if it does not exist
+func (cts *Server) getLogMetadata(ctx context.Context, log *pb.LogRef) (*govpb.LogMetadata, error) {
+	ns, err := metadataNs()
+	if err != nil {
+		return nil, err
+	}
+	logKey, err := makeKeyForLog(log)
+	if err != nil {
+		return nil, err
+	}
+
+	var md govpb.LogMetadata
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		return kr.Get(ctx, logKey, &md)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
+
+// logMetadataCacheTTL is how long getCachedLogMetadata keeps metadata for. Changes made by this process are seen
+// at once, and those made by others serving the same logs once it expires.
+const logMetadataCacheTTL = 10 * time.Second
+
+// cachedLogMetadata is log metadata as read at a time
+type cachedLogMetadata struct {
+	md      *govpb.LogMetadata
+	expires time.Time
+}
+
+// getCachedLogMetadata is as getLogMetadata, but may return metadata up to logMetadataCacheTTL old, for calls made
+// on every read request. The result must not be modified.
+func (cts *Server) getCachedLogMetadata(ctx context.Context, log *pb.LogRef) (*govpb.LogMetadata, error) {
+	logKey, err := makeKeyForLog(log)
+	if err != nil {
+		return nil, err
+	}
+
+	cts.knownLogMutex.RLock()
+	cached := cts.logMetadata[string(logKey)]
+	cts.knownLogMutex.RUnlock()
+	if cached != nil && time.Now().Before(cached.expires) {
+		return cached.md, nil
+	}
+
+	md, err := cts.getLogMetadata(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	cts.knownLogMutex.Lock()
+	if cts.logMetadata == nil {
+		cts.logMetadata = make(map[string]*cachedLogMetadata)
+	}
+	cts.logMetadata[string(logKey)] = &cachedLogMetadata{md: md, expires: time.Now().Add(logMetadataCacheTTL)}
+	cts.knownLogMutex.Unlock()
+
+	return md, nil
+}
+
+// forgetLogMetadata removes the metadata for the log with logKey from the cache, once it has been changed
+func (cts *Server) forgetLogMetadata(logKey []byte) {
+	cts.knownLogMutex.Lock()
+	delete(cts.logMetadata, string(logKey))
+	cts.knownLogMutex.Unlock()
+}
+
+// updateLogMetadata calls f to modify the stored metadata for an existing log, and saves it if f returns nil
+func (cts *Server) updateLogMetadata(ctx context.Context, vlog *verifiable.Log, f func(md *govpb.LogMetadata) error) (*govpb.LogMetadata, error) {
+	ns, err := metadataNs()
+	if err != nil {
+		return nil, err
+	}
+	logKey, err := makeKeyForLog(vlog.Log)
+	if err != nil {
+		return nil, err
+	}
+
+	var md govpb.LogMetadata
+	err = cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		err := kw.Get(ctx, logKey, &md)
+		if err != nil {
+			return err
+		}
+		err = f(&md)
+		if err != nil {
+			return err
+		}
+		return kw.Set(ctx, logKey, &md)
+	})
+	cts.forgetLogMetadata(logKey)
+	if err != nil {
+		return nil, err
+	}
+	return &md, nil
 }
 
 func (cts *Server) handleMetadata(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
@@ -17,7 +262,71 @@ func (cts *Server) handleMetadata(vlog *verifiable.Log, r *http.Request) (interf
 	if err != nil {
 		return nil, err
 	}
-	return &MetadataResponse{
-		Key: sk.PublicDER,
-	}, nil
+	md, err := cts.getLogMetadata(r.Context(), vlog.Log)
+	if err != nil {
+		return nil, err
+	}
+	return metadataResponse(md, sk.PublicDER), nil
+}
+
+// validURL returns true if s is empty, or an absolute http(s) URL, so that we don't publish links to javascript: etc
+func validURL(s string) bool {
+	if s == "" {
+		return true
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// validOrigins returns true if each of origins is "*", or a http(s) origin as sent by browsers in the Origin header
+func validOrigins(origins []string) bool {
+	for _, o := range origins {
+		if o == "*" {
+			continue
+		}
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (cts *Server) handleSetMetadata(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	var req SetMetadataRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || !req.valid() {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	sk, err := cts.getSigningKey(r.Context(), vlog, false)
+	if err != nil {
+		return nil, err
+	}
+
+	md, err := cts.updateLogMetadata(r.Context(), vlog, func(md *govpb.LogMetadata) error {
+		if req.MapKeyField != nil && md.MapKeyField != "" && *req.MapKeyField != md.MapKeyField {
+			return status.Error(codes.FailedPrecondition, "map key field cannot be changed")
+		}
+		req.apply(md)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IndexFields != nil {
+		err = cts.setIndexFields(r.Context(), vlog, *req.IndexFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if req.MapKeyField != nil {
+		err = cts.setMapKeyField(r.Context(), vlog, *req.MapKeyField)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return metadataResponse(md, sk.PublicDER), nil
 }
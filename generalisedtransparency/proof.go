@@ -0,0 +1,27 @@
+package generalisedtransparency
+
+import (
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// VerificationError is returned by LogClient when data received from a log fails cryptographic
+// verification, as distinct from a failure to fetch it.
+type VerificationError struct {
+	Err error
+}
+
+func (e *VerificationError) Error() string {
+	return "verification failed: " + e.Err.Error()
+}
+
+// leafHashForLeaf returns the RFC6962 Merkle Tree Hash for a leaf
+func leafHashForLeaf(leaf *ct.MerkleTreeLeaf) ([]byte, error) {
+	leafData, err := tls.Marshal(*leaf)
+	if err != nil {
+		return nil, err
+	}
+	return merkle.LeafHash(leafData), nil
+}
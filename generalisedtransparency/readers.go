@@ -0,0 +1,168 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+// Reader is allowed to read private logs in an account. A request is from the reader if it presents any
+// one of its credentials.
+type Reader struct {
+	// ID identifies the reader, and is used to derive the API key we use with Service on its behalf
+	ID string `json:"id"`
+
+	// APIKeys are accepted as the Authorization header value
+	APIKeys []string `json:"api_keys"`
+
+	// BearerTokens are accepted as "Authorization: Bearer <token>"
+	BearerTokens []string `json:"bearer_tokens"`
+
+	// ClientCertSHA256 are the hex encoded SHA-256 fingerprints of TLS client certificates accepted
+	ClientCertSHA256 []string `json:"client_cert_sha256"`
+
+	// Logs are the names of the logs that may be read, where "*" matches any log and a trailing "*"
+	// matches any suffix, as per NameMatch in the access policy
+	Logs []string `json:"logs"`
+
+	// AllowedFields are the top-level fields of entries the reader may see, as per AllowedFields in
+	// the access policy. Other fields are redacted. If nil, all fields may be seen.
+	AllowedFields []string `json:"allowed_fields"`
+}
+
+type contextKey int
+
+// allowedFieldsKey is the request context key for the fields of entries the caller may see
+const allowedFieldsKey contextKey = iota
+
+// allowedFields returns the fields of entries the caller may see, or nil for all
+func allowedFields(r *http.Request) []string {
+	rv, _ := r.Context().Value(allowedFieldsKey).([]string)
+	return rv
+}
+
+// readerAPIKey is the API key used with Service for reads by a reader
+func readerAPIKey(id string) string {
+	return "reader:" + id
+}
+
+// nameMatches returns true if name matches pattern, using the same rules as NameMatch in the access policy
+func nameMatches(pattern, name string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(name, pattern[:len(pattern)-1])
+	}
+	return pattern == name
+}
+
+// canRead returns true if the reader may read the named log
+func (rd *Reader) canRead(name string) bool {
+	for _, pattern := range rd.Logs {
+		if nameMatches(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsSecret returns true if s is one of secrets, comparing in constant time
+func containsSecret(secrets []string, s string) bool {
+	found := false
+	for _, secret := range secrets {
+		if secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(s)) == 1 {
+			found = true
+		}
+	}
+	return found
+}
+
+// authenticateReader returns the reader that r has credentials for, or nil if none
+func (acc *AccountConfig) authenticateReader(r *http.Request) *Reader {
+	auth := r.Header.Get("Authorization")
+	bearer := ""
+	if strings.HasPrefix(auth, "Bearer ") {
+		bearer = strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	// Client certificates are verified by the TLS server config, if it is configured to request them
+	fingerprint := ""
+	if r.TLS != nil && len(r.TLS.PeerCertificates) != 0 {
+		fp := sha256.Sum256(r.TLS.PeerCertificates[0].Raw)
+		fingerprint = hex.EncodeToString(fp[:])
+	}
+
+	for _, rd := range acc.Readers {
+		if auth != "" && containsSecret(rd.APIKeys, auth) {
+			return rd
+		}
+		if bearer != "" && containsSecret(rd.BearerTokens, bearer) {
+			return rd
+		}
+		if fingerprint != "" {
+			for _, fp := range rd.ClientCertSHA256 {
+				if strings.EqualFold(fp, fingerprint) {
+					return rd
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readAccessFor returns the API key to use with Service to read the named log, and the fields of entries
+// the caller may see. Authenticated readers use their own key and fields, otherwise the account's are used
+// unless the log is private.
+func (cts *Server) readAccessFor(r *http.Request, account string, acc *AccountConfig, name string) (string, []string, error) {
+	rd := acc.authenticateReader(r)
+	if rd != nil && rd.canRead(name) {
+		return readerAPIKey(rd.ID), rd.AllowedFields, nil
+	}
+
+	md, err := cts.getCachedLogMetadata(r.Context(), &pb.LogRef{
+		Account: &pb.AccountRef{Id: account},
+		Name:    name,
+	})
+	switch err {
+	case nil:
+		// continue
+	case verifiable.ErrNoSuchKey:
+		return acc.ReadAPIKey, acc.ReadAllowedFields, nil // doesn't exist yet, so nothing to protect
+	default:
+		return "", nil, err
+	}
+
+	if md.Private {
+		return "", nil, verifiable.ErrNotAuthorized
+	}
+	return acc.ReadAPIKey, acc.ReadAllowedFields, nil
+}
+
+// allowedOrigins returns the origins that may make CORS requests for the named log
+func (cts *Server) allowedOrigins(ctx context.Context, account, name string) ([]string, error) {
+	md, err := cts.getCachedLogMetadata(ctx, &pb.LogRef{
+		Account: &pb.AccountRef{Id: account},
+		Name:    name,
+	})
+	switch err {
+	case nil:
+		// continue
+	case verifiable.ErrNoSuchKey:
+		return []string{"*"}, nil
+	default:
+		return nil, err
+	}
+
+	switch {
+	case len(md.CorsOrigins) != 0:
+		return md.CorsOrigins, nil
+	case md.Private:
+		return nil, nil
+	default:
+		return []string{"*"}, nil
+	}
+}
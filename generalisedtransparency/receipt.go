@@ -0,0 +1,142 @@
+package generalisedtransparency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+
+	"github.com/govau/verifiable-logs/merkle"
+)
+
+// Receipt is a self-contained proof that an objecthash entry is included in a log.
+// Everything needed to check it is within, so that it can be verified offline, and
+// attached to a published dataset alongside the rows it describes.
+type Receipt struct {
+	// ObjectHash is the objecthash of the entry
+	ObjectHash []byte `json:"object_hash"`
+
+	// ExtraData is the entry data as stored in the log (optional). If present it must hash to ObjectHash.
+	ExtraData json.RawMessage `json:"extra_data,omitempty"`
+
+	// SCT is the signed certificate timestamp issued when the entry was added
+	SCT *ct.AddChainResponse `json:"sct"`
+
+	// LeafIndex is the index of the entry in the log
+	LeafIndex int64 `json:"leaf_index"`
+
+	// AuditPath is the inclusion proof for the entry in the tree described by STH
+	AuditPath [][]byte `json:"audit_path"`
+
+	// STH is the signed tree head that the audit path leads to
+	STH *ct.GetSTHResponse `json:"sth"`
+
+	// PublicKey is the ASN.1 DER encoded ECDSA public key for the log
+	PublicKey []byte `json:"public_key"`
+}
+
+// Verify checks the receipt without any network access. If trustedPublicKeyDER is
+// non-nil, then the receipt must have been issued by that key, else we trust the
+// public key within the receipt.
+func (r *Receipt) Verify(trustedPublicKeyDER []byte) error {
+	if trustedPublicKeyDER != nil && !bytes.Equal(trustedPublicKeyDER, r.PublicKey) {
+		return errors.New("receipt public key does not match trusted key")
+	}
+	if r.SCT == nil || r.STH == nil {
+		return errors.New("receipt is missing sct or sth")
+	}
+	if len(r.ObjectHash) != sha256.Size {
+		return errors.New("receipt object hash is wrong length")
+	}
+
+	var oh ct.ObjectHash
+	copy(oh[:], r.ObjectHash)
+
+	// If we have the data, make sure it matches
+	if len(r.ExtraData) != 0 {
+		var data interface{}
+		err := json.Unmarshal(r.ExtraData, &data)
+		if err != nil {
+			return err
+		}
+		expected, err := RedactableObjectHash(data)
+		if err != nil {
+			return err
+		}
+		if expected != oh {
+			return errors.New("receipt extra data does not match object hash")
+		}
+	}
+
+	pubKey, err := x509.ParsePKIXPublicKey(r.PublicKey)
+	if err != nil {
+		return err
+	}
+	verifier, err := ct.NewSignatureVerifier(pubKey)
+	if err != nil {
+		return err
+	}
+
+	// Check the SCT was issued by this log for this entry
+	logID := sha256.Sum256(r.PublicKey)
+	if !bytes.Equal(r.SCT.ID, logID[:]) {
+		return errors.New("sct log id does not match public key")
+	}
+	sct, err := sctFromAddChainResponse(r.SCT)
+	if err != nil {
+		return err
+	}
+	leaf := ct.CreateObjectHashMerkleTreeLeaf(oh, sct.Timestamp)
+	err = verifier.VerifySCTSignature(*sct, ct.LogEntry{Leaf: *leaf})
+	if err != nil {
+		return err
+	}
+
+	// Then the STH
+	sth, err := r.STH.ToSignedTreeHead()
+	if err != nil {
+		return err
+	}
+	err = verifier.VerifySTHSignature(*sth)
+	if err != nil {
+		return err
+	}
+
+	// And finally that one leads to the other
+	leafHash, err := leafHashForLeaf(leaf)
+	if err != nil {
+		return err
+	}
+	if r.LeafIndex < 0 {
+		return errors.New("receipt leaf index is negative")
+	}
+	return merkle.VerifyInclusion(uint64(r.LeafIndex), r.STH.TreeSize, leafHash, r.AuditPath, r.STH.SHA256RootHash)
+}
+
+// sctFromAddChainResponse converts the JSON form of an SCT into the structure signed by the log
+func sctFromAddChainResponse(resp *ct.AddChainResponse) (*ct.SignedCertificateTimestamp, error) {
+	if len(resp.ID) != sha256.Size {
+		return nil, errors.New("sct log id is wrong length")
+	}
+
+	var sig ct.DigitallySigned
+	remaining, err := tls.Unmarshal(resp.Signature, &sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(remaining) != 0 {
+		return nil, errors.New("trailing bytes")
+	}
+
+	rv := &ct.SignedCertificateTimestamp{
+		SCTVersion: resp.SCTVersion,
+		Timestamp:  resp.Timestamp,
+		Signature:  sig,
+	}
+	copy(rv.LogID.KeyID[:], resp.ID)
+	return rv, nil
+}
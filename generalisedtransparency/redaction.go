@@ -0,0 +1,139 @@
+package generalisedtransparency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/benlaurie/objecthash/go/objecthash"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RedactedPrefix starts a string that replaces a redacted value in extra data, and is followed by the hex
+// encoded objecthash of the value. This is the same convention as objectHashWithRedaction in verifiable.js.
+const RedactedPrefix = "***REDACTED*** Hash: "
+
+// allFields is the field name that allows all fields, as per AllowedFields in the access policy
+const allFields = "*"
+
+// errCannotRedact is returned for entries that must be redacted for the caller, but keep their data in the leaf
+// input rather than extra data, such as CMS entries. Such entries may only be read by callers allowed all fields.
+var errCannotRedact = status.Error(codes.PermissionDenied, "entries in this log cannot be redacted, so may only be read by readers allowed to see all fields")
+
+// RedactableObjectHash returns the objecthash of o, which is as per objecthash.ObjectHash except that
+// redacted values (see RedactedPrefix) contribute the hash they contain.
+func RedactableObjectHash(o interface{}) ([sha256.Size]byte, error) {
+	switch v := o.(type) {
+	case map[string]interface{}:
+		var pairs [][]byte
+		for k, val := range v {
+			kh, err := objecthash.ObjectHash(k)
+			if err != nil {
+				return [sha256.Size]byte{}, err
+			}
+			vh, err := RedactableObjectHash(val)
+			if err != nil {
+				return [sha256.Size]byte{}, err
+			}
+			pairs = append(pairs, append(kh[:], vh[:]...))
+		}
+		sort.Slice(pairs, func(i, j int) bool {
+			return bytes.Compare(pairs[i], pairs[j]) < 0
+		})
+		return taggedHash('d', bytes.Join(pairs, nil)), nil
+	case []interface{}:
+		var b []byte
+		for _, val := range v {
+			h, err := RedactableObjectHash(val)
+			if err != nil {
+				return [sha256.Size]byte{}, err
+			}
+			b = append(b, h[:]...)
+		}
+		return taggedHash('l', b), nil
+	case string:
+		if strings.HasPrefix(v, RedactedPrefix) {
+			h, err := hex.DecodeString(strings.TrimPrefix(v, RedactedPrefix))
+			if err == nil && len(h) == sha256.Size {
+				var rv [sha256.Size]byte
+				copy(rv[:], h)
+				return rv, nil
+			}
+		}
+	}
+	return objecthash.ObjectHash(o)
+}
+
+func taggedHash(tag byte, b []byte) [sha256.Size]byte {
+	return sha256.Sum256(append([]byte{tag}, b...))
+}
+
+// redactedValue returns the redaction to replace the JSON value raw with
+func redactedValue(raw json.RawMessage) (json.RawMessage, error) {
+	var v interface{}
+	err := json.Unmarshal(raw, &v)
+	if err != nil {
+		return nil, err
+	}
+	h, err := RedactableObjectHash(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(RedactedPrefix + hex.EncodeToString(h[:]))
+}
+
+// redactExtraData returns the extra data for an entry with the top-level fields not in allowed replaced by
+// their redactions, so that the objecthash of the result is unchanged. A nil allowed means all fields are allowed.
+// Only objecthash entries can be redacted, as other types keep their data in the leaf input, so for those
+// errCannotRedact is returned unless all fields are allowed.
+func redactExtraData(leafInput, extraData []byte, allowed []string) ([]byte, error) {
+	if allowed == nil {
+		return extraData, nil
+	}
+	for _, f := range allowed {
+		if f == allFields {
+			return extraData, nil
+		}
+	}
+
+	var leaf ct.MerkleTreeLeaf
+	_, err := tls.Unmarshal(leafInput, &leaf)
+	if err != nil {
+		return nil, err
+	}
+	if leaf.TimestampedEntry == nil || leaf.TimestampedEntry.EntryType != ct.XObjectHashLogEntryType {
+		return nil, errCannotRedact
+	}
+	if len(extraData) == 0 {
+		return extraData, nil
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(extraData, &fields)
+	if err != nil {
+		// Not an object, so there are no fields that may be seen
+		return redactedValue(extraData)
+	}
+
+	isAllowed := make(map[string]bool)
+	for _, f := range allowed {
+		isAllowed[f] = true
+	}
+	for k, v := range fields {
+		if isAllowed[k] {
+			continue
+		}
+		fields[k], err = redactedValue(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(fields)
+}
@@ -0,0 +1,106 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/continusec/verifiabledatastructures/mutator/instant"
+	"github.com/continusec/verifiabledatastructures/oracle/policy"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/storage/memory"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+)
+
+// newServiceTestServer returns a server with different read and write API keys, using a Service that enforces
+// the server's access policy, as is done by the server command
+func newServiceTestServer(t *testing.T, readers ...*Reader) *Server {
+	storage := &memory.TransientStorage{}
+	cts := &Server{
+		Account:            "test",
+		ReadAPIKey:         "read",
+		WriteAPIKey:        "write",
+		Reader:             storage,
+		Writer:             storage,
+		TableNameValidator: &InsecureSkipTableNameValidator{},
+		Readers:            readers,
+	}
+
+	service := &verifiable.Service{
+		AccessPolicy: &policy.Static{
+			Policy: cts.AccessPolicy(),
+		},
+		Mutator: &instant.Mutator{
+			Writer: storage,
+		},
+		Reader: storage,
+	}
+	server, err := service.Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cts.Service = &verifiable.Client{
+		Service: server,
+	}
+	return cts
+}
+
+// addTestLeaf adds an entry to the named log, creating the log if needed
+func addTestLeaf(t *testing.T, cts *Server, name string, leaf *ct.MerkleTreeLeaf, extraData []byte) {
+	ctx := context.Background()
+	vlog := cts.Service.Account(cts.Account, cts.WriteAPIKey).VerifiableLog(name)
+	_, err := cts.getSigningKey(ctx, vlog, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafInput, err := tls.Marshal(*leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = vlog.Add(ctx, &pb.LeafData{
+		LeafInput: leafInput,
+		ExtraData: extraData,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRedactCMSForRestrictedReader(t *testing.T) {
+	cts := newServiceTestServer(t, &Reader{
+		ID:            "restricted",
+		APIKeys:       []string{"restrictedsecret"},
+		Logs:          []string{"*"},
+		AllowedFields: []string{"make"},
+	}, &Reader{
+		ID:            "auditor",
+		APIKeys:       []string{"auditorsecret"},
+		Logs:          []string{"*"},
+		AllowedFields: []string{"*"},
+	})
+	addTestLeaf(t, cts, "vins", ct.CreateCMSMerkleTreeLeaf([]byte(`{"vin":"A","make":"B"}`), 0), nil)
+
+	handler := cts.CreateRESTHandler()
+	for _, tc := range []struct {
+		apiKey string
+		want   int
+	}{
+		{"restrictedsecret", http.StatusForbidden},
+		{"auditorsecret", http.StatusOK},
+		{"", http.StatusOK}, // readers without credentials may see all fields by default
+	} {
+		req := httptest.NewRequest("GET", "/dataset/vins/ct/v1/get-entries?start=0&end=0", nil)
+		if tc.apiKey != "" {
+			req.Header.Set("Authorization", tc.apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("reader with %q got status %d, want %d: %s", tc.apiKey, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
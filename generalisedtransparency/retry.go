@@ -0,0 +1,273 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when too many consecutive requests to a log have failed,
+// and we are waiting before trying again
+var ErrCircuitOpen = errors.New("circuit breaker open, log server unavailable")
+
+// RetryPolicy controls how failed requests to a log are retried.
+// The zero value is usable, and results in the defaults described below.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request, including the first. Defaults to 5.
+	MaxAttempts int
+
+	// InitialBackoff is the upper bound of the wait before the first retry, doubled for each subsequent retry. Defaults to 250ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between any two attempts. Defaults to 10s.
+	MaxBackoff time.Duration
+
+	// AttemptTimeout bounds each individual attempt, including reading the response body. For an event stream, it
+	// only bounds waiting for the stream to start. Defaults to 30s.
+	AttemptTimeout time.Duration
+
+	// BreakerThreshold is the number of consecutive failed attempts after which we stop
+	// sending requests to the log for BreakerCooldown. Defaults to 10.
+	BreakerThreshold int
+
+	// BreakerCooldown is how long we fail fast for once the breaker has opened. Defaults to 30s.
+	BreakerCooldown time.Duration
+}
+
+func (p *RetryPolicy) withDefaults() RetryPolicy {
+	rv := *p
+	if rv.MaxAttempts <= 0 {
+		rv.MaxAttempts = 5
+	}
+	if rv.InitialBackoff <= 0 {
+		rv.InitialBackoff = 250 * time.Millisecond
+	}
+	if rv.MaxBackoff <= 0 {
+		rv.MaxBackoff = 10 * time.Second
+	}
+	if rv.AttemptTimeout <= 0 {
+		rv.AttemptTimeout = 30 * time.Second
+	}
+	if rv.BreakerThreshold <= 0 {
+		rv.BreakerThreshold = 10
+	}
+	if rv.BreakerCooldown <= 0 {
+		rv.BreakerCooldown = 30 * time.Second
+	}
+	return rv
+}
+
+// backoff returns how long to wait before the given retry (1 for the first retry), using "full jitter"
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.InitialBackoff
+	for i := 1; i < retry && ceiling < p.MaxBackoff; i++ {
+		ceiling *= 2
+	}
+	if ceiling > p.MaxBackoff {
+		ceiling = p.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// circuitBreaker counts consecutive failures, and once over a threshold, refuses requests until a cooldown has passed.
+// After the cooldown it is half-open: a single probe request is let through, and while it is outstanding others are
+// refused. A successful probe closes the breaker, and a failed one re-opens it, since the count is only reset by a success.
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *circuitBreaker) allow(threshold int) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if time.Now().Before(b.openUntil) {
+		return false
+	}
+	if b.failures < threshold {
+		return true
+	}
+
+	// Half-open
+	if b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) record(success bool, threshold int, cooldown time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+	if success {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= threshold {
+		b.openUntil = time.Now().Add(cooldown)
+	}
+}
+
+// abandon is called when a request gives up without an outcome, so that if it was the probe, another may be made
+func (b *circuitBreaker) abandon() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+}
+
+// retryRT retries failed requests with exponential backoff and jitter.
+// GET requests are always retried. POST requests are only retried if RetryPost is set, which
+// should only be done where the server de-duplicates submissions (as we do for add-objecthash).
+type retryRT struct {
+	Base      http.RoundTripper
+	Policy    RetryPolicy
+	Breaker   *circuitBreaker
+	RetryPost bool
+}
+
+func (rt *retryRT) canRetry(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return rt.RetryPost && (req.Body == nil || req.GetBody != nil)
+	default:
+		return false
+	}
+}
+
+// isRetryableStatus returns true for server side errors that are likely to be transient
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func (rt *retryRT) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := rt.Policy.withDefaults()
+	attempts := policy.MaxAttempts
+	if !rt.canRetry(req) {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if !rt.Breaker.allow(policy.BreakerThreshold) {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := rt.attempt(req, attempt, policy.AttemptTimeout)
+		switch {
+		case err == nil && !isRetryableStatus(resp.StatusCode):
+			rt.Breaker.record(true, policy.BreakerThreshold, policy.BreakerCooldown)
+			return resp, nil
+		case req.Context().Err() != nil:
+			// Caller has given up, so this does not count against the server
+			rt.Breaker.abandon()
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, req.Context().Err()
+		}
+		rt.Breaker.record(false, policy.BreakerThreshold, policy.BreakerCooldown)
+
+		if attempt >= attempts {
+			// Out of retries, return whatever we got so the caller can see the real status
+			return resp, err
+		}
+
+		wait := policy.backoff(attempt)
+		if resp != nil {
+			// Honour a Retry-After header if the server sent one, within reason
+			if secs, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && secs >= 0 {
+				wait = time.Duration(secs) * time.Second
+				if wait > policy.MaxBackoff {
+					wait = policy.MaxBackoff
+				}
+			}
+			// Drain a little so the connection may be reused
+			io.CopyN(ioutil.Discard, resp.Body, 4096)
+			resp.Body.Close()
+			lastErr = errors.New("bad http status code: " + resp.Status)
+		} else {
+			lastErr = err
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, lastErr
+		}
+	}
+}
+
+// isEventStream returns true if req asks for Server-Sent Events, which last as long as the caller wants
+func isEventStream(req *http.Request) bool {
+	return req.Header.Get("Accept") == "text/event-stream"
+}
+
+// attempt makes a single request, bounding it (including reading the body) by timeout. For an event stream,
+// only waiting for the response headers is bounded, since the body lasts as long as the request context.
+func (rt *retryRT) attempt(req *http.Request, attempt int, timeout time.Duration) (*http.Response, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	var timer *time.Timer
+	if isEventStream(req) {
+		ctx, cancel = context.WithCancel(req.Context())
+		timer = time.AfterFunc(timeout, cancel)
+	} else {
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+	}
+	r := req.WithContext(ctx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	resp, err := rt.Base.RoundTrip(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if timer != nil && !timer.Stop() {
+		// Timed out just as the headers arrived
+		resp.Body.Close()
+		cancel()
+		return nil, context.DeadlineExceeded
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the per-attempt context once the caller is done with the body
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
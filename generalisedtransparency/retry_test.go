@@ -0,0 +1,274 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPolicy retries quickly, so that tests do not wait on backoff
+var testPolicy = RetryPolicy{
+	MaxAttempts:      3,
+	InitialBackoff:   time.Millisecond,
+	MaxBackoff:       5 * time.Millisecond,
+	BreakerThreshold: 100,
+}
+
+// failingServer responds with each of codes in turn, then 200, counting requests and recording the last body
+type failingServer struct {
+	*httptest.Server
+	requests int32
+	lastBody atomic.Value
+}
+
+func newFailingServer(codes []int, header http.Header) *failingServer {
+	fs := &failingServer{}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&fs.requests, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		fs.lastBody.Store(string(body))
+		if int(n) <= len(codes) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(codes[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	return fs
+}
+
+func newTestRT(policy RetryPolicy, retryPost bool) *retryRT {
+	return &retryRT{
+		Base:      http.DefaultTransport,
+		Policy:    policy,
+		Breaker:   &circuitBreaker{},
+		RetryPost: retryPost,
+	}
+}
+
+func TestRetryStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		method     string
+		retryPost  bool
+		codes      []int
+		wantStatus int
+		wantTries  int32
+	}{
+		{"500 then ok", http.MethodGet, false, []int{500}, 200, 2},
+		{"429 and 503 then ok", http.MethodGet, false, []int{429, 503}, 200, 3},
+		{"out of attempts", http.MethodGet, false, []int{502, 502, 502}, 502, 3},
+		{"400 not retried", http.MethodGet, false, []int{400}, 400, 1},
+		{"post not retried", http.MethodPost, false, []int{500}, 500, 1},
+		{"post retried when allowed", http.MethodPost, true, []int{500}, 200, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFailingServer(tc.codes, nil)
+			defer server.Close()
+
+			req, err := http.NewRequest(tc.method, server.URL, strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := newTestRT(testPolicy, tc.retryPost).RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("status %d, want %d", resp.StatusCode, tc.wantStatus)
+			}
+			if n := atomic.LoadInt32(&server.requests); n != tc.wantTries {
+				t.Errorf("%d requests, want %d", n, tc.wantTries)
+			}
+			if tc.method == http.MethodPost && server.lastBody.Load() != "body" {
+				t.Errorf("body of last request %q, want it replayed", server.lastBody.Load())
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		maxBackoff time.Duration
+		min, max   time.Duration
+	}{
+		{"honoured", 5 * time.Second, time.Second, 3 * time.Second},
+		{"capped by max backoff", 10 * time.Millisecond, 0, 500 * time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFailingServer([]int{503}, http.Header{"Retry-After": []string{"1"}})
+			defer server.Close()
+
+			policy := testPolicy
+			policy.MaxBackoff = tc.maxBackoff
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			resp, err := newTestRT(policy, false).RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			elapsed := time.Since(start)
+			if elapsed < tc.min || elapsed > tc.max {
+				t.Errorf("took %s, want between %s and %s", elapsed, tc.min, tc.max)
+			}
+			if resp.StatusCode != 200 {
+				t.Errorf("status %d, want 200", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	healthy := int32(0)
+	requests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(500)
+		}
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{
+		MaxAttempts:      1,
+		BreakerThreshold: 3,
+		BreakerCooldown:  50 * time.Millisecond,
+	}
+	rt := newTestRT(policy, false)
+	get := func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rt.RoundTrip(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := get()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := get()
+	if err != ErrCircuitOpen {
+		t.Fatalf("got %v, want breaker open", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("%d requests reached the server while open, want 3", n)
+	}
+
+	// Once the cooldown has passed, only a single probe is allowed at a time
+	time.Sleep(policy.BreakerCooldown)
+	if !rt.Breaker.allow(policy.BreakerThreshold) {
+		t.Fatal("probe not allowed after cooldown")
+	}
+	if rt.Breaker.allow(policy.BreakerThreshold) {
+		t.Fatal("second request allowed while probing")
+	}
+	rt.Breaker.abandon()
+
+	// A failed probe re-opens it
+	_, err = get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = get()
+	if err != ErrCircuitOpen {
+		t.Fatalf("got %v, want breaker re-opened", err)
+	}
+
+	// And a successful one closes it
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(policy.BreakerCooldown)
+	for i := 0; i < 3; i++ {
+		resp, err := get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != 200 {
+			t.Fatalf("status %d, want 200", resp.StatusCode)
+		}
+	}
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	server := newFailingServer([]int{500, 500, 500}, nil)
+	defer server.Close()
+
+	policy := testPolicy
+	policy.InitialBackoff = time.Minute
+	policy.MaxBackoff = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	resp, err := newTestRT(policy, false).RoundTrip(req.WithContext(ctx))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected an error once the context was cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("took %s to give up after the context was cancelled", elapsed)
+	}
+	if n := atomic.LoadInt32(&server.requests); n != 1 {
+		t.Errorf("%d requests, want 1", n)
+	}
+}
+
+func TestStreamOutlivesAttemptTimeout(t *testing.T) {
+	policy := testPolicy
+	policy.AttemptTimeout = 50 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 2; i++ {
+			if i > 0 {
+				time.Sleep(4 * policy.AttemptTimeout)
+			}
+			fmt.Fprintf(w, "event: entry\nid: %d\ndata: {}\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	errDone := errors.New("done")
+	var ids []string
+	err := (&LogClient{URL: server.URL, Retry: policy}).Stream(context.Background(), 0, func(ev *StreamEvent) error {
+		ids = append(ids, ev.ID)
+		if len(ids) == 2 {
+			return errDone
+		}
+		return nil
+	})
+	if err != errDone {
+		t.Fatalf("stream ended with %v after events %v, want both events", err, ids)
+	}
+}
@@ -20,7 +20,8 @@ type Server struct {
 	// Service is the underlying verifiabledatastructures client used for log management
 	Service *verifiable.Client
 
-	// Account is verifiabledatastructures account to use with Service
+	// Account is verifiabledatastructures account to use with Service for the default account,
+	// which is served under /dataset/{logname}. The fields below up to Readers apply to it.
 	Account string
 
 	// ReadAPIKey is the API key to use to read from Service
@@ -29,6 +30,13 @@ type Server struct {
 	// ReadAPIKey is the API key to use to write to Service (used by /add-objecthash only)
 	WriteAPIKey string
 
+	// AdminAPIKey is the Authorization header value required for the admin API. If empty, the admin API is disabled.
+	AdminAPIKey string
+
+	// RequireCreate, if set, means logs must be created with the admin API before entries can be added,
+	// rather than being created on the first add
+	RequireCreate bool
+
 	// InputValidator checks if it is valid request to accept input from
 	InputValidator SubmissionValidator
 
@@ -41,9 +49,58 @@ type Server struct {
 	// TableNameValidator only allows logs to be created for the specified tables
 	TableNameValidator TableNameValidator
 
+	// GossipLogKeys are the ASN.1 DER encoded public keys of other logs whose signed tree heads may be reported
+	// with STH gossip. Heads for logs hosted by this server are always accepted.
+	GossipLogKeys [][]byte
+
+	// ReadAllowedFields are the top-level fields of entries that readers without credentials may see. If nil, all fields may be seen.
+	ReadAllowedFields []string
+
+	// Readers may read private logs, and see the fields of entries allowed for them
+	Readers []*Reader
+
+	// Accounts are additional accounts, keyed by account ID, served under /account/{account}/dataset/{logname}
+	Accounts map[string]*AccountConfig
+
+	// WebhookClient is used to send notifications to webhook subscribers. If nil, http.DefaultClient is used.
+	// Each attempt is limited to 30 seconds either way.
+	WebhookClient *http.Client
+
+	// CacheSize is the most items to keep in the in-process cache of entries, proofs and STHs, which never
+	// change once they exist. If 0, a default size is used, and if negative, nothing is cached.
+	CacheSize int
+
 	// Known logs - here we caching the signing key. TODO, consider caching all sorts of other things!
 	// We actually use this on every request, if nothing else but an indication of if a log exists, and thus whether
 	// we should allow a read-only operation to do (to stop creating new tables on read of a non-existent log)
 	knownLogMutex sync.RWMutex
 	knownLogs     map[string]*signingKey
+
+	// listedLogs are those known to be in the log directory
+	listedLogs map[string]bool
+
+	// logMetadata caches the metadata for logs, as checked on every read request, and is guarded by knownLogMutex
+	logMetadata map[string]*cachedLogMetadata
+
+	// lifecycleMutex is held for reading while adding entries, and for writing while freezing a log,
+	// so that no entry is added after the final STH is signed by this process
+	lifecycleMutex sync.RWMutex
+
+	// gossipLimits limit how often heads for other logs may be reported to each log
+	gossipMutex  sync.Mutex
+	gossipLimits map[string]*gossipLimit
+
+	// sthIndexBuilds are the logs whose index of signed tree heads is being built in the background
+	sthIndexMutex  sync.Mutex
+	sthIndexBuilds map[string]bool
+
+	// cache holds immutable data, and is created on first use
+	cacheOnce sync.Once
+	cache     *lruCache
+
+	// updated has a channel per log that is closed when entries are added, to wake streams, and
+	// wakers are signalled to wake background processes such as RunWebhooks
+	updatedMutex sync.Mutex
+	updated      map[string]chan struct{}
+	wakers       []chan struct{}
 }
@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/x509"
+	"time"
 
 	"github.com/benlaurie/objecthash/go/objecthash"
 	"github.com/continusec/verifiabledatastructures/pb"
@@ -21,6 +22,14 @@ type signingKey struct {
 	LogID      [sha256.Size]byte
 }
 
+// metadataNs returns the namespace that holds metadata for all logs
+func metadataNs() ([sha256.Size]byte, error) {
+	// Weird, but same convention we use inside of verifiable data library
+	return objecthash.ObjectHash(map[string]interface{}{
+		"type": "metadata",
+	})
+}
+
 func makeKeyForLog(log *pb.LogRef) ([]byte, error) {
 	h, err := objecthash.ObjectHash(map[string]interface{}{
 		"account": log.Account.Id,
@@ -76,13 +85,16 @@ func (cts *Server) getSigningKey(ctx context.Context, vlog *verifiable.Log, crea
 	cts.knownLogMutex.RUnlock()
 
 	if rv != nil {
+		if create {
+			err = cts.listLog(ctx, vlog, logKeyString, rv)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return rv, nil
 	}
 
-	// Weird, but same convention we use inside of verifiable data library
-	ns, err := objecthash.ObjectHash(map[string]interface{}{
-		"type": "metadata",
-	})
+	ns, err := metadataNs()
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +105,18 @@ func (cts *Server) getSigningKey(ctx context.Context, vlog *verifiable.Log, crea
 	})
 	switch err {
 	case nil:
-		return cts.cacheSigningKey(logKeyString, &logMetadata)
+		rv, err = cts.cacheSigningKey(logKeyString, &logMetadata)
+		if err != nil {
+			return nil, err
+		}
+
+		if create {
+			err = cts.listLog(ctx, vlog, logKeyString, rv)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return rv, nil
 	case verifiable.ErrNoSuchKey:
 		if !create {
 			return nil, err
@@ -128,11 +151,61 @@ func (cts *Server) getSigningKey(ctx context.Context, vlog *verifiable.Log, crea
 		}
 
 		logMetadata.PrivateKeyDer = der
+		logMetadata.Created = time.Now().UnixNano() / (1000 * 1000)
 		return kw.Set(ctx, logKey, &logMetadata)
 	})
+	cts.forgetLogMetadata(logKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rv, err = cts.cacheSigningKey(logKeyString, &logMetadata)
 	if err != nil {
 		return nil, err
 	}
 
-	return cts.cacheSigningKey(logKeyString, &logMetadata)
+	err = cts.updateLogDirectory(ctx, vlog, func(e *govpb.LogDirectoryEntry) bool {
+		e.PublicKeyDer = rv.PublicDER
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	cts.setListed(logKeyString)
+
+	return rv, nil
+}
+
+// listLog makes sure that a log created before we kept a directory is listed in it. That is a write to the
+// directory, so is only done when the caller is about to write to the log anyway, and once since we started.
+func (cts *Server) listLog(ctx context.Context, vlog *verifiable.Log, logKeyString string, sk *signingKey) error {
+	cts.knownLogMutex.RLock()
+	listed := cts.listedLogs[logKeyString]
+	cts.knownLogMutex.RUnlock()
+	if listed {
+		return nil
+	}
+
+	err := cts.updateLogDirectory(ctx, vlog, func(e *govpb.LogDirectoryEntry) bool {
+		if e.PublicKeyDer != nil {
+			return false
+		}
+		e.PublicKeyDer = sk.PublicDER
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	cts.setListed(logKeyString)
+	return nil
+}
+
+// setListed records that the log with logKeyString is known to be in the directory
+func (cts *Server) setListed(logKeyString string) {
+	cts.knownLogMutex.Lock()
+	if cts.listedLogs == nil {
+		cts.listedLogs = make(map[string]bool)
+	}
+	cts.listedLogs[logKeyString] = true
+	cts.knownLogMutex.Unlock()
 }
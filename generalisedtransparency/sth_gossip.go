@@ -0,0 +1,524 @@
+package generalisedtransparency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+	"github.com/govau/verifiable-logs/merkle"
+	govpb "github.com/govau/verifiable-logs/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reasons given in STH gossip evidence
+const (
+	// GossipConflictingRoot means two validly signed heads for the same tree size have different root hashes
+	GossipConflictingRoot = "conflicting_root"
+
+	// GossipNotInTree means a validly signed head for this log does not match our tree at that size
+	GossipNotInTree = "not_in_tree"
+
+	// GossipFutureTreeSize means a validly signed head for this log is for a tree size we have not yet reached
+	GossipFutureTreeSize = "future_tree_size"
+)
+
+const (
+	// maxGossipBody is the largest STH gossip request we will read
+	maxGossipBody = 64 * 1024
+
+	// maxGossipSTHsPerSize is the most distinct heads we will record for one log at one tree size
+	maxGossipSTHsPerSize = 16
+
+	// maxGossipEvidencePerLog is the most evidence we will record against one log. The first is what matters,
+	// and more only shows the log is still misbehaving.
+	maxGossipEvidencePerLog = 64
+
+	// foreignGossipBurst and foreignGossipInterval limit how often heads for other logs may be reported to each
+	// log, allowing a burst, then one per interval
+	foreignGossipBurst    = 60
+	foreignGossipInterval = time.Second
+)
+
+// AddSTHGossipRequest is posted by clients and monitors to report an STH they have received
+type AddSTHGossipRequest struct {
+	// Key is the ASN.1 DER encoded public key of the log that signed STH. If empty, the STH is for this log.
+	Key []byte `json:"key,omitempty"`
+
+	STH *ct.GetSTHResponse `json:"sth"`
+}
+
+// STHGossipEvidence shows a log presenting different views of itself to different clients
+type STHGossipEvidence struct {
+	LogID  []byte `json:"log_id"`
+	Key    []byte `json:"key"`
+	Reason string `json:"reason"`
+
+	// Observed is when we found the problem, in milliseconds since the epoch
+	Observed uint64 `json:"observed"`
+
+	// STHs are the signed heads that conflict, including, for this log, the one we signed for the same tree size
+	STHs []*ct.GetSTHResponse `json:"sths"`
+}
+
+// AddSTHGossipResponse lists any evidence found as a result of the submitted STH
+type AddSTHGossipResponse struct {
+	Evidence []*STHGossipEvidence `json:"evidence"`
+}
+
+// GetSTHGossipResponse lists the distinct signed heads reported for a log at a tree size
+type GetSTHGossipResponse struct {
+	STHs []*ct.GetSTHResponse `json:"sths"`
+}
+
+// GetSTHGossipEvidenceResponse lists all evidence recorded by this log
+type GetSTHGossipEvidenceResponse struct {
+	Evidence []*STHGossipEvidence `json:"evidence"`
+}
+
+func sthFromPB(sth *govpb.SignedTreeHead) *ct.GetSTHResponse {
+	return &ct.GetSTHResponse{
+		TreeSize:          uint64(sth.TreeSize),
+		Timestamp:         uint64(sth.Timestamp),
+		SHA256RootHash:    sth.Sha256RootHash,
+		TreeHeadSignature: sth.TreeHeadSignature,
+	}
+}
+
+func sthToPB(sth *ct.GetSTHResponse) *govpb.SignedTreeHead {
+	return &govpb.SignedTreeHead{
+		TreeSize:          int64(sth.TreeSize),
+		Timestamp:         int64(sth.Timestamp),
+		Sha256RootHash:    sth.SHA256RootHash,
+		TreeHeadSignature: sth.TreeHeadSignature,
+	}
+}
+
+func evidenceFromPB(e *govpb.STHGossipEvidence) *STHGossipEvidence {
+	rv := &STHGossipEvidence{
+		LogID:    e.LogId,
+		Key:      e.LogKey,
+		Reason:   e.Reason,
+		Observed: uint64(e.Observed),
+	}
+	for _, sth := range e.Sths {
+		rv.STHs = append(rv.STHs, sthFromPB(sth))
+	}
+	return rv
+}
+
+func gossipRecordKey(logID []byte, treeSize uint64) []byte {
+	return append(append([]byte("gossipsth"), logID...), toIntBinary(treeSize)...)
+}
+
+// gossipEvidenceKey is where evidence against the log with logID is stored
+func gossipEvidenceKey(logID []byte) []byte {
+	return append([]byte("gossipevidence"), logID...)
+}
+
+// gossipEvidenceLogsKey lists the logs with evidence against them
+var gossipEvidenceLogsKey = []byte("gossipevidencelogs")
+
+// gossipLimit is a token bucket limiting reports of heads for other logs
+type gossipLimit struct {
+	tokens  float64
+	updated time.Time
+}
+
+// allowForeignGossip returns true if a head for another log may be reported to vlog now
+func (cts *Server) allowForeignGossip(vlog *verifiable.Log) bool {
+	cts.gossipMutex.Lock()
+	defer cts.gossipMutex.Unlock()
+
+	if cts.gossipLimits == nil {
+		cts.gossipLimits = make(map[string]*gossipLimit)
+	}
+	name := vlog.Log.Account.Id + "/" + vlog.Log.Name
+	limit, ok := cts.gossipLimits[name]
+	now := time.Now()
+	if !ok {
+		limit = &gossipLimit{tokens: foreignGossipBurst, updated: now}
+		cts.gossipLimits[name] = limit
+	}
+
+	limit.tokens += float64(now.Sub(limit.updated)) / float64(foreignGossipInterval)
+	if limit.tokens > foreignGossipBurst {
+		limit.tokens = foreignGossipBurst
+	}
+	limit.updated = now
+
+	if limit.tokens < 1 {
+		return false
+	}
+	limit.tokens--
+	return true
+}
+
+// isGossipLogKey returns true if heads signed with key may be reported, as it is for a log on this server,
+// or one configured in GossipLogKeys. Otherwise anyone could make a key and report as many heads as they like.
+func (cts *Server) isGossipLogKey(ctx context.Context, key []byte) (bool, error) {
+	for _, k := range cts.GossipLogKeys {
+		if bytes.Equal(k, key) {
+			return true, nil
+		}
+	}
+
+	known := false
+	err := cts.forEachDirectoryEntry(ctx, func(e *govpb.LogDirectoryEntry) bool {
+		if bytes.Equal(e.PublicKeyDer, key) {
+			known = true
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return false, err
+	}
+	return known, nil
+}
+
+func (cts *Server) handleAddSTHGossip(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	var req AddSTHGossipRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, maxGossipBody)).Decode(&req)
+	if err != nil || req.STH == nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	sk, err := cts.getSigningKey(r.Context(), vlog, false)
+	if err != nil {
+		return nil, err
+	}
+
+	key := req.Key
+	if len(key) == 0 {
+		key = sk.PublicDER
+	}
+	ours := bytes.Equal(key, sk.PublicDER)
+	if !ours {
+		known, err := cts.isGossipLogKey(r.Context(), key)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			return nil, status.Error(codes.InvalidArgument, "heads are only accepted for logs known to this server")
+		}
+		if !cts.allowForeignGossip(vlog) {
+			return nil, status.Error(codes.ResourceExhausted, "too many heads reported for other logs, try again later")
+		}
+	}
+
+	// Only record heads that were really signed by the log, else anyone could manufacture evidence
+	pubKey, err := x509.ParsePKIXPublicKey(key)
+	if err != nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+	verifier, err := ct.NewSignatureVerifier(pubKey)
+	if err != nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+	sth, err := req.STH.ToSignedTreeHead()
+	if err != nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+	err = verifier.VerifySTHSignature(*sth)
+	if err != nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	var evidence []*govpb.STHGossipEvidence
+	if ours {
+		e, err := cts.checkOwnSTH(r.Context(), vlog, key, req.STH)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			evidence = append(evidence, e)
+		}
+	}
+
+	e, err := cts.recordGossipSTH(r.Context(), vlog, key, req.STH)
+	if err != nil {
+		return nil, err
+	}
+	if e != nil {
+		evidence = append(evidence, e)
+	}
+
+	rv := &AddSTHGossipResponse{Evidence: []*STHGossipEvidence{}}
+	for _, e := range evidence {
+		rv.Evidence = append(rv.Evidence, evidenceFromPB(e))
+	}
+	return rv, nil
+}
+
+// checkOwnSTH compares a head signed with our key against our own tree, returning evidence if it does not match
+func (cts *Server) checkOwnSTH(ctx context.Context, vlog *verifiable.Log, key []byte, sth *ct.GetSTHResponse) (*govpb.STHGossipEvidence, error) {
+	head, err := vlog.TreeHead(ctx, verifiable.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	logID := sha256.Sum256(key)
+	e := &govpb.STHGossipEvidence{
+		LogId:    logID[:],
+		LogKey:   key,
+		Observed: time.Now().UnixNano() / (1000 * 1000),
+		Sths:     []*govpb.SignedTreeHead{sthToPB(sth)},
+	}
+
+	if int64(sth.TreeSize) > head.TreeSize {
+		e.Reason = GossipFutureTreeSize
+		return e, cts.saveGossipEvidence(ctx, vlog, e)
+	}
+
+	e.Reason = GossipNotInTree
+
+	// Size 0 would mean the latest head to getSTH, and in any case there is only one empty tree
+	if sth.TreeSize == 0 {
+		if bytes.Equal(sth.SHA256RootHash, merkle.EmptyRoot()) {
+			return nil, nil
+		}
+		return e, cts.saveGossipEvidence(ctx, vlog, e)
+	}
+
+	ours, err := cts.getSTH(ctx, vlog, int64(sth.TreeSize))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(ours.SHA256RootHash, sth.SHA256RootHash) {
+		return nil, nil
+	}
+
+	e.Sths = append(e.Sths, sthToPB(ours))
+	return e, cts.saveGossipEvidence(ctx, vlog, e)
+}
+
+// recordGossipSTH saves a head we have been sent, returning evidence if it conflicts with another for the same tree size
+func (cts *Server) recordGossipSTH(ctx context.Context, vlog *verifiable.Log, key []byte, sth *ct.GetSTHResponse) (*govpb.STHGossipEvidence, error) {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	logID := sha256.Sum256(key)
+	recKey := gossipRecordKey(logID[:], sth.TreeSize)
+
+	var conflicting []*govpb.SignedTreeHead
+	err = cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		var rec govpb.STHGossipRecord
+		err := kw.Get(ctx, recKey, &rec)
+		switch err {
+		case nil:
+			// continue
+		case verifiable.ErrNoSuchKey:
+			rec.LogKey = key
+		default:
+			return err
+		}
+
+		conflicting = nil
+		for _, existing := range rec.Sths {
+			if bytes.Equal(existing.TreeHeadSignature, sth.TreeHeadSignature) {
+				conflicting = nil
+				return nil // seen it already
+			}
+			if !bytes.Equal(existing.Sha256RootHash, sth.SHA256RootHash) {
+				conflicting = append(conflicting, existing)
+			}
+		}
+
+		if len(rec.Sths) >= maxGossipSTHsPerSize && len(conflicting) == 0 {
+			return nil // nothing new to learn
+		}
+
+		rec.Sths = append(rec.Sths, sthToPB(sth))
+		return kw.Set(ctx, recKey, &rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conflicting) == 0 {
+		return nil, nil
+	}
+
+	e := &govpb.STHGossipEvidence{
+		LogId:    logID[:],
+		LogKey:   key,
+		Reason:   GossipConflictingRoot,
+		Observed: time.Now().UnixNano() / (1000 * 1000),
+		Sths:     append(conflicting, sthToPB(sth)),
+	}
+	return e, cts.saveGossipEvidence(ctx, vlog, e)
+}
+
+func (cts *Server) saveGossipEvidence(ctx context.Context, vlog *verifiable.Log, e *govpb.STHGossipEvidence) error {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return err
+	}
+
+	return cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		var list govpb.STHGossipEvidenceList
+		err := kw.Get(ctx, gossipEvidenceKey(e.LogId), &list)
+		switch err {
+		case nil, verifiable.ErrNoSuchKey:
+			// continue
+		default:
+			return err
+		}
+
+		for _, existing := range list.Evidence {
+			if sameGossipEvidence(existing, e) {
+				return nil // already recorded, e.g. the same head was posted again
+			}
+		}
+		if len(list.Evidence) >= maxGossipEvidencePerLog {
+			return nil
+		}
+
+		if len(list.Evidence) == 0 {
+			var logs govpb.STHGossipEvidenceLogs
+			err := kw.Get(ctx, gossipEvidenceLogsKey, &logs)
+			switch err {
+			case nil, verifiable.ErrNoSuchKey:
+				// continue
+			default:
+				return err
+			}
+			logs.LogIds = append(logs.LogIds, e.LogId)
+			err = kw.Set(ctx, gossipEvidenceLogsKey, &logs)
+			if err != nil {
+				return err
+			}
+		}
+
+		list.Evidence = append(list.Evidence, e)
+		return kw.Set(ctx, gossipEvidenceKey(e.LogId), &list)
+	})
+}
+
+// sameGossipEvidence returns true if a and b are for the same reason, with the same heads
+func sameGossipEvidence(a, b *govpb.STHGossipEvidence) bool {
+	if a.Reason != b.Reason || !bytes.Equal(a.LogId, b.LogId) || len(a.Sths) != len(b.Sths) {
+		return false
+	}
+	for i := range a.Sths {
+		if !bytes.Equal(a.Sths[i].TreeHeadSignature, b.Sths[i].TreeHeadSignature) {
+			return false
+		}
+	}
+	return true
+}
+
+func (cts *Server) handleGetSTHGossip(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	treeSize, err := strconv.ParseUint(r.FormValue("tree_size"), 10, 64)
+	if err != nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	var logID []byte
+	if r.FormValue("log_id") == "" {
+		sk, err := cts.getSigningKey(r.Context(), vlog, false)
+		if err != nil {
+			return nil, err
+		}
+		logID = sk.LogID[:]
+	} else {
+		logID, err = base64.StdEncoding.DecodeString(r.FormValue("log_id"))
+		if err != nil || len(logID) != sha256.Size {
+			return nil, verifiable.ErrInvalidRequest
+		}
+	}
+
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	var rec govpb.STHGossipRecord
+	err = cts.Reader.ExecuteReadOnly(r.Context(), ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		return kr.Get(ctx, gossipRecordKey(logID, treeSize), &rec)
+	})
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		// continue
+	default:
+		return nil, err
+	}
+
+	rv := &GetSTHGossipResponse{STHs: []*ct.GetSTHResponse{}}
+	for _, sth := range rec.Sths {
+		rv.STHs = append(rv.STHs, sthFromPB(sth))
+	}
+	return rv, nil
+}
+
+func (cts *Server) handleGetSTHGossipEvidence(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	var logID []byte
+	if r.FormValue("log_id") != "" {
+		var err error
+		logID, err = base64.StdEncoding.DecodeString(r.FormValue("log_id"))
+		if err != nil || len(logID) != sha256.Size {
+			return nil, verifiable.ErrInvalidRequest
+		}
+	}
+
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &GetSTHGossipEvidenceResponse{Evidence: []*STHGossipEvidence{}}
+	err = cts.Reader.ExecuteReadOnly(r.Context(), ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		var keys [][]byte
+		if logID != nil {
+			keys = [][]byte{gossipEvidenceKey(logID)}
+		} else {
+			var logs govpb.STHGossipEvidenceLogs
+			err := kr.Get(ctx, gossipEvidenceLogsKey, &logs)
+			switch err {
+			case nil, verifiable.ErrNoSuchKey:
+				// continue
+			default:
+				return err
+			}
+			for _, id := range logs.LogIds {
+				keys = append(keys, gossipEvidenceKey(id))
+			}
+		}
+
+		for _, key := range keys {
+			var list govpb.STHGossipEvidenceList
+			err := kr.Get(ctx, key, &list)
+			switch err {
+			case nil:
+				// continue
+			case verifiable.ErrNoSuchKey:
+				continue
+			default:
+				return err
+			}
+			for _, e := range list.Evidence {
+				if logID == nil || bytes.Equal(e.LogId, logID) {
+					rv.Evidence = append(rv.Evidence, evidenceFromPB(e))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,487 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	ct "github.com/google/certificate-transparency-go"
+	govpb "github.com/govau/verifiable-logs/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// sthIndexChunkSize is the number of entries stored in each chunk of the STH index
+	sthIndexChunkSize = 1024
+
+	// defaultSTHHistoryCount and maxSTHHistoryCount limit the STHs returned by one get-sth-history request
+	defaultSTHHistoryCount = 100
+	maxSTHHistoryCount     = 1000
+
+	// maxSTHHistoryScan limits how many index entries one get-sth-history request will look at,
+	// so that a filter that matches little does not scan the whole index.
+	maxSTHHistoryScan = 10 * sthIndexChunkSize
+
+	// sthIndexBuildBatchSize is the number of tree sizes looked at for STHs in each update while building the index
+	sthIndexBuildBatchSize = 1000
+)
+
+// errSTHIndexBuilding is returned by calls that need the index of STHs before it has been built
+var errSTHIndexBuilding = status.Error(codes.Unavailable, "the index of signed tree heads is being built, try again later")
+
+var (
+	sthIndexMetadataKey = []byte("sthindex")
+	sthIndexBuildKey    = []byte("sthindexbuild")
+)
+
+func sthIndexChunkKey(chunk int64) []byte {
+	return append([]byte("sthindexchunk"), toIntBinary(uint64(chunk))...)
+}
+
+func sthIndexBuildChunkKey(chunk int64) []byte {
+	return append([]byte("sthindexbuildchunk"), toIntBinary(uint64(chunk))...)
+}
+
+// GetSTHHistoryResponse is a page of the STHs signed by a log, in the order they were signed
+type GetSTHHistoryResponse struct {
+	STHs []*ct.GetSTHResponse `json:"sths"`
+
+	// Next is the start parameter to fetch the next page, if there may be more
+	Next *int64 `json:"next,omitempty"`
+}
+
+// GetTreeSizeAtTimeResponse gives the largest tree size the log had signed an STH for at a time
+type GetTreeSizeAtTimeResponse struct {
+	TreeSize uint64             `json:"tree_size"`
+	STH      *ct.GetSTHResponse `json:"sth"`
+}
+
+// appendChunkedSTHIndex adds entry to the chunks at chunkKey that hold count entries
+func appendChunkedSTHIndex(ctx context.Context, kw verifiable.KeyWriter, chunkKey func(chunk int64) []byte, count int64, entry *govpb.STHIndexEntry) error {
+	key := chunkKey(count / sthIndexChunkSize)
+	var chunk govpb.STHIndexChunk
+	err := kw.Get(ctx, key, &chunk)
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		// continue
+	default:
+		return err
+	}
+
+	chunk.Entries = append(chunk.Entries, entry)
+	return kw.Set(ctx, key, &chunk)
+}
+
+// summariseSTHIndex adds entry, as the next in the index, to the summary of its chunk in md
+func summariseSTHIndex(md *govpb.STHIndexMetadata, entry *govpb.STHIndexEntry) {
+	c := md.Count / sthIndexChunkSize
+	if int64(len(md.ChunkMaxTreeSize)) == c {
+		md.ChunkMinTimestamp = append(md.ChunkMinTimestamp, entry.Timestamp)
+		md.ChunkMaxTimestamp = append(md.ChunkMaxTimestamp, entry.Timestamp)
+		md.ChunkMaxTreeSize = append(md.ChunkMaxTreeSize, entry.TreeSize)
+		return
+	}
+	if entry.Timestamp < md.ChunkMinTimestamp[c] {
+		md.ChunkMinTimestamp[c] = entry.Timestamp
+	}
+	if entry.Timestamp > md.ChunkMaxTimestamp[c] {
+		md.ChunkMaxTimestamp[c] = entry.Timestamp
+	}
+	if entry.TreeSize > md.ChunkMaxTreeSize[c] {
+		md.ChunkMaxTreeSize[c] = entry.TreeSize
+	}
+}
+
+// appendSTHIndex adds an entry to the index of signed STHs. If the index is being built, it is added to the
+// build, and if that has not started yet, it does nothing, as the entry will be found when it does.
+func appendSTHIndex(ctx context.Context, kw verifiable.KeyWriter, entry *govpb.STHIndexEntry) error {
+	var md govpb.STHIndexMetadata
+	err := kw.Get(ctx, sthIndexMetadataKey, &md)
+	switch err {
+	case nil:
+		// continue
+	case verifiable.ErrNoSuchKey:
+		var build govpb.STHIndexBuild
+		err = kw.Get(ctx, sthIndexBuildKey, &build)
+		switch err {
+		case nil:
+			// continue
+		case verifiable.ErrNoSuchKey:
+			return nil
+		default:
+			return err
+		}
+
+		err = appendChunkedSTHIndex(ctx, kw, sthIndexBuildChunkKey, build.Count, entry)
+		if err != nil {
+			return err
+		}
+		build.Count++
+		return kw.Set(ctx, sthIndexBuildKey, &build)
+	default:
+		return err
+	}
+
+	err = appendChunkedSTHIndex(ctx, kw, sthIndexChunkKey, md.Count, entry)
+	if err != nil {
+		return err
+	}
+
+	summariseSTHIndex(&md, entry)
+	md.Count++
+	return kw.Set(ctx, sthIndexMetadataKey, &md)
+}
+
+// startSTHIndexBuild builds the index of signed STHs for vlog in the background, unless already doing so. Until
+// it is built, get-sth-history and get-tree-size-at-time are unavailable for the log.
+func (cts *Server) startSTHIndexBuild(vlog *verifiable.Log) {
+	name := vlog.Log.Account.Id + "/" + vlog.Log.Name
+
+	cts.sthIndexMutex.Lock()
+	defer cts.sthIndexMutex.Unlock()
+	if cts.sthIndexBuilds == nil {
+		cts.sthIndexBuilds = make(map[string]bool)
+	}
+	if cts.sthIndexBuilds[name] {
+		return
+	}
+	cts.sthIndexBuilds[name] = true
+
+	go func() {
+		err := cts.buildSTHIndex(context.Background(), vlog)
+		if err != nil {
+			log.Println("error building sth index for", name, err)
+		}
+
+		// Once built we won't be asked again, and if it failed, the next request will try again
+		cts.sthIndexMutex.Lock()
+		delete(cts.sthIndexBuilds, name)
+		cts.sthIndexMutex.Unlock()
+	}()
+}
+
+// buildSTHIndex builds the index of signed STHs for vlog, if not already built, by looking for an STH at every
+// tree size, a batch at a time. The entries found are then sorted into the order signed.
+func (cts *Server) buildSTHIndex(ctx context.Context, vlog *verifiable.Log) error {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return err
+	}
+
+	for {
+		done := false
+		err = cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+			var md govpb.STHIndexMetadata
+			err := kw.Get(ctx, sthIndexMetadataKey, &md)
+			switch err {
+			case nil:
+				done = true
+				return nil
+			case verifiable.ErrNoSuchKey:
+				// continue
+			default:
+				return err
+			}
+
+			var build govpb.STHIndexBuild
+			err = kw.Get(ctx, sthIndexBuildKey, &build)
+			switch err {
+			case nil:
+				// continue
+			case verifiable.ErrNoSuchKey:
+				// Any STH saved before we start has a tree size no larger than the head now, and any after
+				// will be added to the build
+				head, err := vlog.TreeHead(ctx, verifiable.Head)
+				if err != nil {
+					return err
+				}
+				build.HeadTreeSize = head.TreeSize
+			default:
+				return err
+			}
+
+			if build.ScannedTreeSize > build.HeadTreeSize {
+				done = true
+				return finishSTHIndexBuild(ctx, kw, &build)
+			}
+
+			end := build.ScannedTreeSize + sthIndexBuildBatchSize
+			if end > build.HeadTreeSize+1 {
+				end = build.HeadTreeSize + 1
+			}
+			for size := build.ScannedTreeSize; size < end; size++ {
+				var sth govpb.SignedTreeHead
+				err = kw.Get(ctx, sthKey(size), &sth)
+				switch err {
+				case nil:
+					err = appendChunkedSTHIndex(ctx, kw, sthIndexBuildChunkKey, build.Count, &govpb.STHIndexEntry{
+						TreeSize:  sth.TreeSize,
+						Timestamp: sth.Timestamp,
+					})
+					if err != nil {
+						return err
+					}
+					build.Count++
+				case verifiable.ErrNoSuchKey:
+					// none signed for this size
+				default:
+					return err
+				}
+			}
+			build.ScannedTreeSize = end
+			return kw.Set(ctx, sthIndexBuildKey, &build)
+		})
+		if err != nil || done {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// finishSTHIndexBuild sorts the entries found by a build into the order signed, and saves them as the index
+func finishSTHIndexBuild(ctx context.Context, kw verifiable.KeyWriter, build *govpb.STHIndexBuild) error {
+	// STHs signed during the build may also have been found by it, and there is only one per tree size
+	seen := make(map[int64]bool)
+	var entries []*govpb.STHIndexEntry
+	for c := int64(0); c*sthIndexChunkSize < build.Count; c++ {
+		var chunk govpb.STHIndexChunk
+		err := kw.Get(ctx, sthIndexBuildChunkKey(c), &chunk)
+		if err != nil {
+			return err
+		}
+		for _, e := range chunk.Entries {
+			if !seen[e.TreeSize] {
+				seen[e.TreeSize] = true
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp < entries[j].Timestamp
+	})
+
+	var md govpb.STHIndexMetadata
+	for i := 0; i < len(entries); i += sthIndexChunkSize {
+		end := i + sthIndexChunkSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		err := kw.Set(ctx, sthIndexChunkKey(int64(i/sthIndexChunkSize)), &govpb.STHIndexChunk{
+			Entries: entries[i:end],
+		})
+		if err != nil {
+			return err
+		}
+		for _, e := range entries[i:end] {
+			summariseSTHIndex(&md, e)
+			md.Count++
+		}
+	}
+
+	return kw.Set(ctx, sthIndexMetadataKey, &md)
+}
+
+// getSTHIndexMetadata returns the metadata for the index of signed STHs, or errSTHIndexBuilding if it has not
+// been built yet
+func getSTHIndexMetadata(ctx context.Context, kr verifiable.KeyReader) (*govpb.STHIndexMetadata, error) {
+	var md govpb.STHIndexMetadata
+	err := kr.Get(ctx, sthIndexMetadataKey, &md)
+	switch err {
+	case nil:
+		return &md, nil
+	case verifiable.ErrNoSuchKey:
+		return nil, errSTHIndexBuilding
+	default:
+		return nil, err
+	}
+}
+
+// scanSTHIndex calls f for each index entry from start, in the order signed, until f returns false
+func scanSTHIndex(ctx context.Context, kr verifiable.KeyReader, md *govpb.STHIndexMetadata, start int64, f func(idx int64, entry *govpb.STHIndexEntry) bool) error {
+	var err error
+
+	var chunk govpb.STHIndexChunk
+	chunkNumber := int64(-1)
+	for idx := start; idx < md.Count; idx++ {
+		if idx/sthIndexChunkSize != chunkNumber {
+			chunkNumber = idx / sthIndexChunkSize
+			chunk = govpb.STHIndexChunk{}
+			err = kr.Get(ctx, sthIndexChunkKey(chunkNumber), &chunk)
+			if err != nil {
+				return err
+			}
+		}
+
+		offset := int(idx % sthIndexChunkSize)
+		if offset >= len(chunk.Entries) {
+			return verifiable.ErrInternalError // index is inconsistent with its metadata
+		}
+		if !f(idx, chunk.Entries[offset]) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// optionalInt64 parses the named form value, returning def if not set
+func optionalInt64(r *http.Request, name string, def int64) (int64, error) {
+	s := r.FormValue(name)
+	if s == "" {
+		return def, nil
+	}
+	rv, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || rv < 0 {
+		return 0, verifiable.ErrInvalidRequest
+	}
+	return rv, nil
+}
+
+func (cts *Server) handleSTHHistory(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	start, err := optionalInt64(r, "start", 0)
+	if err != nil {
+		return nil, err
+	}
+	count, err := optionalInt64(r, "count", defaultSTHHistoryCount)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 || count > maxSTHHistoryCount {
+		count = maxSTHHistoryCount
+	}
+
+	// Filters, all inclusive
+	var bounds [4]int64
+	for i, name := range []string{"min_tree_size", "max_tree_size", "min_timestamp", "max_timestamp"} {
+		def := int64(0)
+		if i%2 == 1 {
+			def = -1 // no maximum
+		}
+		bounds[i], err = optionalInt64(r, name, def)
+		if err != nil {
+			return nil, err
+		}
+	}
+	inRange := func(v, min, max int64) bool {
+		return v >= min && (max == -1 || v <= max)
+	}
+
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &GetSTHHistoryResponse{STHs: []*ct.GetSTHResponse{}}
+	err = cts.Reader.ExecuteReadOnly(r.Context(), ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		md, err := getSTHIndexMetadata(ctx, kr)
+		if err != nil {
+			return err
+		}
+
+		var innerErr error
+		err = scanSTHIndex(ctx, kr, md, start, func(idx int64, entry *govpb.STHIndexEntry) bool {
+			if int64(len(rv.STHs)) == count || idx-start == maxSTHHistoryScan {
+				rv.Next = &idx
+				return false
+			}
+
+			if !inRange(entry.TreeSize, bounds[0], bounds[1]) || !inRange(entry.Timestamp, bounds[2], bounds[3]) {
+				return true
+			}
+
+			var sth govpb.SignedTreeHead
+			innerErr = kr.Get(ctx, sthKey(entry.TreeSize), &sth)
+			if innerErr != nil {
+				return false
+			}
+			rv.STHs = append(rv.STHs, sthFromPB(&sth))
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return innerErr
+	})
+	if err == errSTHIndexBuilding {
+		cts.startSTHIndexBuild(vlog)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+func (cts *Server) handleTreeSizeAtTime(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	timestamp, err := strconv.ParseInt(r.FormValue("timestamp"), 10, 64)
+	if err != nil {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	// STHs for old tree sizes may be signed at any time, so we want the largest of all signed by then. The summary
+	// of each chunk lets us skip those entirely before or after the time, which is all but those around it.
+	var rv *GetTreeSizeAtTimeResponse
+	err = cts.Reader.ExecuteReadOnly(r.Context(), ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		md, err := getSTHIndexMetadata(ctx, kr)
+		if err != nil {
+			return err
+		}
+
+		best := int64(-1)
+		for c := range md.ChunkMaxTreeSize {
+			switch {
+			case md.ChunkMinTimestamp[c] > timestamp || md.ChunkMaxTreeSize[c] <= best:
+				// nothing better in this chunk
+			case md.ChunkMaxTimestamp[c] <= timestamp:
+				best = md.ChunkMaxTreeSize[c]
+			default:
+				var chunk govpb.STHIndexChunk
+				err = kr.Get(ctx, sthIndexChunkKey(int64(c)), &chunk)
+				if err != nil {
+					return err
+				}
+				for _, entry := range chunk.Entries {
+					if entry.Timestamp <= timestamp && entry.TreeSize > best {
+						best = entry.TreeSize
+					}
+				}
+			}
+		}
+
+		if best == -1 {
+			return verifiable.ErrNotFound
+		}
+
+		var sth govpb.SignedTreeHead
+		err = kr.Get(ctx, sthKey(best), &sth)
+		if err != nil {
+			return err
+		}
+
+		rv = &GetTreeSizeAtTimeResponse{
+			TreeSize: uint64(sth.TreeSize),
+			STH:      sthFromPB(&sth),
+		}
+		return nil
+	})
+	if err == errSTHIndexBuilding {
+		cts.startSTHIndexBuild(vlog)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,215 @@
+package generalisedtransparency
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+const (
+	// streamPollInterval is how often a stream checks for new entries, in case they were added by another
+	// server process, and so we wouldn't have been notified. A comment is sent if there is nothing new,
+	// to stop proxies closing the connection.
+	streamPollInterval = 15 * time.Second
+
+	// streamMinInterval is the least time between checks for new entries, which bounds how often we sign
+	// a new STH for a stream while entries are being added
+	streamMinInterval = time.Second
+)
+
+// logUpdated returns a channel that is closed when entries are next added to vlog by this server
+func (cts *Server) logUpdated(vlog *verifiable.Log) <-chan struct{} {
+	cts.updatedMutex.Lock()
+	defer cts.updatedMutex.Unlock()
+
+	if cts.updated == nil {
+		cts.updated = make(map[string]chan struct{})
+	}
+
+	key := cacheKey(vlog, "updated")
+	rv, ok := cts.updated[key]
+	if !ok {
+		rv = make(chan struct{})
+		cts.updated[key] = rv
+	}
+	return rv
+}
+
+// newWaker returns a channel that is signalled when entries are added to any log by this server, for a
+// background process that would otherwise only poll
+func (cts *Server) newWaker() <-chan struct{} {
+	cts.updatedMutex.Lock()
+	defer cts.updatedMutex.Unlock()
+
+	rv := make(chan struct{}, 1)
+	cts.wakers = append(cts.wakers, rv)
+	return rv
+}
+
+// notifyUpdated wakes any streams waiting for entries to be added to vlog, and background processes
+func (cts *Server) notifyUpdated(vlog *verifiable.Log) {
+	cts.updatedMutex.Lock()
+	defer cts.updatedMutex.Unlock()
+
+	key := cacheKey(vlog, "updated")
+	if ch, ok := cts.updated[key]; ok {
+		close(ch)
+		delete(cts.updated, key)
+	}
+
+	for _, wake := range cts.wakers {
+		select {
+		case wake <- struct{}{}:
+		default: // already due to run
+		}
+	}
+}
+
+// sseWriter writes Server-Sent Events
+type sseWriter struct {
+	w  http.ResponseWriter
+	bw *bufio.Writer
+}
+
+// event writes an event, with an id if not empty, and v as JSON data
+func (s *sseWriter) event(name, id string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		fmt.Fprintf(s.bw, "id: %s\n", id)
+	}
+	fmt.Fprintf(s.bw, "event: %s\ndata: %s\n\n", name, b)
+	return nil
+}
+
+// comment writes a comment, which clients ignore
+func (s *sseWriter) comment(c string) {
+	fmt.Fprintf(s.bw, ": %s\n\n", c)
+}
+
+// flush sends what we have written to the client
+func (s *sseWriter) flush() error {
+	err := s.bw.Flush()
+	if err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// handleStream sends new entries and STHs as Server-Sent Events as they are added, starting from the
+// start param, or after the Last-Event-ID sent by a reconnecting client, else from the current tree size
+func (cts *Server) handleStream(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	next := int64(-1)
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		last, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, verifiable.ErrInvalidRequest
+		}
+		next = last + 1
+	} else if s := r.FormValue("start"); s != "" {
+		var err error
+		next, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || next < 0 {
+			return nil, verifiable.ErrInvalidRequest
+		}
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+
+	return &streamedResponse{
+		Header: header,
+		Write: func(w http.ResponseWriter) error {
+			return cts.stream(r.Context(), vlog, &sseWriter{w: w, bw: bufio.NewWriter(w)}, next, allowedFields(r))
+		},
+	}, nil
+}
+
+// stream sends events until ctx is done. If next is negative, entries are sent from the current tree size.
+func (cts *Server) stream(ctx context.Context, vlog *verifiable.Log, sse *sseWriter, next int64, fields []string) error {
+	lastSTHSize := int64(-1)
+	for {
+		// Get this before we check the tree, so that we can't miss an update
+		updated := cts.logUpdated(vlog)
+
+		root, err := vlog.TreeHead(ctx, verifiable.Head)
+		if err != nil {
+			return err
+		}
+		if next < 0 {
+			next = root.TreeSize
+		}
+
+		// A client resuming from an index past the tree has nothing to send until the tree is that large
+		if next < root.TreeSize {
+			it := newEntryIterator(ctx, vlog, next, root.TreeSize)
+			for it.Next() {
+				idx, entry := it.Entry()
+				e, err := apiEntry(idx, entry, fields)
+				if err != nil {
+					return err
+				}
+				err = sse.event("entry", strconv.FormatInt(idx, 10), e)
+				if err != nil {
+					return err
+				}
+				err = flushBatch(sse.w, idx, sse.flush)
+				if err != nil {
+					return err
+				}
+			}
+			if it.Err() != nil {
+				return it.Err()
+			}
+			next = root.TreeSize
+		}
+
+		if root.TreeSize != lastSTHSize {
+			sth, err := cts.getSTH(ctx, vlog, root.TreeSize)
+			if err != nil {
+				return err
+			}
+			err = sse.event("sth", "", &APISTH{
+				TreeSize:  sth.TreeSize,
+				Timestamp: sth.Timestamp,
+				RootHash:  sth.SHA256RootHash,
+				Signature: sth.TreeHeadSignature,
+			})
+			if err != nil {
+				return err
+			}
+			lastSTHSize = root.TreeSize
+		}
+
+		err = sse.flush()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-updated:
+		case <-time.After(streamPollInterval):
+			sse.comment("keepalive")
+		case <-ctx.Done():
+			return nil
+		}
+
+		select {
+		case <-time.After(streamMinInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,785 @@
+package generalisedtransparency
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	govpb "github.com/govau/verifiable-logs/pb"
+)
+
+const (
+	// WebhookSignatureHeader is the header with the HMAC-SHA256 of the payload, as "sha256=" followed by the hex
+	// encoded MAC, keyed by the subscriber's secret
+	WebhookSignatureHeader = "X-Verifiable-Signature"
+
+	// WebhookDeliveryHeader is the header with the delivery ID, which is the same for retries of a delivery
+	WebhookDeliveryHeader = "X-Verifiable-Delivery"
+
+	// webhookPollInterval is how often we check logs for new entries, in case they were added by another server
+	// process, and for deliveries due to be retried
+	webhookPollInterval = 10 * time.Second
+
+	// webhookMaxEntries is the most entries in one payload
+	webhookMaxEntries = 100
+
+	// webhookMaxPending is the most deliveries queued for a log, beyond which we stop queueing more until
+	// some are sent, so that a subscriber that is down does not fill storage
+	webhookMaxPending = 1000
+
+	// webhookMaxCompleted is the number of completed deliveries kept per log for reporting status
+	webhookMaxCompleted = 100
+
+	// webhookMaxAttempts is the most times we try a delivery before giving up
+	webhookMaxAttempts = 10
+
+	// webhookSendTimeout bounds each attempt to send a delivery
+	webhookSendTimeout = 30 * time.Second
+
+	// webhookLease is how long a delivery is reserved for the server sending it, after which another may retry.
+	// It is claimed just before it is sent, so this only needs to be longer than webhookSendTimeout.
+	webhookLease = time.Minute
+
+	// webhookMaxSends is the most deliveries sent to a subscriber in one pass, so that other logs are not
+	// held up for long by a subscriber with a backlog
+	webhookMaxSends = 10
+
+	// webhookMaxConcurrent is the most subscribers of a log that are sent deliveries at once
+	webhookMaxConcurrent = 10
+
+	// webhookMaxBackoff limits the wait between attempts
+	webhookMaxBackoff = time.Hour
+)
+
+var webhookStateKey = []byte("webhooks")
+
+func webhookDeliveryKey(id int64) []byte {
+	return append([]byte("webhookdelivery"), toIntBinary(uint64(id))...)
+}
+
+// WebhookEntry is an entry in a WebhookPayload
+type WebhookEntry struct {
+	// Index is the 0-based index of the entry in the log
+	Index int64 `json:"index"`
+
+	// ObjectHash is set for objecthash entries
+	ObjectHash HexBytes `json:"object_hash,omitempty"`
+}
+
+// WebhookPayload is posted to subscribers when entries are added to a log. Entries are included in STH.
+type WebhookPayload struct {
+	// Account and Log identify the log
+	Account string `json:"account"`
+	Log     string `json:"log"`
+
+	Entries []*WebhookEntry `json:"entries"`
+	STH     *APISTH         `json:"sth"`
+}
+
+// AddWebhookRequest is posted to the admin API to add a subscriber
+type AddWebhookRequest struct {
+	URL string `json:"url"`
+
+	// Secret is the key for the HMAC of payloads. If empty, one is generated.
+	Secret string `json:"secret,omitempty"`
+}
+
+// RemoveWebhookRequest is posted to the admin API to remove a subscriber
+type RemoveWebhookRequest struct {
+	ID string `json:"id"`
+}
+
+// WebhookSubscriber is a subscriber, as returned by the admin API
+type WebhookSubscriber struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+
+	// Secret is only returned when the subscriber is added
+	Secret string `json:"secret,omitempty"`
+
+	// Created is in milliseconds since the epoch
+	Created uint64 `json:"created"`
+}
+
+// WebhookDelivery is the status of a delivery, as returned by the admin API
+type WebhookDelivery struct {
+	ID           int64  `json:"id"`
+	SubscriberID string `json:"subscriber_id"`
+
+	// Created, NextAttempt and Completed are in milliseconds since the epoch
+	Created     uint64 `json:"created"`
+	Attempts    int    `json:"attempts"`
+	NextAttempt uint64 `json:"next_attempt,omitempty"`
+	Completed   uint64 `json:"completed,omitempty"`
+	Delivered   bool   `json:"delivered"`
+
+	// LastStatus is the HTTP status code of the last attempt, or 0 if there was no response
+	LastStatus int    `json:"last_status,omitempty"`
+	LastError  string `json:"last_error,omitempty"`
+
+	// Payload is as was posted
+	Payload json.RawMessage `json:"payload"`
+}
+
+// WebhooksResponse is returned by the admin API, with subscribers and the status of deliveries
+type WebhooksResponse struct {
+	Subscribers []*WebhookSubscriber `json:"subscribers"`
+
+	// QueuedTreeSize is the tree size up to which deliveries have been queued
+	QueuedTreeSize int64 `json:"queued_tree_size"`
+
+	Pending   []*WebhookDelivery `json:"pending"`
+	Completed []*WebhookDelivery `json:"completed"`
+}
+
+// WebhookSignature returns the value of WebhookSignatureHeader for body
+func WebhookSignature(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature returns true if signature, as sent in WebhookSignatureHeader, is correct for body
+func VerifyWebhookSignature(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(WebhookSignature(secret, body)), []byte(signature))
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / (1000 * 1000)
+}
+
+// validWebhookURL returns true if s is an absolute http(s) URL
+func validWebhookURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && u.User == nil
+}
+
+// readWebhookState returns the webhook state for a log, which is empty if there have been no subscribers
+func readWebhookState(ctx context.Context, kr verifiable.KeyReader) (*govpb.WebhookState, error) {
+	var ws govpb.WebhookState
+	err := kr.Get(ctx, webhookStateKey, &ws)
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		return &ws, nil
+	default:
+		return nil, err
+	}
+}
+
+// readWebhookDeliveries returns the pending and completed deliveries for a log
+func readWebhookDeliveries(ctx context.Context, kr verifiable.KeyReader, ws *govpb.WebhookState) ([]*govpb.WebhookDelivery, []*govpb.WebhookDelivery, error) {
+	read := func(ids []int64) ([]*govpb.WebhookDelivery, error) {
+		var rv []*govpb.WebhookDelivery
+		for _, id := range ids {
+			var d govpb.WebhookDelivery
+			err := kr.Get(ctx, webhookDeliveryKey(id), &d)
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, &d)
+		}
+		return rv, nil
+	}
+
+	pending, err := read(ws.PendingIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	completed, err := read(ws.CompletedIds)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pending, completed, nil
+}
+
+// clearWebhookDelivery overwrites a delivery that is no longer needed, so that its payload is not kept
+func clearWebhookDelivery(ctx context.Context, kw verifiable.KeyWriter, id int64) error {
+	return kw.Set(ctx, webhookDeliveryKey(id), &govpb.WebhookDelivery{})
+}
+
+// updateWebhookState calls f to modify the webhook state for a log, and saves it if f returns nil. f may
+// also read and write deliveries with kw.
+func (cts *Server) updateWebhookState(ctx context.Context, vlog *verifiable.Log, f func(ctx context.Context, kw verifiable.KeyWriter, ws *govpb.WebhookState) error) (*govpb.WebhookState, error) {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	var ws *govpb.WebhookState
+	err = cts.Writer.ExecuteUpdate(ctx, ns[:], func(ctx context.Context, kw verifiable.KeyWriter) error {
+		var err error
+		ws, err = readWebhookState(ctx, kw)
+		if err != nil {
+			return err
+		}
+		err = f(ctx, kw, ws)
+		if err != nil {
+			return err
+		}
+		return kw.Set(ctx, webhookStateKey, ws)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// getWebhookState returns the webhook state for a log, which is empty if there have been no subscribers
+func (cts *Server) getWebhookState(ctx context.Context, vlog *verifiable.Log) (*govpb.WebhookState, error) {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	var ws *govpb.WebhookState
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		var err error
+		ws, err = readWebhookState(ctx, kr)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// getWebhooks returns the webhook state for a log, with its subscribers and deliveries, for the admin API
+func (cts *Server) getWebhooks(ctx context.Context, vlog *verifiable.Log) (*WebhooksResponse, error) {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv *WebhooksResponse
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		ws, err := readWebhookState(ctx, kr)
+		if err != nil {
+			return err
+		}
+		rv, err = webhooksResponse(ctx, kr, ws)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// setHasWebhooks records in the directory whether a log has subscribers
+func (cts *Server) setHasWebhooks(ctx context.Context, vlog *verifiable.Log, has bool) error {
+	return cts.updateLogDirectory(ctx, vlog, func(e *govpb.LogDirectoryEntry) bool {
+		if e.Webhooks == has {
+			return false
+		}
+		e.Webhooks = has
+		return true
+	})
+}
+
+func webhookDelivery(d *govpb.WebhookDelivery) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:           d.Id,
+		SubscriberID: d.SubscriberId,
+		Created:      uint64(d.Created),
+		Attempts:     int(d.Attempts),
+		NextAttempt:  uint64(d.NextAttempt),
+		Completed:    uint64(d.Completed),
+		Delivered:    d.Delivered,
+		LastStatus:   int(d.LastStatus),
+		LastError:    d.LastError,
+		Payload:      d.Payload,
+	}
+}
+
+func webhooksResponse(ctx context.Context, kr verifiable.KeyReader, ws *govpb.WebhookState) (*WebhooksResponse, error) {
+	pending, completed, err := readWebhookDeliveries(ctx, kr, ws)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &WebhooksResponse{
+		Subscribers:    []*WebhookSubscriber{},
+		QueuedTreeSize: ws.QueuedTreeSize,
+		Pending:        []*WebhookDelivery{},
+		Completed:      []*WebhookDelivery{},
+	}
+	for _, s := range ws.Subscribers {
+		rv.Subscribers = append(rv.Subscribers, &WebhookSubscriber{
+			ID:      s.Id,
+			URL:     s.Url,
+			Created: uint64(s.Created),
+		})
+	}
+	for _, d := range pending {
+		rv.Pending = append(rv.Pending, webhookDelivery(d))
+	}
+	for _, d := range completed {
+		rv.Completed = append(rv.Completed, webhookDelivery(d))
+	}
+	return rv, nil
+}
+
+func (cts *Server) handleGetWebhooks(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	return cts.getWebhooks(r.Context(), vlog)
+}
+
+func (cts *Server) handleAddWebhook(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	var req AddWebhookRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || !validWebhookURL(req.URL) {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	secret := []byte(req.Secret)
+	if len(secret) == 0 {
+		b := make([]byte, 32)
+		_, err = rand.Read(b)
+		if err != nil {
+			return nil, err
+		}
+		secret = []byte(hex.EncodeToString(b))
+	}
+
+	idBytes := make([]byte, 8)
+	_, err = rand.Read(idBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// New subscribers are notified of entries added from now on
+	root, err := vlog.TreeHead(r.Context(), verifiable.Head)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &govpb.WebhookSubscriber{
+		Id:      hex.EncodeToString(idBytes),
+		Url:     req.URL,
+		Secret:  secret,
+		Created: millis(time.Now()),
+	}
+	_, err = cts.updateWebhookState(r.Context(), vlog, func(ctx context.Context, kw verifiable.KeyWriter, ws *govpb.WebhookState) error {
+		if len(ws.Subscribers) == 0 {
+			ws.QueuedTreeSize = root.TreeSize
+		}
+		ws.Subscribers = append(ws.Subscribers, sub)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cts.setHasWebhooks(r.Context(), vlog, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookSubscriber{
+		ID:      sub.Id,
+		URL:     sub.Url,
+		Secret:  string(sub.Secret),
+		Created: uint64(sub.Created),
+	}, nil
+}
+
+func (cts *Server) handleRemoveWebhook(vlog *verifiable.Log, r *http.Request) (interface{}, error) {
+	var req RemoveWebhookRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.ID == "" {
+		return nil, verifiable.ErrInvalidRequest
+	}
+
+	ws, err := cts.updateWebhookState(r.Context(), vlog, func(ctx context.Context, kw verifiable.KeyWriter, ws *govpb.WebhookState) error {
+		var subs []*govpb.WebhookSubscriber
+		for _, s := range ws.Subscribers {
+			if s.Id != req.ID {
+				subs = append(subs, s)
+			}
+		}
+		if len(subs) == len(ws.Subscribers) {
+			return verifiable.ErrNotFound
+		}
+		ws.Subscribers = subs
+
+		// Pending deliveries for the subscriber will never be sent
+		var pending []int64
+		for _, id := range ws.PendingIds {
+			var d govpb.WebhookDelivery
+			err := kw.Get(ctx, webhookDeliveryKey(id), &d)
+			if err != nil {
+				return err
+			}
+			if d.SubscriberId != req.ID {
+				pending = append(pending, id)
+				continue
+			}
+			err = clearWebhookDelivery(ctx, kw, id)
+			if err != nil {
+				return err
+			}
+		}
+		ws.PendingIds = pending
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ws.Subscribers) == 0 {
+		err = cts.setHasWebhooks(r.Context(), vlog, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cts.getWebhooks(r.Context(), vlog)
+}
+
+// RunWebhooks sends notifications to webhook subscribers until ctx is done. Entries added by this server are
+// notified straight away, and those added by others within webhookPollInterval. It is safe to run in more than
+// one server process, though a subscriber may then occasionally receive a delivery twice.
+func (cts *Server) RunWebhooks(ctx context.Context) {
+	wake := cts.newWaker()
+
+	for {
+		err := cts.processWebhooks(ctx)
+		if err != nil {
+			log.Println("error processing webhooks:", err)
+		}
+
+		select {
+		case <-wake:
+		case <-time.After(webhookPollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// processWebhooks queues and sends notifications for each log with subscribers
+func (cts *Server) processWebhooks(ctx context.Context) error {
+	return cts.forEachDirectoryLog(ctx, "webhooks", func(e *govpb.LogDirectoryEntry) bool {
+		return e.Webhooks
+	}, func(ctx context.Context, vlog *verifiable.Log) error {
+		err := cts.queueWebhooks(ctx, vlog)
+		if err != nil {
+			return err
+		}
+		return cts.sendWebhooks(ctx, vlog)
+	})
+}
+
+// queueWebhooks queues deliveries to each subscriber for entries added since we last queued, in payloads
+// of at most webhookMaxEntries, along with an STH that includes them
+func (cts *Server) queueWebhooks(ctx context.Context, vlog *verifiable.Log) error {
+	for {
+		ws, err := cts.getWebhookState(ctx, vlog)
+		if err != nil {
+			return err
+		}
+		if len(ws.Subscribers) == 0 || len(ws.PendingIds) >= webhookMaxPending {
+			return nil
+		}
+
+		root, err := vlog.TreeHead(ctx, verifiable.Head)
+		if err != nil {
+			return err
+		}
+		start := ws.QueuedTreeSize
+		if start >= root.TreeSize {
+			return nil
+		}
+		end := start + webhookMaxEntries
+		if end > root.TreeSize {
+			end = root.TreeSize
+		}
+
+		sth, err := cts.getSTH(ctx, vlog, root.TreeSize)
+		if err != nil {
+			return err
+		}
+		payload := &WebhookPayload{
+			Account: vlog.Log.Account.Id,
+			Log:     vlog.Log.Name,
+			STH: &APISTH{
+				TreeSize:  sth.TreeSize,
+				Timestamp: sth.Timestamp,
+				RootHash:  sth.SHA256RootHash,
+				Signature: sth.TreeHeadSignature,
+			},
+		}
+		entries := cts.getEntries(ctx, vlog, start, end)
+		if int64(len(entries)) != end-start {
+			return errors.New("fewer entries returned than expected")
+		}
+		for i, entry := range entries {
+			de, err := DecodeEntry(start+int64(i), entry.LeafInput, nil)
+			if err != nil {
+				return err
+			}
+			payload.Entries = append(payload.Entries, &WebhookEntry{
+				Index:      de.Index,
+				ObjectHash: de.ObjectHash,
+			})
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		_, err = cts.updateWebhookState(ctx, vlog, func(ctx context.Context, kw verifiable.KeyWriter, ws *govpb.WebhookState) error {
+			// Another server may have queued these meanwhile
+			if ws.QueuedTreeSize != start {
+				return nil
+			}
+			now := millis(time.Now())
+			for _, s := range ws.Subscribers {
+				ws.NextDeliveryId++
+				err := kw.Set(ctx, webhookDeliveryKey(ws.NextDeliveryId), &govpb.WebhookDelivery{
+					Id:           ws.NextDeliveryId,
+					SubscriberId: s.Id,
+					Payload:      body,
+					Created:      now,
+				})
+				if err != nil {
+					return err
+				}
+				ws.PendingIds = append(ws.PendingIds, ws.NextDeliveryId)
+			}
+			ws.QueuedTreeSize = end
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sendWebhooks sends deliveries that are due, to up to webhookMaxConcurrent subscribers at once, and records
+// the outcome
+func (cts *Server) sendWebhooks(ctx context.Context, vlog *verifiable.Log) error {
+	ns, err := cts.getNs(vlog)
+	if err != nil {
+		return err
+	}
+
+	// Find those that are due for each subscriber, in the order they were queued
+	subs := make(map[string]*govpb.WebhookSubscriber)
+	due := make(map[string][]int64)
+	err = cts.Reader.ExecuteReadOnly(ctx, ns[:], func(ctx context.Context, kr verifiable.KeyReader) error {
+		ws, err := readWebhookState(ctx, kr)
+		if err != nil {
+			return err
+		}
+		for _, s := range ws.Subscribers {
+			subs[s.Id] = s
+		}
+
+		now := millis(time.Now())
+		for _, id := range ws.PendingIds {
+			var d govpb.WebhookDelivery
+			err := kr.Get(ctx, webhookDeliveryKey(id), &d)
+			if err != nil {
+				return err
+			}
+			if d.NextAttempt <= now && len(due[d.SubscriberId]) < webhookMaxSends {
+				due[d.SubscriberId] = append(due[d.SubscriberId], id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, webhookMaxConcurrent)
+	errs := make(chan error, len(due))
+	for subID, ids := range due {
+		wg.Add(1)
+		go func(sub *govpb.WebhookSubscriber, ids []int64) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			errs <- cts.sendWebhooksTo(ctx, ns[:], sub, ids)
+		}(subs[subID], ids)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendWebhooksTo sends deliveries to a subscriber in turn, stopping at the first that fails, as the subscriber
+// is likely to be down. Each is claimed just before it is sent, so that other servers do not send it at the
+// same time.
+func (cts *Server) sendWebhooksTo(ctx context.Context, ns []byte, sub *govpb.WebhookSubscriber, ids []int64) error {
+	for _, id := range ids {
+		var d *govpb.WebhookDelivery
+		err := cts.Writer.ExecuteUpdate(ctx, ns, func(ctx context.Context, kw verifiable.KeyWriter) error {
+			var err error
+			d, err = claimWebhookDelivery(ctx, kw, id)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			continue // sent by another server meanwhile
+		}
+
+		status, sendErr := cts.postWebhook(ctx, sub, d)
+		if ctx.Err() != nil {
+			return ctx.Err() // leave it to be retried once the lease expires
+		}
+
+		err = cts.Writer.ExecuteUpdate(ctx, ns, func(ctx context.Context, kw verifiable.KeyWriter) error {
+			return recordWebhookAttempt(ctx, kw, id, status, sendErr)
+		})
+		if err != nil {
+			return err
+		}
+		if sendErr != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// claimWebhookDelivery reserves a delivery for webhookLease and counts the attempt, returning nil if it is no
+// longer due
+func claimWebhookDelivery(ctx context.Context, kw verifiable.KeyWriter, id int64) (*govpb.WebhookDelivery, error) {
+	var d govpb.WebhookDelivery
+	err := kw.Get(ctx, webhookDeliveryKey(id), &d)
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		// continue
+	default:
+		return nil, err
+	}
+
+	now := time.Now()
+	if d.Id != id || d.Completed != 0 || d.NextAttempt > millis(now) {
+		return nil, nil
+	}
+	d.NextAttempt = millis(now.Add(webhookLease))
+	d.Attempts++
+	err = kw.Set(ctx, webhookDeliveryKey(id), &d)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// postWebhook posts a delivery, returning the HTTP status code if there was a response
+func (cts *Server) postWebhook(ctx context.Context, sub *govpb.WebhookSubscriber, d *govpb.WebhookDelivery) (int, error) {
+	if sub == nil {
+		return 0, errors.New("subscriber has been removed")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Url, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, WebhookSignature(sub.Secret, d.Payload))
+	req.Header.Set(WebhookDeliveryHeader, fmt.Sprintf("%s-%d", sub.Id, d.Id))
+
+	ctx, cancel := context.WithTimeout(ctx, webhookSendTimeout)
+	defer cancel()
+
+	hc := cts.WebhookClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("bad http status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// recordWebhookAttempt records the outcome of an attempt, completing the delivery if it succeeded or we
+// have tried too many times, else scheduling a retry
+func recordWebhookAttempt(ctx context.Context, kw verifiable.KeyWriter, id int64, status int, sendErr error) error {
+	var d govpb.WebhookDelivery
+	err := kw.Get(ctx, webhookDeliveryKey(id), &d)
+	switch err {
+	case nil, verifiable.ErrNoSuchKey:
+		// continue
+	default:
+		return err
+	}
+	if d.Id != id || d.Completed != 0 {
+		return nil // subscriber was removed meanwhile
+	}
+
+	now := time.Now()
+	d.LastStatus = int32(status)
+	d.LastError = ""
+	if sendErr != nil {
+		d.LastError = sendErr.Error()
+		if d.Attempts < webhookMaxAttempts {
+			backoff := time.Second << uint(d.Attempts)
+			if backoff > webhookMaxBackoff {
+				backoff = webhookMaxBackoff
+			}
+			d.NextAttempt = millis(now.Add(backoff))
+			return kw.Set(ctx, webhookDeliveryKey(id), &d)
+		}
+	}
+
+	d.Delivered = sendErr == nil
+	d.Completed = millis(now)
+	d.NextAttempt = 0
+	err = kw.Set(ctx, webhookDeliveryKey(id), &d)
+	if err != nil {
+		return err
+	}
+
+	ws, err := readWebhookState(ctx, kw)
+	if err != nil {
+		return err
+	}
+	var pending []int64
+	for _, p := range ws.PendingIds {
+		if p != id {
+			pending = append(pending, p)
+		}
+	}
+	ws.PendingIds = pending
+	ws.CompletedIds = append(ws.CompletedIds, id)
+	for len(ws.CompletedIds) > webhookMaxCompleted {
+		err = clearWebhookDelivery(ctx, kw, ws.CompletedIds[0])
+		if err != nil {
+			return err
+		}
+		ws.CompletedIds = ws.CompletedIds[1:]
+	}
+	return kw.Set(ctx, webhookStateKey, ws)
+}
@@ -0,0 +1,299 @@
+package generalisedtransparency
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/storage/memory"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	govpb "github.com/govau/verifiable-logs/pb"
+)
+
+// webhookReceiver responds to each notification with each of codes in turn, then 200, recording what it was sent
+type webhookReceiver struct {
+	*httptest.Server
+
+	mutex      sync.Mutex
+	codes      []int
+	secret     []byte
+	deliveries []string
+	badSigs    int
+}
+
+func newWebhookReceiver(secret string, codes ...int) *webhookReceiver {
+	wr := &webhookReceiver{codes: codes, secret: []byte(secret)}
+	wr.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		wr.mutex.Lock()
+		defer wr.mutex.Unlock()
+		if !VerifyWebhookSignature(wr.secret, body, r.Header.Get(WebhookSignatureHeader)) {
+			wr.badSigs++
+		}
+		wr.deliveries = append(wr.deliveries, r.Header.Get(WebhookDeliveryHeader))
+		if len(wr.codes) != 0 {
+			w.WriteHeader(wr.codes[0])
+			wr.codes = wr.codes[1:]
+		}
+	}))
+	return wr
+}
+
+func (wr *webhookReceiver) received() ([]string, int) {
+	wr.mutex.Lock()
+	defer wr.mutex.Unlock()
+	return append([]string{}, wr.deliveries...), wr.badSigs
+}
+
+func newWebhookTestServer() (*Server, *verifiable.Log) {
+	storage := &memory.TransientStorage{}
+	cts := &Server{
+		Reader: storage,
+		Writer: storage,
+	}
+	vlog := &verifiable.Log{Log: &pb.LogRef{
+		Account: &pb.AccountRef{Id: "test"},
+		Name:    "webhooks",
+	}}
+	return cts, vlog
+}
+
+// addTestSubscriber adds a subscriber without going through the admin API, as that needs the log itself
+func addTestSubscriber(t *testing.T, cts *Server, vlog *verifiable.Log, id, url, secret string) {
+	_, err := cts.updateWebhookState(context.Background(), vlog, func(ctx context.Context, kw verifiable.KeyWriter, ws *govpb.WebhookState) error {
+		ws.Subscribers = append(ws.Subscribers, &govpb.WebhookSubscriber{Id: id, Url: url, Secret: []byte(secret)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// queueTestDelivery queues a delivery to a subscriber, as queueWebhooks does for entries added to the log
+func queueTestDelivery(t *testing.T, cts *Server, vlog *verifiable.Log, subscriberID, payload string) {
+	_, err := cts.updateWebhookState(context.Background(), vlog, func(ctx context.Context, kw verifiable.KeyWriter, ws *govpb.WebhookState) error {
+		ws.NextDeliveryId++
+		ws.PendingIds = append(ws.PendingIds, ws.NextDeliveryId)
+		return kw.Set(ctx, webhookDeliveryKey(ws.NextDeliveryId), &govpb.WebhookDelivery{
+			Id:           ws.NextDeliveryId,
+			SubscriberId: subscriberID,
+			Payload:      []byte(payload),
+			Created:      millis(time.Now()),
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// makeDeliveriesDue brings forward retries of pending deliveries, as if their backoff had passed
+func makeDeliveriesDue(t *testing.T, cts *Server, vlog *verifiable.Log) {
+	_, err := cts.updateWebhookState(context.Background(), vlog, func(ctx context.Context, kw verifiable.KeyWriter, ws *govpb.WebhookState) error {
+		for _, id := range ws.PendingIds {
+			var d govpb.WebhookDelivery
+			err := kw.Get(ctx, webhookDeliveryKey(id), &d)
+			if err != nil {
+				return err
+			}
+			d.NextAttempt = 0
+			err = kw.Set(ctx, webhookDeliveryKey(id), &d)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	ctx := context.Background()
+	receiver := newWebhookReceiver("secret", 500)
+	defer receiver.Close()
+
+	cts, vlog := newWebhookTestServer()
+	addTestSubscriber(t, cts, vlog, "sub", receiver.URL, "secret")
+	queueTestDelivery(t, cts, vlog, "sub", `{"entries":[]}`)
+
+	// The first attempt fails, so is retried after a backoff
+	err := cts.sendWebhooks(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := cts.getWebhooks(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Pending) != 1 || len(resp.Completed) != 0 {
+		t.Fatalf("%d pending and %d completed, want 1 and 0", len(resp.Pending), len(resp.Completed))
+	}
+	d := resp.Pending[0]
+	if d.Attempts != 1 || d.LastStatus != 500 {
+		t.Errorf("attempts %d with status %d, want 1 with 500", d.Attempts, d.LastStatus)
+	}
+	if wait := time.Duration(int64(d.NextAttempt)-millis(time.Now())) * time.Millisecond; wait < time.Second {
+		t.Errorf("retry in %s, want a backoff", wait)
+	}
+
+	// Nor is it sent again until then
+	err = cts.sendWebhooks(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deliveries, _ := receiver.received(); len(deliveries) != 1 {
+		t.Fatalf("%d requests before the backoff passed, want 1", len(deliveries))
+	}
+
+	makeDeliveriesDue(t, cts, vlog)
+	err = cts.sendWebhooks(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries, badSigs := receiver.received()
+	if badSigs != 0 {
+		t.Errorf("%d requests with a bad signature", badSigs)
+	}
+	if len(deliveries) != 2 || deliveries[0] != "sub-1" || deliveries[1] != "sub-1" {
+		t.Errorf("deliveries %v, want sub-1 twice", deliveries)
+	}
+
+	resp, err = cts.getWebhooks(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Pending) != 0 || len(resp.Completed) != 1 {
+		t.Fatalf("%d pending and %d completed, want 0 and 1", len(resp.Pending), len(resp.Completed))
+	}
+	d = resp.Completed[0]
+	if !d.Delivered || d.Completed == 0 || d.Attempts != 2 || d.LastStatus != 200 {
+		t.Errorf("completed delivery %+v, want delivered on the second attempt", d)
+	}
+}
+
+func TestRemoveWebhook(t *testing.T) {
+	ctx := context.Background()
+	kept := newWebhookReceiver("kept")
+	defer kept.Close()
+	removed := newWebhookReceiver("removed")
+	defer removed.Close()
+
+	cts, vlog := newWebhookTestServer()
+	addTestSubscriber(t, cts, vlog, "kept", kept.URL, "kept")
+	addTestSubscriber(t, cts, vlog, "removed", removed.URL, "removed")
+	queueTestDelivery(t, cts, vlog, "kept", `{"n":1}`)
+	queueTestDelivery(t, cts, vlog, "removed", `{"n":1}`)
+	queueTestDelivery(t, cts, vlog, "kept", `{"n":2}`)
+	queueTestDelivery(t, cts, vlog, "removed", `{"n":2}`)
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":"removed"}`))
+	rv, err := cts.handleRemoveWebhook(vlog, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := rv.(*WebhooksResponse)
+	if len(resp.Subscribers) != 1 || resp.Subscribers[0].ID != "kept" {
+		t.Errorf("subscribers %+v, want just kept", resp.Subscribers)
+	}
+	if len(resp.Pending) != 2 {
+		t.Fatalf("%d pending, want 2", len(resp.Pending))
+	}
+	for _, d := range resp.Pending {
+		if d.SubscriberID != "kept" {
+			t.Errorf("delivery %d to %s still pending", d.ID, d.SubscriberID)
+		}
+	}
+
+	err = cts.sendWebhooks(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deliveries, _ := removed.received(); len(deliveries) != 0 {
+		t.Errorf("removed subscriber sent %v", deliveries)
+	}
+	if deliveries, _ := kept.received(); len(deliveries) != 2 {
+		t.Errorf("kept subscriber sent %v, want 2 deliveries", deliveries)
+	}
+
+	// Removing it again is an error
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":"removed"}`))
+	_, err = cts.handleRemoveWebhook(vlog, r)
+	if err != verifiable.ErrNotFound {
+		t.Errorf("got %v, want not found", err)
+	}
+}
+
+func TestWebhookHangingSubscriber(t *testing.T) {
+	ctx := context.Background()
+	working := newWebhookReceiver("working")
+	defer working.Close()
+
+	// The hanging subscriber does not respond until the test is over, so each attempt lasts until the client
+	// gives up
+	var hangs int32
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hangs, 1)
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	cts, vlog := newWebhookTestServer()
+	cts.WebhookClient = &http.Client{Timeout: 200 * time.Millisecond}
+	addTestSubscriber(t, cts, vlog, "hanging", hanging.URL, "hanging")
+	addTestSubscriber(t, cts, vlog, "working", working.URL, "working")
+	for i := 0; i < 3; i++ {
+		queueTestDelivery(t, cts, vlog, "hanging", `{}`)
+		queueTestDelivery(t, cts, vlog, "working", `{}`)
+	}
+
+	// Only one attempt is made to the hanging subscriber, and it does not hold up the other
+	start := time.Now()
+	err := cts.sendWebhooks(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s to send", elapsed)
+	}
+	if n := atomic.LoadInt32(&hangs); n != 1 {
+		t.Errorf("%d attempts to the hanging subscriber, want 1", n)
+	}
+	if deliveries, _ := working.received(); len(deliveries) != 3 {
+		t.Errorf("working subscriber sent %v, want 3 deliveries", deliveries)
+	}
+
+	// Those not attempted are not claimed, so remain due
+	resp, err := cts.getWebhooks(ctx, vlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attempted, due int
+	now := uint64(millis(time.Now()))
+	for _, d := range resp.Pending {
+		if d.SubscriberID != "hanging" {
+			t.Errorf("delivery %d to %s still pending", d.ID, d.SubscriberID)
+			continue
+		}
+		if d.Attempts != 0 {
+			attempted++
+		}
+		if d.NextAttempt <= now {
+			due++
+		}
+	}
+	if attempted != 1 || due != 2 {
+		t.Errorf("%d attempted and %d due to the hanging subscriber, want 1 and 2", attempted, due)
+	}
+}
@@ -0,0 +1,74 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// The verifiable maps of the continusec verifiable data structures library are sparse Merkle trees, where the
+// value for a key is at the leaf reached by following the bits of the SHA-256 hash of the key, most significant
+// first, from the root (0 going left). A key that is not in the map has an empty value.
+
+// MapDepth is the number of levels below the root of a map, which is the number of entries in an audit path
+const MapDepth = sha256.Size * 8
+
+// mapEmptyHashes[i] is the hash of an empty subtree whose root is i levels below the map root
+var mapEmptyHashes = func() [][]byte {
+	rv := make([][]byte, MapDepth+1)
+	rv[MapDepth] = LeafHash(nil)
+	for i := MapDepth - 1; i >= 0; i-- {
+		rv[i] = NodeHash(rv[i+1], rv[i+1])
+	}
+	return rv
+}()
+
+// EmptyMapRoot returns the root hash of a map with no keys
+func EmptyMapRoot() []byte {
+	return mapEmptyHashes[0]
+}
+
+// mapKeyBit returns true if the path to keyHash goes right i levels below the root
+func mapKeyBit(keyHash []byte, i int) bool {
+	return keyHash[i/8]&(0x80>>uint(i%8)) != 0
+}
+
+// RootFromMapInclusionProof returns the map root hash that proof leads to for key having value, which is the
+// leaf input of the value, or empty if the key is not in the map. proof has the sibling of each node on the
+// path, from below the root down, where nil (or a proof that stops early) stands for an empty subtree.
+func RootFromMapInclusionProof(key, value []byte, proof [][]byte) ([]byte, error) {
+	if len(proof) > MapDepth {
+		return nil, errors.New("map inclusion proof too long")
+	}
+
+	keyHash := sha256.Sum256(key)
+	r := LeafHash(value)
+	for i := MapDepth - 1; i >= 0; i-- {
+		var p []byte
+		if i < len(proof) {
+			p = proof[i]
+		}
+		if p == nil {
+			p = mapEmptyHashes[i+1]
+		}
+		if mapKeyBit(keyHash[:], i) {
+			r = NodeHash(p, r)
+		} else {
+			r = NodeHash(r, p)
+		}
+	}
+	return r, nil
+}
+
+// VerifyMapInclusion checks that proof shows key has value in the map with the root given. An empty value
+// proves that key is not in the map.
+func VerifyMapInclusion(key, value []byte, proof [][]byte, root []byte) error {
+	r, err := RootFromMapInclusionProof(key, value, proof)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(r, root) {
+		return errors.New("calculated map root hash does not match")
+	}
+	return nil
+}
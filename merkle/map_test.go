@@ -0,0 +1,122 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+// refMapItem is a key in a reference map
+type refMapItem struct {
+	keyHash  []byte
+	leafHash []byte
+}
+
+// refMapRoot returns the hash of the subtree level levels below the root that holds items, by the definition
+func refMapRoot(level int, items []*refMapItem) []byte {
+	if len(items) == 0 {
+		return mapEmptyHashes[level]
+	}
+	if level == MapDepth {
+		return items[0].leafHash
+	}
+	var left, right []*refMapItem
+	for _, it := range items {
+		if mapKeyBit(it.keyHash, level) {
+			right = append(right, it)
+		} else {
+			left = append(left, it)
+		}
+	}
+	return NodeHash(refMapRoot(level+1, left), refMapRoot(level+1, right))
+}
+
+// refMapProof returns the audit path for key, with nil for empty subtrees
+func refMapProof(key []byte, items []*refMapItem) [][]byte {
+	keyHash := sha256.Sum256(key)
+	proof := make([][]byte, MapDepth)
+	for i := 0; i < MapDepth; i++ {
+		var same, other []*refMapItem
+		for _, it := range items {
+			if mapKeyBit(it.keyHash, i) == mapKeyBit(keyHash[:], i) {
+				same = append(same, it)
+			} else {
+				other = append(other, it)
+			}
+		}
+		if len(other) != 0 {
+			proof[i] = refMapRoot(i+1, other)
+		}
+		items = same
+	}
+	return proof
+}
+
+func TestEmptyMapRoot(t *testing.T) {
+	if !bytes.Equal(EmptyMapRoot(), refMapRoot(0, nil)) {
+		t.Fatal("wrong empty map root")
+	}
+	err := VerifyMapInclusion([]byte("absent"), nil, make([][]byte, MapDepth), EmptyMapRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyMapInclusion([]byte("absent"), nil, nil, EmptyMapRoot())
+	if err != nil {
+		t.Fatal("proof that stops early:", err)
+	}
+	if VerifyMapInclusion([]byte("absent"), []byte("value"), nil, EmptyMapRoot()) == nil {
+		t.Fatal("verified value in empty map")
+	}
+}
+
+// TestMapAgainstReference checks inclusion and non-inclusion proofs for maps of up to 20 keys, and that
+// a changed value or proof is detected
+func TestMapAgainstReference(t *testing.T) {
+	values := make(map[string][]byte)
+	var items []*refMapItem
+	for n := 0; n <= 20; n++ {
+		root := refMapRoot(0, items)
+
+		for k, v := range values {
+			proof := refMapProof([]byte(k), items)
+			err := VerifyMapInclusion([]byte(k), v, proof, root)
+			if err != nil {
+				t.Fatalf("inclusion of %s in %d: %s", k, n, err)
+			}
+			if VerifyMapInclusion([]byte(k), append(v, 0), proof, root) == nil {
+				t.Fatalf("inclusion of %s in %d: verified wrong value", k, n)
+			}
+			if VerifyMapInclusion([]byte(k), nil, proof, root) == nil {
+				t.Fatalf("inclusion of %s in %d: verified as absent", k, n)
+			}
+			for i := range proof {
+				if proof[i] == nil {
+					continue
+				}
+				proof[i] = append([]byte{}, proof[i]...)
+				proof[i][0] ^= 1
+				if VerifyMapInclusion([]byte(k), v, proof, root) == nil {
+					t.Fatalf("inclusion of %s in %d: verified with corrupt proof", k, n)
+				}
+				proof[i][0] ^= 1
+			}
+		}
+
+		absent := []byte(fmt.Sprintf("absent%d", n))
+		err := VerifyMapInclusion(absent, nil, refMapProof(absent, items), root)
+		if err != nil {
+			t.Fatalf("non-inclusion in %d: %s", n, err)
+		}
+
+		k := fmt.Sprintf("key%d", n)
+		v := []byte(fmt.Sprintf("value%d", n))
+		kh := sha256.Sum256([]byte(k))
+		values[k] = v
+		items = append(items, &refMapItem{keyHash: kh[:], leafHash: LeafHash(v)})
+	}
+
+	if _, err := RootFromMapInclusionProof(nil, nil, make([][]byte, MapDepth+1)); err == nil {
+		t.Fatal("accepted proof that is too long")
+	}
+}
@@ -0,0 +1,201 @@
+// Package merkle implements the Merkle Tree Hash, proof verification and incremental root
+// calculation used by RFC6962 logs, as described in https://tools.ietf.org/html/draft-ietf-trans-rfc6962-bis-28#section-2.1
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+// LeafHash returns the hash of a leaf, given its input, e.g. a TLS encoded MerkleTreeLeaf
+func LeafHash(leafInput []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0})
+	h.Write(leafInput)
+	return h.Sum(nil)
+}
+
+// NodeHash returns the hash of an interior node from its children
+func NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// EmptyRoot returns the root hash of a tree with no entries
+func EmptyRoot() []byte {
+	h := sha256.Sum256(nil)
+	return h[:]
+}
+
+// SubtreeRoot returns the Merkle Tree Hash for a list of leaf hashes, which may be a
+// whole tree, or any subtree within one.
+func SubtreeRoot(leafHashes [][]byte) []byte {
+	switch len(leafHashes) {
+	case 0:
+		return EmptyRoot()
+	case 1:
+		return leafHashes[0]
+	}
+
+	// Split at the largest power of 2 less than the number of leaves
+	k := 1
+	for k*2 < len(leafHashes) {
+		k *= 2
+	}
+	return NodeHash(SubtreeRoot(leafHashes[:k]), SubtreeRoot(leafHashes[k:]))
+}
+
+// CompactRange holds the minimal set of subtree hashes needed to calculate the root of the tree
+// with entries [0, Size), and to extend it. Hashes are ordered largest subtree first, with one
+// for each bit set in Size. It may be serialized by the caller to resume later.
+type CompactRange struct {
+	Size   uint64
+	Hashes [][]byte
+}
+
+// Append adds the leaf hash for the entry at index Size
+func (r *CompactRange) Append(leafHash []byte) {
+	r.Hashes = append(r.Hashes, leafHash)
+	for i := r.Size; (i % 2) == 1; i >>= 1 {
+		n := len(r.Hashes)
+		r.Hashes = append(r.Hashes[:n-2], NodeHash(r.Hashes[n-2], r.Hashes[n-1]))
+	}
+	r.Size++
+}
+
+// Root returns the Merkle Tree Hash for entries [0, Size)
+func (r *CompactRange) Root() []byte {
+	if len(r.Hashes) == 0 {
+		return EmptyRoot()
+	}
+	rv := r.Hashes[len(r.Hashes)-1]
+	for i := len(r.Hashes) - 2; i >= 0; i-- {
+		rv = NodeHash(r.Hashes[i], rv)
+	}
+	return rv
+}
+
+// Valid returns true if the number of hashes is consistent with Size, as it should be for
+// a range read from storage before it is used
+func (r *CompactRange) Valid() bool {
+	n := 0
+	for s := r.Size; s != 0; s >>= 1 {
+		n += int(s & 1)
+	}
+	return n == len(r.Hashes)
+}
+
+// RootFromInclusionProof calculates the root hash implied by an inclusion proof (audit path) for
+// the leaf at leafIndex, as per https://tools.ietf.org/html/draft-ietf-trans-rfc6962-bis-28#section-2.1.3.2
+func RootFromInclusionProof(leafIndex, treeSize uint64, leafHash []byte, proof [][]byte) ([]byte, error) {
+	if leafIndex >= treeSize {
+		return nil, errors.New("leaf index out of range for tree size")
+	}
+
+	fn, sn := leafIndex, treeSize-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return nil, errors.New("audit path too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = NodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = NodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return nil, errors.New("audit path too short")
+	}
+	return r, nil
+}
+
+// VerifyInclusion checks that proof shows the leaf at leafIndex is included in the tree with the root given
+func VerifyInclusion(leafIndex, treeSize uint64, leafHash []byte, proof [][]byte, root []byte) error {
+	r, err := RootFromInclusionProof(leafIndex, treeSize, leafHash, proof)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(r, root) {
+		return errors.New("calculated root hash does not match")
+	}
+	return nil
+}
+
+// VerifyConsistency checks that proof shows the tree of secondSize with secondRoot is an append-only extension
+// of that of firstSize with firstRoot, as per https://tools.ietf.org/html/draft-ietf-trans-rfc6962-bis-28#section-2.1.4.2
+func VerifyConsistency(firstSize, secondSize uint64, firstRoot, secondRoot []byte, proof [][]byte) error {
+	switch {
+	case firstSize > secondSize:
+		return errors.New("invalid tree sizes for consistency proof")
+	case firstSize == 0:
+		// Everything is consistent with the empty tree
+		if len(proof) != 0 {
+			return errors.New("consistency proof from empty tree should be empty")
+		}
+		return nil
+	case firstSize == secondSize:
+		if len(proof) != 0 {
+			return errors.New("consistency proof between identical sizes should be empty")
+		}
+		if !bytes.Equal(firstRoot, secondRoot) {
+			return errors.New("root hashes differ for same tree size")
+		}
+		return nil
+	}
+
+	// If the first tree is a complete subtree, then its root is the implicit first node
+	if firstSize&(firstSize-1) == 0 {
+		proof = append([][]byte{firstRoot}, proof...)
+	}
+	if len(proof) == 0 {
+		return errors.New("consistency proof too short")
+	}
+
+	fn, sn := firstSize-1, secondSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return errors.New("consistency proof too long")
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = NodeHash(c, fr)
+			sr = NodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = NodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return errors.New("consistency proof too short")
+	}
+	if !bytes.Equal(fr, firstRoot) {
+		return errors.New("calculated first root hash does not match")
+	}
+	if !bytes.Equal(sr, secondRoot) {
+		return errors.New("calculated second root hash does not match")
+	}
+	return nil
+}
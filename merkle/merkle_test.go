@@ -0,0 +1,269 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Leaf inputs and roots used by the reference RFC6962 implementation tests
+var leafInputs = []string{
+	"",
+	"00",
+	"10",
+	"2021",
+	"3031",
+	"40414243",
+	"5051525354555657",
+	"606162636465666768696a6b6c6d6e6f",
+}
+
+var roots = []string{
+	"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+	"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+	"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+	"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+	"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+	"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+	"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+}
+
+var inclusionProofs = []struct {
+	leafIndex, treeSize uint64
+	proof               []string
+}{
+	{0, 1, nil},
+	{0, 8, []string{
+		"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+		"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+		"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+	}},
+	{5, 8, []string{
+		"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	}},
+	{2, 3, []string{
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+	}},
+	{1, 5, []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+		"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+	}},
+}
+
+var consistencyProofs = []struct {
+	first, second uint64
+	proof         []string
+}{
+	{1, 1, nil},
+	{1, 8, []string{
+		"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+		"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+		"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+	}},
+	{6, 8, []string{
+		"0ebc5d3437fbe2db158b9f126a1d118e308181031d0a949f8dededebc558ef6a",
+		"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	}},
+	{2, 5, []string{
+		"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+		"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+	}},
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func mustHexList(t *testing.T, l []string) [][]byte {
+	var rv [][]byte
+	for _, s := range l {
+		rv = append(rv, mustHex(t, s))
+	}
+	return rv
+}
+
+func leafHashes(t *testing.T) [][]byte {
+	var rv [][]byte
+	for _, s := range leafInputs {
+		rv = append(rv, LeafHash(mustHex(t, s)))
+	}
+	return rv
+}
+
+// refPath is a direct implementation of PATH from https://tools.ietf.org/html/rfc6962#section-2.1.1
+func refPath(m int, d [][]byte) [][]byte {
+	if len(d) <= 1 {
+		return nil
+	}
+	k := 1
+	for k*2 < len(d) {
+		k *= 2
+	}
+	if m < k {
+		return append(refPath(m, d[:k]), SubtreeRoot(d[k:]))
+	}
+	return append(refPath(m-k, d[k:]), SubtreeRoot(d[:k]))
+}
+
+// refSubproof is a direct implementation of SUBPROOF from https://tools.ietf.org/html/rfc6962#section-2.1.2
+func refSubproof(m int, d [][]byte, b bool) [][]byte {
+	if m == len(d) {
+		if b {
+			return nil
+		}
+		return [][]byte{SubtreeRoot(d)}
+	}
+	k := 1
+	for k*2 < len(d) {
+		k *= 2
+	}
+	if m <= k {
+		return append(refSubproof(m, d[:k], b), SubtreeRoot(d[k:]))
+	}
+	return append(refSubproof(m-k, d[k:], false), SubtreeRoot(d[:k]))
+}
+
+func TestEmptyRoot(t *testing.T) {
+	expected := mustHex(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+	if !bytes.Equal(EmptyRoot(), expected) {
+		t.Fatal("wrong empty root")
+	}
+	if !bytes.Equal((&CompactRange{}).Root(), expected) {
+		t.Fatal("wrong empty compact range root")
+	}
+}
+
+func TestRoots(t *testing.T) {
+	lh := leafHashes(t)
+	cr := &CompactRange{}
+	for i, root := range roots {
+		expected := mustHex(t, root)
+		if !bytes.Equal(SubtreeRoot(lh[:i+1]), expected) {
+			t.Fatalf("wrong subtree root for size %d", i+1)
+		}
+
+		cr.Append(lh[i])
+		if !cr.Valid() {
+			t.Fatalf("compact range invalid at size %d", i+1)
+		}
+		if !bytes.Equal(cr.Root(), expected) {
+			t.Fatalf("wrong compact range root for size %d", i+1)
+		}
+	}
+}
+
+func TestInclusionVectors(t *testing.T) {
+	lh := leafHashes(t)
+	for _, tc := range inclusionProofs {
+		root := mustHex(t, roots[tc.treeSize-1])
+		proof := mustHexList(t, tc.proof)
+		err := VerifyInclusion(tc.leafIndex, tc.treeSize, lh[tc.leafIndex], proof, root)
+		if err != nil {
+			t.Fatalf("leaf %d size %d: %s", tc.leafIndex, tc.treeSize, err)
+		}
+
+		// Wrong index
+		if VerifyInclusion(tc.leafIndex+1, tc.treeSize, lh[tc.leafIndex], proof, root) == nil {
+			t.Fatalf("leaf %d size %d: verified with wrong index", tc.leafIndex, tc.treeSize)
+		}
+
+		// Extra element
+		if VerifyInclusion(tc.leafIndex, tc.treeSize, lh[tc.leafIndex], append(proof, root), root) == nil {
+			t.Fatalf("leaf %d size %d: verified with extra proof element", tc.leafIndex, tc.treeSize)
+		}
+	}
+}
+
+func TestConsistencyVectors(t *testing.T) {
+	for _, tc := range consistencyProofs {
+		first := mustHex(t, roots[tc.first-1])
+		second := mustHex(t, roots[tc.second-1])
+		proof := mustHexList(t, tc.proof)
+		err := VerifyConsistency(tc.first, tc.second, first, second, proof)
+		if err != nil {
+			t.Fatalf("%d to %d: %s", tc.first, tc.second, err)
+		}
+
+		// Swapped roots
+		if tc.first != tc.second && VerifyConsistency(tc.first, tc.second, second, first, proof) == nil {
+			t.Fatalf("%d to %d: verified with swapped roots", tc.first, tc.second)
+		}
+	}
+}
+
+// TestAgainstReference checks every proof for trees of up to 40 entries against the RFC6962 definitions,
+// and that any single changed byte is detected
+func TestAgainstReference(t *testing.T) {
+	var lh [][]byte
+	for i := 0; i < 40; i++ {
+		lh = append(lh, LeafHash([]byte{byte(i)}))
+	}
+
+	for n := 1; n <= len(lh); n++ {
+		root := SubtreeRoot(lh[:n])
+
+		for m := 0; m < n; m++ {
+			proof := refPath(m, lh[:n])
+			err := VerifyInclusion(uint64(m), uint64(n), lh[m], proof, root)
+			if err != nil {
+				t.Fatalf("inclusion of %d in %d: %s", m, n, err)
+			}
+			for i := range proof {
+				proof[i] = append([]byte{}, proof[i]...)
+				proof[i][0] ^= 1
+				if VerifyInclusion(uint64(m), uint64(n), lh[m], proof, root) == nil {
+					t.Fatalf("inclusion of %d in %d: verified with corrupt proof", m, n)
+				}
+				proof[i][0] ^= 1
+			}
+			if len(proof) > 0 && VerifyInclusion(uint64(m), uint64(n), lh[m], proof[:len(proof)-1], root) == nil {
+				t.Fatalf("inclusion of %d in %d: verified with short proof", m, n)
+			}
+		}
+
+		for m := 1; m <= n; m++ {
+			first := SubtreeRoot(lh[:m])
+			proof := refSubproof(m, lh[:n], true)
+			err := VerifyConsistency(uint64(m), uint64(n), first, root, proof)
+			if err != nil {
+				t.Fatalf("consistency of %d with %d: %s", m, n, err)
+			}
+			for i := range proof {
+				proof[i] = append([]byte{}, proof[i]...)
+				proof[i][0] ^= 1
+				if VerifyConsistency(uint64(m), uint64(n), first, root, proof) == nil {
+					t.Fatalf("consistency of %d with %d: verified with corrupt proof", m, n)
+				}
+				proof[i][0] ^= 1
+			}
+			if len(proof) > 0 && VerifyConsistency(uint64(m), uint64(n), first, root, proof[:len(proof)-1]) == nil {
+				t.Fatalf("consistency of %d with %d: verified with short proof", m, n)
+			}
+		}
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	root := EmptyRoot()
+	if VerifyInclusion(0, 0, root, nil, root) == nil {
+		t.Fatal("verified inclusion in empty tree")
+	}
+	if VerifyConsistency(2, 1, root, root, nil) == nil {
+		t.Fatal("verified consistency with first larger than second")
+	}
+	if VerifyConsistency(0, 5, root, root, [][]byte{root}) == nil {
+		t.Fatal("verified non-empty consistency proof from empty tree")
+	}
+	if (&CompactRange{Size: 3, Hashes: [][]byte{root}}).Valid() {
+		t.Fatal("compact range with too few hashes is valid")
+	}
+}
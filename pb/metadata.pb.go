@@ -20,10 +20,65 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
-// LogMetadata is stored per log and contains the private key
+// LogState is the lifecycle state of a log, as per the CT log list schema.
+type LogState int32
+
+const (
+	LogState_LOG_STATE_USABLE    LogState = 0
+	LogState_LOG_STATE_READ_ONLY LogState = 1
+	LogState_LOG_STATE_RETIRED   LogState = 2
+)
+
+var LogState_name = map[int32]string{
+	0: "LOG_STATE_USABLE",
+	1: "LOG_STATE_READ_ONLY",
+	2: "LOG_STATE_RETIRED",
+}
+
+var LogState_value = map[string]int32{
+	"LOG_STATE_USABLE":    0,
+	"LOG_STATE_READ_ONLY": 1,
+	"LOG_STATE_RETIRED":   2,
+}
+
+func (x LogState) String() string {
+	return proto.EnumName(LogState_name, int32(x))
+}
+
+func (LogState) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{0}
+}
+
+// LogMetadata is stored per log and contains the private key, and the
+// descriptive fields published for the log.
 type LogMetadata struct {
 	// ASN.1 DER encoded ECDSA private key
-	PrivateKeyDer        []byte   `protobuf:"bytes,2,opt,name=private_key_der,json=privateKeyDer,proto3" json:"private_key_der,omitempty"`
+	PrivateKeyDer []byte `protobuf:"bytes,2,opt,name=private_key_der,json=privateKeyDer,proto3" json:"private_key_der,omitempty"`
+	Description   string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	// Name of the operator or owner of the log
+	Operator string `protobuf:"bytes,4,opt,name=operator,proto3" json:"operator,omitempty"`
+	// Base URL of the log, if different to that it is served at
+	Url string `protobuf:"bytes,5,opt,name=url,proto3" json:"url,omitempty"`
+	// Maximum merge delay in seconds, 0 for the server default
+	MaximumMergeDelay int64    `protobuf:"varint,6,opt,name=maximum_merge_delay,json=maximumMergeDelay,proto3" json:"maximum_merge_delay,omitempty"`
+	State             LogState `protobuf:"varint,7,opt,name=state,proto3,enum=au.gov.digital.verifiabledatastructures.LogState" json:"state,omitempty"`
+	// Timestamp (milliseconds since epoch) at which the log was created, or 0 if it
+	// was created before we tracked this.
+	Created int64 `protobuf:"varint,8,opt,name=created,proto3" json:"created,omitempty"`
+	// Link to the dataset that the log is for
+	DatasetUrl string `protobuf:"bytes,9,opt,name=dataset_url,json=datasetUrl,proto3" json:"dataset_url,omitempty"`
+	// Timestamp (milliseconds since epoch) at which the state last changed
+	StateTimestamp int64 `protobuf:"varint,10,opt,name=state_timestamp,json=stateTimestamp,proto3" json:"state_timestamp,omitempty"`
+	// The last STH for the log, set when it is frozen
+	FinalSth *SignedTreeHead `protobuf:"bytes,11,opt,name=final_sth,json=finalSth,proto3" json:"final_sth,omitempty"`
+	// If set, only authenticated readers may read the log
+	Private bool `protobuf:"varint,12,opt,name=private,proto3" json:"private,omitempty"`
+	// Origins allowed for CORS requests. If empty, any origin is allowed for a public log, and none for a private log.
+	CorsOrigins []string `protobuf:"bytes,13,rep,name=cors_origins,json=corsOrigins,proto3" json:"cors_origins,omitempty"`
+	// Top-level fields of entries to index for searching
+	IndexFields []string `protobuf:"bytes,14,rep,name=index_fields,json=indexFields,proto3" json:"index_fields,omitempty"`
+	// Top-level field of entries that keys the log's verifiable map of the latest entry per key
+	MapKeyField          string   `protobuf:"bytes,15,opt,name=map_key_field,json=mapKeyField,proto3" json:"map_key_field,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -61,6 +116,97 @@ func (m *LogMetadata) GetPrivateKeyDer() []byte {
 	return nil
 }
 
+func (m *LogMetadata) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *LogMetadata) GetOperator() string {
+	if m != nil {
+		return m.Operator
+	}
+	return ""
+}
+
+func (m *LogMetadata) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *LogMetadata) GetMaximumMergeDelay() int64 {
+	if m != nil {
+		return m.MaximumMergeDelay
+	}
+	return 0
+}
+
+func (m *LogMetadata) GetState() LogState {
+	if m != nil {
+		return m.State
+	}
+	return LogState_LOG_STATE_USABLE
+}
+
+func (m *LogMetadata) GetCreated() int64 {
+	if m != nil {
+		return m.Created
+	}
+	return 0
+}
+
+func (m *LogMetadata) GetDatasetUrl() string {
+	if m != nil {
+		return m.DatasetUrl
+	}
+	return ""
+}
+
+func (m *LogMetadata) GetStateTimestamp() int64 {
+	if m != nil {
+		return m.StateTimestamp
+	}
+	return 0
+}
+
+func (m *LogMetadata) GetFinalSth() *SignedTreeHead {
+	if m != nil {
+		return m.FinalSth
+	}
+	return nil
+}
+
+func (m *LogMetadata) GetPrivate() bool {
+	if m != nil {
+		return m.Private
+	}
+	return false
+}
+
+func (m *LogMetadata) GetCorsOrigins() []string {
+	if m != nil {
+		return m.CorsOrigins
+	}
+	return nil
+}
+
+func (m *LogMetadata) GetIndexFields() []string {
+	if m != nil {
+		return m.IndexFields
+	}
+	return nil
+}
+
+func (m *LogMetadata) GetMapKeyField() string {
+	if m != nil {
+		return m.MapKeyField
+	}
+	return ""
+}
+
 // SignedTreeHead is persisted for each tree size that it is requested
 // for. In theory we could store only the last, however for now we'll keep all.
 // The fields here are as per https://tools.ietf.org/html/rfc6962#section-3.5
@@ -177,32 +323,1375 @@ func (m *AddResponse) GetSignature() []byte {
 	return nil
 }
 
+// STHGossipRecord holds the distinct signed tree heads that clients have
+// reported seeing for a single log at a single tree size.
+type STHGossipRecord struct {
+	// ASN.1 DER encoded public key of the log that signed them
+	LogKey               []byte            `protobuf:"bytes,1,opt,name=log_key,json=logKey,proto3" json:"log_key,omitempty"`
+	Sths                 []*SignedTreeHead `protobuf:"bytes,2,rep,name=sths,proto3" json:"sths,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *STHGossipRecord) Reset()         { *m = STHGossipRecord{} }
+func (m *STHGossipRecord) String() string { return proto.CompactTextString(m) }
+func (*STHGossipRecord) ProtoMessage()    {}
+func (*STHGossipRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{3}
+}
+
+func (m *STHGossipRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_STHGossipRecord.Unmarshal(m, b)
+}
+func (m *STHGossipRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_STHGossipRecord.Marshal(b, m, deterministic)
+}
+func (m *STHGossipRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_STHGossipRecord.Merge(m, src)
+}
+func (m *STHGossipRecord) XXX_Size() int {
+	return xxx_messageInfo_STHGossipRecord.Size(m)
+}
+func (m *STHGossipRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_STHGossipRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_STHGossipRecord proto.InternalMessageInfo
+
+func (m *STHGossipRecord) GetLogKey() []byte {
+	if m != nil {
+		return m.LogKey
+	}
+	return nil
+}
+
+func (m *STHGossipRecord) GetSths() []*SignedTreeHead {
+	if m != nil {
+		return m.Sths
+	}
+	return nil
+}
+
+// STHGossipEvidence records signed tree heads that show a log presenting
+// different views of itself to different clients.
+type STHGossipEvidence struct {
+	LogId  []byte `protobuf:"bytes,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	LogKey []byte `protobuf:"bytes,2,opt,name=log_key,json=logKey,proto3" json:"log_key,omitempty"`
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Timestamp (milliseconds since epoch) at which we observed the conflict
+	Observed             int64             `protobuf:"varint,4,opt,name=observed,proto3" json:"observed,omitempty"`
+	Sths                 []*SignedTreeHead `protobuf:"bytes,5,rep,name=sths,proto3" json:"sths,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *STHGossipEvidence) Reset()         { *m = STHGossipEvidence{} }
+func (m *STHGossipEvidence) String() string { return proto.CompactTextString(m) }
+func (*STHGossipEvidence) ProtoMessage()    {}
+func (*STHGossipEvidence) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{4}
+}
+
+func (m *STHGossipEvidence) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_STHGossipEvidence.Unmarshal(m, b)
+}
+func (m *STHGossipEvidence) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_STHGossipEvidence.Marshal(b, m, deterministic)
+}
+func (m *STHGossipEvidence) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_STHGossipEvidence.Merge(m, src)
+}
+func (m *STHGossipEvidence) XXX_Size() int {
+	return xxx_messageInfo_STHGossipEvidence.Size(m)
+}
+func (m *STHGossipEvidence) XXX_DiscardUnknown() {
+	xxx_messageInfo_STHGossipEvidence.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_STHGossipEvidence proto.InternalMessageInfo
+
+func (m *STHGossipEvidence) GetLogId() []byte {
+	if m != nil {
+		return m.LogId
+	}
+	return nil
+}
+
+func (m *STHGossipEvidence) GetLogKey() []byte {
+	if m != nil {
+		return m.LogKey
+	}
+	return nil
+}
+
+func (m *STHGossipEvidence) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *STHGossipEvidence) GetObserved() int64 {
+	if m != nil {
+		return m.Observed
+	}
+	return 0
+}
+
+func (m *STHGossipEvidence) GetSths() []*SignedTreeHead {
+	if m != nil {
+		return m.Sths
+	}
+	return nil
+}
+
+// STHGossipEvidenceList is stored per log, for each log that evidence has
+// been found against, and lists that evidence.
+type STHGossipEvidenceList struct {
+	Evidence             []*STHGossipEvidence `protobuf:"bytes,1,rep,name=evidence,proto3" json:"evidence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *STHGossipEvidenceList) Reset()         { *m = STHGossipEvidenceList{} }
+func (m *STHGossipEvidenceList) String() string { return proto.CompactTextString(m) }
+func (*STHGossipEvidenceList) ProtoMessage()    {}
+func (*STHGossipEvidenceList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{5}
+}
+
+func (m *STHGossipEvidenceList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_STHGossipEvidenceList.Unmarshal(m, b)
+}
+func (m *STHGossipEvidenceList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_STHGossipEvidenceList.Marshal(b, m, deterministic)
+}
+func (m *STHGossipEvidenceList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_STHGossipEvidenceList.Merge(m, src)
+}
+func (m *STHGossipEvidenceList) XXX_Size() int {
+	return xxx_messageInfo_STHGossipEvidenceList.Size(m)
+}
+func (m *STHGossipEvidenceList) XXX_DiscardUnknown() {
+	xxx_messageInfo_STHGossipEvidenceList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_STHGossipEvidenceList proto.InternalMessageInfo
+
+func (m *STHGossipEvidenceList) GetEvidence() []*STHGossipEvidence {
+	if m != nil {
+		return m.Evidence
+	}
+	return nil
+}
+
+// STHGossipEvidenceLogs is stored per log and lists the IDs of the logs that
+// evidence has been found against.
+type STHGossipEvidenceLogs struct {
+	LogIds               [][]byte `protobuf:"bytes,1,rep,name=log_ids,json=logIds,proto3" json:"log_ids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *STHGossipEvidenceLogs) Reset()         { *m = STHGossipEvidenceLogs{} }
+func (m *STHGossipEvidenceLogs) String() string { return proto.CompactTextString(m) }
+func (*STHGossipEvidenceLogs) ProtoMessage()    {}
+func (*STHGossipEvidenceLogs) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{6}
+}
+
+func (m *STHGossipEvidenceLogs) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_STHGossipEvidenceLogs.Unmarshal(m, b)
+}
+func (m *STHGossipEvidenceLogs) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_STHGossipEvidenceLogs.Marshal(b, m, deterministic)
+}
+func (m *STHGossipEvidenceLogs) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_STHGossipEvidenceLogs.Merge(m, src)
+}
+func (m *STHGossipEvidenceLogs) XXX_Size() int {
+	return xxx_messageInfo_STHGossipEvidenceLogs.Size(m)
+}
+func (m *STHGossipEvidenceLogs) XXX_DiscardUnknown() {
+	xxx_messageInfo_STHGossipEvidenceLogs.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_STHGossipEvidenceLogs proto.InternalMessageInfo
+
+func (m *STHGossipEvidenceLogs) GetLogIds() [][]byte {
+	if m != nil {
+		return m.LogIds
+	}
+	return nil
+}
+
+// STHIndexEntry records that an STH was signed for a tree size.
+type STHIndexEntry struct {
+	TreeSize             int64    `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Timestamp            int64    `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *STHIndexEntry) Reset()         { *m = STHIndexEntry{} }
+func (m *STHIndexEntry) String() string { return proto.CompactTextString(m) }
+func (*STHIndexEntry) ProtoMessage()    {}
+func (*STHIndexEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{7}
+}
+
+func (m *STHIndexEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_STHIndexEntry.Unmarshal(m, b)
+}
+func (m *STHIndexEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_STHIndexEntry.Marshal(b, m, deterministic)
+}
+func (m *STHIndexEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_STHIndexEntry.Merge(m, src)
+}
+func (m *STHIndexEntry) XXX_Size() int {
+	return xxx_messageInfo_STHIndexEntry.Size(m)
+}
+func (m *STHIndexEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_STHIndexEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_STHIndexEntry proto.InternalMessageInfo
+
+func (m *STHIndexEntry) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *STHIndexEntry) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+// STHIndexChunk is a fixed size part of the list of all STHs signed, in the order they were signed.
+type STHIndexChunk struct {
+	Entries              []*STHIndexEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *STHIndexChunk) Reset()         { *m = STHIndexChunk{} }
+func (m *STHIndexChunk) String() string { return proto.CompactTextString(m) }
+func (*STHIndexChunk) ProtoMessage()    {}
+func (*STHIndexChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{8}
+}
+
+func (m *STHIndexChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_STHIndexChunk.Unmarshal(m, b)
+}
+func (m *STHIndexChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_STHIndexChunk.Marshal(b, m, deterministic)
+}
+func (m *STHIndexChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_STHIndexChunk.Merge(m, src)
+}
+func (m *STHIndexChunk) XXX_Size() int {
+	return xxx_messageInfo_STHIndexChunk.Size(m)
+}
+func (m *STHIndexChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_STHIndexChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_STHIndexChunk proto.InternalMessageInfo
+
+func (m *STHIndexChunk) GetEntries() []*STHIndexEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+// STHIndexMetadata is stored once the index of STHs has been built, and counts the entries.
+type STHIndexMetadata struct {
+	Count int64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	// For each chunk, the least and greatest timestamps and the greatest tree size,
+	// so that the tree size at a time can be found without reading every chunk.
+	ChunkMinTimestamp    []int64  `protobuf:"varint,2,rep,packed,name=chunk_min_timestamp,json=chunkMinTimestamp,proto3" json:"chunk_min_timestamp,omitempty"`
+	ChunkMaxTimestamp    []int64  `protobuf:"varint,3,rep,packed,name=chunk_max_timestamp,json=chunkMaxTimestamp,proto3" json:"chunk_max_timestamp,omitempty"`
+	ChunkMaxTreeSize     []int64  `protobuf:"varint,4,rep,packed,name=chunk_max_tree_size,json=chunkMaxTreeSize,proto3" json:"chunk_max_tree_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *STHIndexMetadata) Reset()         { *m = STHIndexMetadata{} }
+func (m *STHIndexMetadata) String() string { return proto.CompactTextString(m) }
+func (*STHIndexMetadata) ProtoMessage()    {}
+func (*STHIndexMetadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{9}
+}
+
+func (m *STHIndexMetadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_STHIndexMetadata.Unmarshal(m, b)
+}
+func (m *STHIndexMetadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_STHIndexMetadata.Marshal(b, m, deterministic)
+}
+func (m *STHIndexMetadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_STHIndexMetadata.Merge(m, src)
+}
+func (m *STHIndexMetadata) XXX_Size() int {
+	return xxx_messageInfo_STHIndexMetadata.Size(m)
+}
+func (m *STHIndexMetadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_STHIndexMetadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_STHIndexMetadata proto.InternalMessageInfo
+
+func (m *STHIndexMetadata) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *STHIndexMetadata) GetChunkMinTimestamp() []int64 {
+	if m != nil {
+		return m.ChunkMinTimestamp
+	}
+	return nil
+}
+
+func (m *STHIndexMetadata) GetChunkMaxTimestamp() []int64 {
+	if m != nil {
+		return m.ChunkMaxTimestamp
+	}
+	return nil
+}
+
+func (m *STHIndexMetadata) GetChunkMaxTreeSize() []int64 {
+	if m != nil {
+		return m.ChunkMaxTreeSize
+	}
+	return nil
+}
+
+// STHIndexBuild is stored while the index of STHs is being built, for logs that
+// existed before it did. Entries found so far are stored in chunks as for the index.
+type STHIndexBuild struct {
+	// Tree sizes below this have been looked at for STHs
+	ScannedTreeSize int64 `protobuf:"varint,1,opt,name=scanned_tree_size,json=scannedTreeSize,proto3" json:"scanned_tree_size,omitempty"`
+	// Tree size of the log when the build started. STHs for other sizes are
+	// added to the build as they are signed.
+	HeadTreeSize         int64    `protobuf:"varint,2,opt,name=head_tree_size,json=headTreeSize,proto3" json:"head_tree_size,omitempty"`
+	Count                int64    `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *STHIndexBuild) Reset()         { *m = STHIndexBuild{} }
+func (m *STHIndexBuild) String() string { return proto.CompactTextString(m) }
+func (*STHIndexBuild) ProtoMessage()    {}
+func (*STHIndexBuild) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{10}
+}
+
+func (m *STHIndexBuild) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_STHIndexBuild.Unmarshal(m, b)
+}
+func (m *STHIndexBuild) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_STHIndexBuild.Marshal(b, m, deterministic)
+}
+func (m *STHIndexBuild) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_STHIndexBuild.Merge(m, src)
+}
+func (m *STHIndexBuild) XXX_Size() int {
+	return xxx_messageInfo_STHIndexBuild.Size(m)
+}
+func (m *STHIndexBuild) XXX_DiscardUnknown() {
+	xxx_messageInfo_STHIndexBuild.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_STHIndexBuild proto.InternalMessageInfo
+
+func (m *STHIndexBuild) GetScannedTreeSize() int64 {
+	if m != nil {
+		return m.ScannedTreeSize
+	}
+	return 0
+}
+
+func (m *STHIndexBuild) GetHeadTreeSize() int64 {
+	if m != nil {
+		return m.HeadTreeSize
+	}
+	return 0
+}
+
+func (m *STHIndexBuild) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+// LogDirectoryEntry describes a log hosted by this server.
+type LogDirectoryEntry struct {
+	Account string `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Name    string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// ASN.1 DER encoded ECDSA public key
+	PublicKeyDer []byte `protobuf:"bytes,5,opt,name=public_key_der,json=publicKeyDer,proto3" json:"public_key_der,omitempty"`
+	// Set if the log has webhook subscribers, so that we know to send them notifications
+	Webhooks bool `protobuf:"varint,6,opt,name=webhooks,proto3" json:"webhooks,omitempty"`
+	// Set if the log has fields to index, so that we know to index new entries
+	Indexed bool `protobuf:"varint,7,opt,name=indexed,proto3" json:"indexed,omitempty"`
+	// Set if the log has a verifiable map, so that we know to map new entries
+	Mapped               bool     `protobuf:"varint,8,opt,name=mapped,proto3" json:"mapped,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LogDirectoryEntry) Reset()         { *m = LogDirectoryEntry{} }
+func (m *LogDirectoryEntry) String() string { return proto.CompactTextString(m) }
+func (*LogDirectoryEntry) ProtoMessage()    {}
+func (*LogDirectoryEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{11}
+}
+
+func (m *LogDirectoryEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogDirectoryEntry.Unmarshal(m, b)
+}
+func (m *LogDirectoryEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogDirectoryEntry.Marshal(b, m, deterministic)
+}
+func (m *LogDirectoryEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogDirectoryEntry.Merge(m, src)
+}
+func (m *LogDirectoryEntry) XXX_Size() int {
+	return xxx_messageInfo_LogDirectoryEntry.Size(m)
+}
+func (m *LogDirectoryEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogDirectoryEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LogDirectoryEntry proto.InternalMessageInfo
+
+func (m *LogDirectoryEntry) GetAccount() string {
+	if m != nil {
+		return m.Account
+	}
+	return ""
+}
+
+func (m *LogDirectoryEntry) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *LogDirectoryEntry) GetPublicKeyDer() []byte {
+	if m != nil {
+		return m.PublicKeyDer
+	}
+	return nil
+}
+
+func (m *LogDirectoryEntry) GetWebhooks() bool {
+	if m != nil {
+		return m.Webhooks
+	}
+	return false
+}
+
+func (m *LogDirectoryEntry) GetIndexed() bool {
+	if m != nil {
+		return m.Indexed
+	}
+	return false
+}
+
+func (m *LogDirectoryEntry) GetMapped() bool {
+	if m != nil {
+		return m.Mapped
+	}
+	return false
+}
+
+// LogDirectory is stored once in the metadata namespace, and lists all logs.
+type LogDirectory struct {
+	Logs                 []*LogDirectoryEntry `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *LogDirectory) Reset()         { *m = LogDirectory{} }
+func (m *LogDirectory) String() string { return proto.CompactTextString(m) }
+func (*LogDirectory) ProtoMessage()    {}
+func (*LogDirectory) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{12}
+}
+
+func (m *LogDirectory) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogDirectory.Unmarshal(m, b)
+}
+func (m *LogDirectory) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogDirectory.Marshal(b, m, deterministic)
+}
+func (m *LogDirectory) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogDirectory.Merge(m, src)
+}
+func (m *LogDirectory) XXX_Size() int {
+	return xxx_messageInfo_LogDirectory.Size(m)
+}
+func (m *LogDirectory) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogDirectory.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LogDirectory proto.InternalMessageInfo
+
+func (m *LogDirectory) GetLogs() []*LogDirectoryEntry {
+	if m != nil {
+		return m.Logs
+	}
+	return nil
+}
+
+// WebhookSubscriber is notified when entries are added to a log.
+type WebhookSubscriber struct {
+	Id  string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	// Key for the HMAC-SHA256 of each payload we send
+	Secret []byte `protobuf:"bytes,3,opt,name=secret,proto3" json:"secret,omitempty"`
+	// Timestamp (milliseconds since epoch) at which it was added
+	Created              int64    `protobuf:"varint,4,opt,name=created,proto3" json:"created,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WebhookSubscriber) Reset()         { *m = WebhookSubscriber{} }
+func (m *WebhookSubscriber) String() string { return proto.CompactTextString(m) }
+func (*WebhookSubscriber) ProtoMessage()    {}
+func (*WebhookSubscriber) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{13}
+}
+
+func (m *WebhookSubscriber) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WebhookSubscriber.Unmarshal(m, b)
+}
+func (m *WebhookSubscriber) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WebhookSubscriber.Marshal(b, m, deterministic)
+}
+func (m *WebhookSubscriber) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WebhookSubscriber.Merge(m, src)
+}
+func (m *WebhookSubscriber) XXX_Size() int {
+	return xxx_messageInfo_WebhookSubscriber.Size(m)
+}
+func (m *WebhookSubscriber) XXX_DiscardUnknown() {
+	xxx_messageInfo_WebhookSubscriber.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WebhookSubscriber proto.InternalMessageInfo
+
+func (m *WebhookSubscriber) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *WebhookSubscriber) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *WebhookSubscriber) GetSecret() []byte {
+	if m != nil {
+		return m.Secret
+	}
+	return nil
+}
+
+func (m *WebhookSubscriber) GetCreated() int64 {
+	if m != nil {
+		return m.Created
+	}
+	return 0
+}
+
+// WebhookDelivery is a notification for a subscriber, and the outcome of sending it.
+type WebhookDelivery struct {
+	Id           int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	SubscriberId string `protobuf:"bytes,2,opt,name=subscriber_id,json=subscriberId,proto3" json:"subscriber_id,omitempty"`
+	// JSON payload, which is the same for each attempt
+	Payload []byte `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Timestamp (milliseconds since epoch) at which it was queued
+	Created  int64 `protobuf:"varint,4,opt,name=created,proto3" json:"created,omitempty"`
+	Attempts int32 `protobuf:"varint,5,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	// Timestamp (milliseconds since epoch) before which it is not to be attempted, as it is
+	// waiting to be retried, or being sent by a server
+	NextAttempt int64 `protobuf:"varint,6,opt,name=next_attempt,json=nextAttempt,proto3" json:"next_attempt,omitempty"`
+	// HTTP status code of the last attempt, or 0 if there was no response
+	LastStatus int32  `protobuf:"varint,7,opt,name=last_status,json=lastStatus,proto3" json:"last_status,omitempty"`
+	LastError  string `protobuf:"bytes,8,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	// Timestamp (milliseconds since epoch) at which it was delivered, or we gave up
+	Completed            int64    `protobuf:"varint,9,opt,name=completed,proto3" json:"completed,omitempty"`
+	Delivered            bool     `protobuf:"varint,10,opt,name=delivered,proto3" json:"delivered,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WebhookDelivery) Reset()         { *m = WebhookDelivery{} }
+func (m *WebhookDelivery) String() string { return proto.CompactTextString(m) }
+func (*WebhookDelivery) ProtoMessage()    {}
+func (*WebhookDelivery) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{14}
+}
+
+func (m *WebhookDelivery) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WebhookDelivery.Unmarshal(m, b)
+}
+func (m *WebhookDelivery) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WebhookDelivery.Marshal(b, m, deterministic)
+}
+func (m *WebhookDelivery) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WebhookDelivery.Merge(m, src)
+}
+func (m *WebhookDelivery) XXX_Size() int {
+	return xxx_messageInfo_WebhookDelivery.Size(m)
+}
+func (m *WebhookDelivery) XXX_DiscardUnknown() {
+	xxx_messageInfo_WebhookDelivery.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WebhookDelivery proto.InternalMessageInfo
+
+func (m *WebhookDelivery) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *WebhookDelivery) GetSubscriberId() string {
+	if m != nil {
+		return m.SubscriberId
+	}
+	return ""
+}
+
+func (m *WebhookDelivery) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (m *WebhookDelivery) GetCreated() int64 {
+	if m != nil {
+		return m.Created
+	}
+	return 0
+}
+
+func (m *WebhookDelivery) GetAttempts() int32 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *WebhookDelivery) GetNextAttempt() int64 {
+	if m != nil {
+		return m.NextAttempt
+	}
+	return 0
+}
+
+func (m *WebhookDelivery) GetLastStatus() int32 {
+	if m != nil {
+		return m.LastStatus
+	}
+	return 0
+}
+
+func (m *WebhookDelivery) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
+func (m *WebhookDelivery) GetCompleted() int64 {
+	if m != nil {
+		return m.Completed
+	}
+	return 0
+}
+
+func (m *WebhookDelivery) GetDelivered() bool {
+	if m != nil {
+		return m.Delivered
+	}
+	return false
+}
+
+// WebhookState is stored per log, with its subscribers and the queue of deliveries to them. Each
+// delivery is stored under its own key, so that sending one does not rewrite the others.
+type WebhookState struct {
+	Subscribers []*WebhookSubscriber `protobuf:"bytes,1,rep,name=subscribers,proto3" json:"subscribers,omitempty"`
+	// Deliveries have been queued for entries before this tree size
+	QueuedTreeSize int64 `protobuf:"varint,2,opt,name=queued_tree_size,json=queuedTreeSize,proto3" json:"queued_tree_size,omitempty"`
+	NextDeliveryId int64 `protobuf:"varint,3,opt,name=next_delivery_id,json=nextDeliveryId,proto3" json:"next_delivery_id,omitempty"`
+	// IDs of deliveries not yet completed, in the order they were queued
+	PendingIds []int64 `protobuf:"varint,4,rep,packed,name=pending_ids,json=pendingIds,proto3" json:"pending_ids,omitempty"`
+	// IDs of the most recently completed deliveries, oldest first
+	CompletedIds         []int64  `protobuf:"varint,5,rep,packed,name=completed_ids,json=completedIds,proto3" json:"completed_ids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WebhookState) Reset()         { *m = WebhookState{} }
+func (m *WebhookState) String() string { return proto.CompactTextString(m) }
+func (*WebhookState) ProtoMessage()    {}
+func (*WebhookState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{15}
+}
+
+func (m *WebhookState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WebhookState.Unmarshal(m, b)
+}
+func (m *WebhookState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WebhookState.Marshal(b, m, deterministic)
+}
+func (m *WebhookState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WebhookState.Merge(m, src)
+}
+func (m *WebhookState) XXX_Size() int {
+	return xxx_messageInfo_WebhookState.Size(m)
+}
+func (m *WebhookState) XXX_DiscardUnknown() {
+	xxx_messageInfo_WebhookState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WebhookState proto.InternalMessageInfo
+
+func (m *WebhookState) GetSubscribers() []*WebhookSubscriber {
+	if m != nil {
+		return m.Subscribers
+	}
+	return nil
+}
+
+func (m *WebhookState) GetQueuedTreeSize() int64 {
+	if m != nil {
+		return m.QueuedTreeSize
+	}
+	return 0
+}
+
+func (m *WebhookState) GetNextDeliveryId() int64 {
+	if m != nil {
+		return m.NextDeliveryId
+	}
+	return 0
+}
+
+func (m *WebhookState) GetPendingIds() []int64 {
+	if m != nil {
+		return m.PendingIds
+	}
+	return nil
+}
+
+func (m *WebhookState) GetCompletedIds() []int64 {
+	if m != nil {
+		return m.CompletedIds
+	}
+	return nil
+}
+
+// FieldIndex is the progress of indexing a field of a log's entries.
+type FieldIndex struct {
+	Field string `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	// Keys for the index include the generation, so that a field that is no longer
+	// indexed and is then indexed again starts afresh
+	Generation int64 `protobuf:"varint,2,opt,name=generation,proto3" json:"generation,omitempty"`
+	// Entries before this tree size have been indexed
+	IndexedTreeSize      int64    `protobuf:"varint,3,opt,name=indexed_tree_size,json=indexedTreeSize,proto3" json:"indexed_tree_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FieldIndex) Reset()         { *m = FieldIndex{} }
+func (m *FieldIndex) String() string { return proto.CompactTextString(m) }
+func (*FieldIndex) ProtoMessage()    {}
+func (*FieldIndex) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{16}
+}
+
+func (m *FieldIndex) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FieldIndex.Unmarshal(m, b)
+}
+func (m *FieldIndex) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FieldIndex.Marshal(b, m, deterministic)
+}
+func (m *FieldIndex) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FieldIndex.Merge(m, src)
+}
+func (m *FieldIndex) XXX_Size() int {
+	return xxx_messageInfo_FieldIndex.Size(m)
+}
+func (m *FieldIndex) XXX_DiscardUnknown() {
+	xxx_messageInfo_FieldIndex.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FieldIndex proto.InternalMessageInfo
+
+func (m *FieldIndex) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *FieldIndex) GetGeneration() int64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+func (m *FieldIndex) GetIndexedTreeSize() int64 {
+	if m != nil {
+		return m.IndexedTreeSize
+	}
+	return 0
+}
+
+// FieldIndexState is stored per log, with the fields being indexed.
+type FieldIndexState struct {
+	Indexes              []*FieldIndex `protobuf:"bytes,1,rep,name=indexes,proto3" json:"indexes,omitempty"`
+	NextGeneration       int64         `protobuf:"varint,2,opt,name=next_generation,json=nextGeneration,proto3" json:"next_generation,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *FieldIndexState) Reset()         { *m = FieldIndexState{} }
+func (m *FieldIndexState) String() string { return proto.CompactTextString(m) }
+func (*FieldIndexState) ProtoMessage()    {}
+func (*FieldIndexState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{17}
+}
+
+func (m *FieldIndexState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FieldIndexState.Unmarshal(m, b)
+}
+func (m *FieldIndexState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FieldIndexState.Marshal(b, m, deterministic)
+}
+func (m *FieldIndexState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FieldIndexState.Merge(m, src)
+}
+func (m *FieldIndexState) XXX_Size() int {
+	return xxx_messageInfo_FieldIndexState.Size(m)
+}
+func (m *FieldIndexState) XXX_DiscardUnknown() {
+	xxx_messageInfo_FieldIndexState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FieldIndexState proto.InternalMessageInfo
+
+func (m *FieldIndexState) GetIndexes() []*FieldIndex {
+	if m != nil {
+		return m.Indexes
+	}
+	return nil
+}
+
+func (m *FieldIndexState) GetNextGeneration() int64 {
+	if m != nil {
+		return m.NextGeneration
+	}
+	return 0
+}
+
+// FieldIndexValue is stored per indexed field and value, and counts the entries with that value.
+type FieldIndexValue struct {
+	Count                int64    `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FieldIndexValue) Reset()         { *m = FieldIndexValue{} }
+func (m *FieldIndexValue) String() string { return proto.CompactTextString(m) }
+func (*FieldIndexValue) ProtoMessage()    {}
+func (*FieldIndexValue) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{18}
+}
+
+func (m *FieldIndexValue) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FieldIndexValue.Unmarshal(m, b)
+}
+func (m *FieldIndexValue) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FieldIndexValue.Marshal(b, m, deterministic)
+}
+func (m *FieldIndexValue) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FieldIndexValue.Merge(m, src)
+}
+func (m *FieldIndexValue) XXX_Size() int {
+	return xxx_messageInfo_FieldIndexValue.Size(m)
+}
+func (m *FieldIndexValue) XXX_DiscardUnknown() {
+	xxx_messageInfo_FieldIndexValue.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FieldIndexValue proto.InternalMessageInfo
+
+func (m *FieldIndexValue) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+// FieldIndexChunk is a fixed size part of the list of entries with a value, in index order.
+type FieldIndexChunk struct {
+	Indices              []int64  `protobuf:"varint,1,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FieldIndexChunk) Reset()         { *m = FieldIndexChunk{} }
+func (m *FieldIndexChunk) String() string { return proto.CompactTextString(m) }
+func (*FieldIndexChunk) ProtoMessage()    {}
+func (*FieldIndexChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{19}
+}
+
+func (m *FieldIndexChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FieldIndexChunk.Unmarshal(m, b)
+}
+func (m *FieldIndexChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FieldIndexChunk.Marshal(b, m, deterministic)
+}
+func (m *FieldIndexChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FieldIndexChunk.Merge(m, src)
+}
+func (m *FieldIndexChunk) XXX_Size() int {
+	return xxx_messageInfo_FieldIndexChunk.Size(m)
+}
+func (m *FieldIndexChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_FieldIndexChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FieldIndexChunk proto.InternalMessageInfo
+
+func (m *FieldIndexChunk) GetIndices() []int64 {
+	if m != nil {
+		return m.Indices
+	}
+	return nil
+}
+
+// SignedMapHead is signed by a log's key, for the state of its verifiable map once entries before tree_size are mapped.
+type SignedMapHead struct {
+	TreeSize             int64    `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	LogRootHash          []byte   `protobuf:"bytes,2,opt,name=log_root_hash,json=logRootHash,proto3" json:"log_root_hash,omitempty"`
+	KeyField             string   `protobuf:"bytes,3,opt,name=key_field,json=keyField,proto3" json:"key_field,omitempty"`
+	MapSize              int64    `protobuf:"varint,4,opt,name=map_size,json=mapSize,proto3" json:"map_size,omitempty"`
+	MapRootHash          []byte   `protobuf:"bytes,5,opt,name=map_root_hash,json=mapRootHash,proto3" json:"map_root_hash,omitempty"`
+	Timestamp            int64    `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Signature            []byte   `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SignedMapHead) Reset()         { *m = SignedMapHead{} }
+func (m *SignedMapHead) String() string { return proto.CompactTextString(m) }
+func (*SignedMapHead) ProtoMessage()    {}
+func (*SignedMapHead) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{20}
+}
+
+func (m *SignedMapHead) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignedMapHead.Unmarshal(m, b)
+}
+func (m *SignedMapHead) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignedMapHead.Marshal(b, m, deterministic)
+}
+func (m *SignedMapHead) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignedMapHead.Merge(m, src)
+}
+func (m *SignedMapHead) XXX_Size() int {
+	return xxx_messageInfo_SignedMapHead.Size(m)
+}
+func (m *SignedMapHead) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignedMapHead.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SignedMapHead proto.InternalMessageInfo
+
+func (m *SignedMapHead) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *SignedMapHead) GetLogRootHash() []byte {
+	if m != nil {
+		return m.LogRootHash
+	}
+	return nil
+}
+
+func (m *SignedMapHead) GetKeyField() string {
+	if m != nil {
+		return m.KeyField
+	}
+	return ""
+}
+
+func (m *SignedMapHead) GetMapSize() int64 {
+	if m != nil {
+		return m.MapSize
+	}
+	return 0
+}
+
+func (m *SignedMapHead) GetMapRootHash() []byte {
+	if m != nil {
+		return m.MapRootHash
+	}
+	return nil
+}
+
+func (m *SignedMapHead) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *SignedMapHead) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// MapState is stored per log, with the progress of mapping entries into its verifiable map.
+type MapState struct {
+	KeyField string `protobuf:"bytes,1,opt,name=key_field,json=keyField,proto3" json:"key_field,omitempty"`
+	// Entries before this tree size have been mapped
+	MappedTreeSize int64 `protobuf:"varint,2,opt,name=mapped_tree_size,json=mappedTreeSize,proto3" json:"mapped_tree_size,omitempty"`
+	// A server mapping entries reserves the next batch until this time, in milliseconds since the epoch
+	LeaseUntil           int64          `protobuf:"varint,3,opt,name=lease_until,json=leaseUntil,proto3" json:"lease_until,omitempty"`
+	Latest               *SignedMapHead `protobuf:"bytes,4,opt,name=latest,proto3" json:"latest,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *MapState) Reset()         { *m = MapState{} }
+func (m *MapState) String() string { return proto.CompactTextString(m) }
+func (*MapState) ProtoMessage()    {}
+func (*MapState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{21}
+}
+
+func (m *MapState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MapState.Unmarshal(m, b)
+}
+func (m *MapState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MapState.Marshal(b, m, deterministic)
+}
+func (m *MapState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MapState.Merge(m, src)
+}
+func (m *MapState) XXX_Size() int {
+	return xxx_messageInfo_MapState.Size(m)
+}
+func (m *MapState) XXX_DiscardUnknown() {
+	xxx_messageInfo_MapState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MapState proto.InternalMessageInfo
+
+func (m *MapState) GetKeyField() string {
+	if m != nil {
+		return m.KeyField
+	}
+	return ""
+}
+
+func (m *MapState) GetMappedTreeSize() int64 {
+	if m != nil {
+		return m.MappedTreeSize
+	}
+	return 0
+}
+
+func (m *MapState) GetLeaseUntil() int64 {
+	if m != nil {
+		return m.LeaseUntil
+	}
+	return 0
+}
+
+func (m *MapState) GetLatest() *SignedMapHead {
+	if m != nil {
+		return m.Latest
+	}
+	return nil
+}
+
+// EntryTimeIndexChunk is a fixed size part of the list of the timestamps of a log's entries, in index order.
+type EntryTimeIndexChunk struct {
+	Timestamps           []int64  `protobuf:"varint,1,rep,packed,name=timestamps,proto3" json:"timestamps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EntryTimeIndexChunk) Reset()         { *m = EntryTimeIndexChunk{} }
+func (m *EntryTimeIndexChunk) String() string { return proto.CompactTextString(m) }
+func (*EntryTimeIndexChunk) ProtoMessage()    {}
+func (*EntryTimeIndexChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{22}
+}
+
+func (m *EntryTimeIndexChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EntryTimeIndexChunk.Unmarshal(m, b)
+}
+func (m *EntryTimeIndexChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EntryTimeIndexChunk.Marshal(b, m, deterministic)
+}
+func (m *EntryTimeIndexChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EntryTimeIndexChunk.Merge(m, src)
+}
+func (m *EntryTimeIndexChunk) XXX_Size() int {
+	return xxx_messageInfo_EntryTimeIndexChunk.Size(m)
+}
+func (m *EntryTimeIndexChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_EntryTimeIndexChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EntryTimeIndexChunk proto.InternalMessageInfo
+
+func (m *EntryTimeIndexChunk) GetTimestamps() []int64 {
+	if m != nil {
+		return m.Timestamps
+	}
+	return nil
+}
+
+// EntryTimeIndexMetadata counts the entries in the time index, with the least and greatest timestamp in each
+// chunk, as entries are not necessarily in timestamp order.
+type EntryTimeIndexMetadata struct {
+	Count                int64    `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	ChunkMin             []int64  `protobuf:"varint,2,rep,packed,name=chunk_min,json=chunkMin,proto3" json:"chunk_min,omitempty"`
+	ChunkMax             []int64  `protobuf:"varint,3,rep,packed,name=chunk_max,json=chunkMax,proto3" json:"chunk_max,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EntryTimeIndexMetadata) Reset()         { *m = EntryTimeIndexMetadata{} }
+func (m *EntryTimeIndexMetadata) String() string { return proto.CompactTextString(m) }
+func (*EntryTimeIndexMetadata) ProtoMessage()    {}
+func (*EntryTimeIndexMetadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_56d9f74966f40d04, []int{23}
+}
+
+func (m *EntryTimeIndexMetadata) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EntryTimeIndexMetadata.Unmarshal(m, b)
+}
+func (m *EntryTimeIndexMetadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EntryTimeIndexMetadata.Marshal(b, m, deterministic)
+}
+func (m *EntryTimeIndexMetadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EntryTimeIndexMetadata.Merge(m, src)
+}
+func (m *EntryTimeIndexMetadata) XXX_Size() int {
+	return xxx_messageInfo_EntryTimeIndexMetadata.Size(m)
+}
+func (m *EntryTimeIndexMetadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_EntryTimeIndexMetadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EntryTimeIndexMetadata proto.InternalMessageInfo
+
+func (m *EntryTimeIndexMetadata) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *EntryTimeIndexMetadata) GetChunkMin() []int64 {
+	if m != nil {
+		return m.ChunkMin
+	}
+	return nil
+}
+
+func (m *EntryTimeIndexMetadata) GetChunkMax() []int64 {
+	if m != nil {
+		return m.ChunkMax
+	}
+	return nil
+}
+
 func init() {
+	proto.RegisterEnum("au.gov.digital.verifiabledatastructures.LogState", LogState_name, LogState_value)
 	proto.RegisterType((*LogMetadata)(nil), "au.gov.digital.verifiabledatastructures.LogMetadata")
 	proto.RegisterType((*SignedTreeHead)(nil), "au.gov.digital.verifiabledatastructures.SignedTreeHead")
 	proto.RegisterType((*AddResponse)(nil), "au.gov.digital.verifiabledatastructures.AddResponse")
+	proto.RegisterType((*STHGossipRecord)(nil), "au.gov.digital.verifiabledatastructures.STHGossipRecord")
+	proto.RegisterType((*STHGossipEvidence)(nil), "au.gov.digital.verifiabledatastructures.STHGossipEvidence")
+	proto.RegisterType((*STHGossipEvidenceList)(nil), "au.gov.digital.verifiabledatastructures.STHGossipEvidenceList")
+	proto.RegisterType((*STHGossipEvidenceLogs)(nil), "au.gov.digital.verifiabledatastructures.STHGossipEvidenceLogs")
+	proto.RegisterType((*STHIndexEntry)(nil), "au.gov.digital.verifiabledatastructures.STHIndexEntry")
+	proto.RegisterType((*STHIndexChunk)(nil), "au.gov.digital.verifiabledatastructures.STHIndexChunk")
+	proto.RegisterType((*STHIndexMetadata)(nil), "au.gov.digital.verifiabledatastructures.STHIndexMetadata")
+	proto.RegisterType((*STHIndexBuild)(nil), "au.gov.digital.verifiabledatastructures.STHIndexBuild")
+	proto.RegisterType((*LogDirectoryEntry)(nil), "au.gov.digital.verifiabledatastructures.LogDirectoryEntry")
+	proto.RegisterType((*LogDirectory)(nil), "au.gov.digital.verifiabledatastructures.LogDirectory")
+	proto.RegisterType((*WebhookSubscriber)(nil), "au.gov.digital.verifiabledatastructures.WebhookSubscriber")
+	proto.RegisterType((*WebhookDelivery)(nil), "au.gov.digital.verifiabledatastructures.WebhookDelivery")
+	proto.RegisterType((*WebhookState)(nil), "au.gov.digital.verifiabledatastructures.WebhookState")
+	proto.RegisterType((*FieldIndex)(nil), "au.gov.digital.verifiabledatastructures.FieldIndex")
+	proto.RegisterType((*FieldIndexState)(nil), "au.gov.digital.verifiabledatastructures.FieldIndexState")
+	proto.RegisterType((*FieldIndexValue)(nil), "au.gov.digital.verifiabledatastructures.FieldIndexValue")
+	proto.RegisterType((*FieldIndexChunk)(nil), "au.gov.digital.verifiabledatastructures.FieldIndexChunk")
+	proto.RegisterType((*SignedMapHead)(nil), "au.gov.digital.verifiabledatastructures.SignedMapHead")
+	proto.RegisterType((*MapState)(nil), "au.gov.digital.verifiabledatastructures.MapState")
+	proto.RegisterType((*EntryTimeIndexChunk)(nil), "au.gov.digital.verifiabledatastructures.EntryTimeIndexChunk")
+	proto.RegisterType((*EntryTimeIndexMetadata)(nil), "au.gov.digital.verifiabledatastructures.EntryTimeIndexMetadata")
 }
 
 func init() { proto.RegisterFile("metadata.proto", fileDescriptor_56d9f74966f40d04) }
 
 var fileDescriptor_56d9f74966f40d04 = []byte{
-	// 275 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x90, 0x3f, 0x4f, 0xf3, 0x30,
-	0x10, 0xc6, 0x95, 0xa6, 0x7a, 0xf5, 0xc6, 0x85, 0x00, 0x66, 0x89, 0x44, 0x87, 0x2a, 0x03, 0x64,
-	0xca, 0x00, 0x2a, 0x3b, 0x88, 0xa1, 0x08, 0x58, 0x12, 0x26, 0x16, 0xeb, 0x82, 0x8f, 0xc4, 0xa2,
-	0x89, 0x23, 0xfb, 0x12, 0xa9, 0xfd, 0x3e, 0x7c, 0x4f, 0x14, 0x37, 0x28, 0x52, 0xd7, 0x9f, 0x1f,
-	0x3f, 0x7f, 0x8e, 0x85, 0x35, 0x12, 0x48, 0x20, 0x48, 0x5b, 0xa3, 0x49, 0xf3, 0x1b, 0xe8, 0xd2,
-	0x52, 0xf7, 0xa9, 0x54, 0xa5, 0x22, 0xd8, 0xa6, 0x3d, 0x1a, 0xf5, 0xa5, 0xa0, 0xd8, 0xe2, 0x20,
-	0xb2, 0x64, 0xba, 0x4f, 0xea, 0x0c, 0xda, 0x78, 0xcd, 0x16, 0xaf, 0xba, 0x7c, 0x1b, 0x7f, 0xf3,
-	0x6b, 0x76, 0xd6, 0x1a, 0xd5, 0x03, 0xa1, 0xf8, 0xc6, 0x9d, 0x90, 0x68, 0xa2, 0xd9, 0xca, 0x4b,
-	0x4e, 0xb2, 0xd3, 0x11, 0xbf, 0xe0, 0xee, 0x09, 0x4d, 0xfc, 0xe3, 0xb1, 0x30, 0x57, 0x65, 0x83,
-	0xf2, 0xdd, 0x20, 0x6e, 0x10, 0x24, 0xbf, 0x62, 0x01, 0x19, 0x44, 0x61, 0xd5, 0x1e, 0x23, 0x6f,
-	0xe5, 0x25, 0x7e, 0xf6, 0x7f, 0x00, 0xb9, 0xda, 0x23, 0x5f, 0xb2, 0x80, 0x54, 0x8d, 0x96, 0xa0,
-	0x6e, 0x9d, 0xa3, 0x9f, 0x4d, 0x80, 0x27, 0xec, 0xdc, 0x56, 0x70, 0xbb, 0xbe, 0x17, 0x46, 0x6b,
-	0x12, 0x15, 0xd8, 0x2a, 0xf2, 0x5d, 0x6c, 0x78, 0xe0, 0x99, 0xd6, 0xb4, 0x01, 0x5b, 0xf1, 0x94,
-	0x5d, 0xba, 0x90, 0x0a, 0x41, 0x0a, 0xab, 0xca, 0x06, 0x86, 0x19, 0xd1, 0xdc, 0x89, 0x2f, 0x68,
-	0xec, 0x92, 0xff, 0x3d, 0xc4, 0xcf, 0x6c, 0xf1, 0x20, 0x65, 0x86, 0xb6, 0xd5, 0x8d, 0x3d, 0xaa,
-	0xe1, 0x1d, 0xd7, 0x58, 0xb2, 0x60, 0xb2, 0x3c, 0xcc, 0x9e, 0xc0, 0xe3, 0xfc, 0x63, 0xd6, 0x16,
-	0xc5, 0x3f, 0x77, 0xdf, 0xbb, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0xcd, 0x16, 0x0e, 0xd5, 0x71,
-	0x01, 0x00, 0x00,
+	// 1540 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0x6d, 0x6f, 0x1c, 0x49,
+	0x11, 0x66, 0xf6, 0xc5, 0x9e, 0xad, 0x5d, 0xaf, 0xd7, 0x9d, 0x4b, 0x6e, 0xb8, 0x1c, 0xc7, 0x32,
+	0x20, 0xb2, 0x3a, 0x84, 0x05, 0x39, 0xdd, 0x21, 0xf1, 0xcd, 0xc1, 0x26, 0x71, 0x62, 0x27, 0xa7,
+	0x5e, 0xe7, 0x10, 0x08, 0x31, 0x6a, 0xef, 0x54, 0x66, 0x5b, 0x99, 0x9d, 0x1e, 0xba, 0x7b, 0x1d,
+	0xef, 0xfd, 0x00, 0x24, 0x7e, 0x08, 0x3f, 0x01, 0xf1, 0x85, 0x2f, 0x20, 0x7e, 0x0c, 0x3f, 0x03,
+	0xf5, 0xcb, 0xbc, 0xac, 0x7d, 0x70, 0x89, 0xc2, 0xb7, 0xad, 0xa7, 0x6b, 0xaa, 0xaa, 0x9f, 0xea,
+	0x7a, 0x59, 0x18, 0xaf, 0x50, 0xb3, 0x94, 0x69, 0x76, 0x58, 0x4a, 0xa1, 0x05, 0x79, 0xc0, 0xd6,
+	0x87, 0x99, 0xb8, 0x3a, 0x4c, 0x79, 0xc6, 0x35, 0xcb, 0x0f, 0xaf, 0x50, 0xf2, 0x57, 0x9c, 0x5d,
+	0xe6, 0x68, 0x94, 0x94, 0x96, 0xeb, 0x85, 0x5e, 0x4b, 0x54, 0xf1, 0xdf, 0x7b, 0x30, 0x3c, 0x13,
+	0xd9, 0xb9, 0xff, 0x9c, 0xfc, 0x18, 0xf6, 0x4b, 0xc9, 0xaf, 0x98, 0xc6, 0xe4, 0x35, 0x6e, 0x92,
+	0x14, 0x65, 0xd4, 0x99, 0x06, 0xb3, 0x11, 0xdd, 0xf3, 0xf0, 0x33, 0xdc, 0x1c, 0xa3, 0x24, 0x53,
+	0x18, 0xa6, 0xa8, 0x16, 0x92, 0x97, 0x9a, 0x8b, 0x22, 0xea, 0x4e, 0x83, 0xd9, 0x80, 0xb6, 0x21,
+	0xf2, 0x11, 0x84, 0xa2, 0x44, 0xc9, 0xb4, 0x90, 0x51, 0xcf, 0x1e, 0xd7, 0x32, 0x99, 0x40, 0x77,
+	0x2d, 0xf3, 0xa8, 0x6f, 0x61, 0xf3, 0x93, 0x1c, 0xc2, 0x9d, 0x15, 0xbb, 0xe6, 0xab, 0xf5, 0x2a,
+	0x59, 0xa1, 0xcc, 0x30, 0x49, 0x31, 0x67, 0x9b, 0x68, 0x67, 0x1a, 0xcc, 0xba, 0xf4, 0xc0, 0x1f,
+	0x9d, 0x9b, 0x93, 0x63, 0x73, 0x40, 0x1e, 0x43, 0x5f, 0x69, 0xa6, 0x31, 0xda, 0x9d, 0x06, 0xb3,
+	0xf1, 0xc3, 0x9f, 0x1f, 0xbe, 0xe5, 0x85, 0x0f, 0xcf, 0x44, 0x36, 0x37, 0x1f, 0x52, 0xf7, 0x3d,
+	0x89, 0x60, 0x77, 0x21, 0x91, 0x69, 0x4c, 0xa3, 0xd0, 0x3a, 0xab, 0x44, 0xf2, 0x7d, 0x18, 0xda,
+	0x6f, 0x51, 0x27, 0x26, 0xd8, 0x81, 0x0d, 0x16, 0x3c, 0xf4, 0x52, 0xe6, 0xe4, 0x01, 0xec, 0x5b,
+	0x1b, 0x89, 0xe6, 0x2b, 0x54, 0x9a, 0xad, 0xca, 0x08, 0xac, 0x89, 0xb1, 0x85, 0x2f, 0x2a, 0x94,
+	0x5c, 0xc0, 0xe0, 0x15, 0x2f, 0x58, 0x9e, 0x28, 0xbd, 0x8c, 0x86, 0xd3, 0x60, 0x36, 0x7c, 0xf8,
+	0x8b, 0xb7, 0x0e, 0x78, 0xce, 0xb3, 0x02, 0xd3, 0x0b, 0x89, 0xf8, 0x04, 0x59, 0x4a, 0x43, 0x6b,
+	0x69, 0xae, 0x97, 0x26, 0x72, 0x9f, 0x93, 0x68, 0x34, 0x0d, 0x66, 0x21, 0xad, 0x44, 0xf2, 0x03,
+	0x18, 0x2d, 0x84, 0x54, 0x89, 0x90, 0x3c, 0xe3, 0x85, 0x8a, 0xf6, 0xa6, 0x5d, 0x93, 0x1d, 0x83,
+	0xbd, 0x70, 0x90, 0x51, 0xe1, 0x45, 0x8a, 0xd7, 0xc9, 0x2b, 0x8e, 0x79, 0xaa, 0xa2, 0xb1, 0x53,
+	0xb1, 0xd8, 0xaf, 0x2d, 0x44, 0x62, 0xd8, 0x5b, 0xb1, 0xd2, 0x3e, 0x03, 0xab, 0x14, 0xed, 0xbb,
+	0x24, 0xaf, 0x58, 0xf9, 0x0c, 0x37, 0x56, 0x29, 0xfe, 0x4b, 0x00, 0xe3, 0xed, 0x00, 0xc9, 0x7d,
+	0x18, 0x68, 0x89, 0x98, 0x28, 0xfe, 0x35, 0x46, 0x81, 0xe5, 0x23, 0x34, 0xc0, 0x9c, 0x7f, 0x8d,
+	0xe4, 0x63, 0x18, 0x34, 0x64, 0x75, 0xec, 0x61, 0x03, 0x90, 0x19, 0x4c, 0xd4, 0x92, 0x3d, 0xfc,
+	0xfc, 0x8b, 0x44, 0x0a, 0xa1, 0x93, 0x25, 0x53, 0x4b, 0xfb, 0xb2, 0x46, 0x74, 0xec, 0x70, 0x2a,
+	0x84, 0x7e, 0xc2, 0xd4, 0xd2, 0x3c, 0x17, 0xeb, 0x64, 0x89, 0x2c, 0x4d, 0x14, 0xcf, 0x0a, 0x66,
+	0xc8, 0xb2, 0xef, 0x6c, 0x44, 0x0f, 0xb4, 0x8f, 0x65, 0x5e, 0x1d, 0xc4, 0xa7, 0x30, 0x3c, 0x4a,
+	0x53, 0x8a, 0xaa, 0x14, 0x85, 0xba, 0x11, 0x46, 0x70, 0x33, 0x8c, 0x8f, 0x61, 0xd0, 0x98, 0x74,
+	0xaf, 0xbf, 0x01, 0xe2, 0x37, 0xb0, 0x3f, 0xbf, 0x78, 0xf2, 0x58, 0x28, 0xc5, 0x4b, 0x8a, 0x0b,
+	0x21, 0x53, 0xf2, 0x21, 0xec, 0xe6, 0x22, 0x33, 0x4c, 0x59, 0x63, 0x23, 0xba, 0x93, 0x8b, 0xec,
+	0x19, 0x6e, 0xc8, 0x33, 0xe8, 0x29, 0xbd, 0x54, 0x51, 0x67, 0xda, 0x7d, 0x9f, 0x9c, 0x5b, 0x23,
+	0xf1, 0xbf, 0x02, 0x38, 0xa8, 0x3d, 0x9f, 0x5c, 0xf1, 0x14, 0x8b, 0x05, 0x92, 0xbb, 0x60, 0x9c,
+	0x25, 0x3c, 0xf5, 0xae, 0xfb, 0xb9, 0xc8, 0x4e, 0xb7, 0x42, 0xea, 0x6c, 0x85, 0x74, 0x0f, 0x76,
+	0x24, 0x32, 0x55, 0xd7, 0xac, 0x97, 0x6c, 0xb9, 0x5e, 0x2a, 0x94, 0x57, 0x98, 0x5a, 0x1a, 0xbb,
+	0xb4, 0x96, 0xeb, 0x6b, 0xf4, 0xff, 0x1f, 0xd7, 0x10, 0x70, 0xf7, 0xd6, 0x2d, 0xce, 0xb8, 0xd2,
+	0xe4, 0x2b, 0x08, 0xd1, 0xcb, 0x51, 0x60, 0x3d, 0xfd, 0xf2, 0xed, 0x3d, 0xdd, 0xb4, 0x48, 0x6b,
+	0x5b, 0xf1, 0xcf, 0xbe, 0xc9, 0xa1, 0xc8, 0x54, 0xc5, 0x11, 0x4f, 0x95, 0xf5, 0xe7, 0x38, 0x3a,
+	0x4d, 0x55, 0xfc, 0x14, 0xf6, 0xe6, 0x17, 0x4f, 0x4e, 0x4d, 0x2d, 0x9c, 0x14, 0x5a, 0x6e, 0xde,
+	0xe3, 0x4d, 0xc7, 0xac, 0xb1, 0xf5, 0xab, 0xe5, 0xba, 0x78, 0x4d, 0xbe, 0x84, 0x5d, 0x2c, 0xb4,
+	0xe4, 0xa8, 0xfc, 0x2d, 0xbf, 0x78, 0x97, 0x5b, 0x36, 0x41, 0xd1, 0xca, 0x4c, 0xfc, 0xb7, 0x00,
+	0x26, 0xd5, 0x51, 0xdd, 0xc8, 0x3f, 0x80, 0xfe, 0x42, 0xac, 0x0b, 0xed, 0xc3, 0x75, 0x82, 0xa9,
+	0x9b, 0x85, 0x89, 0x22, 0x59, 0xf1, 0x22, 0x69, 0x47, 0xdd, 0x35, 0x6d, 0xd6, 0x1e, 0x9d, 0xf3,
+	0xa2, 0xe9, 0x5c, 0x8d, 0x3e, 0xbb, 0x6e, 0xe9, 0x77, 0xdb, 0xfa, 0xec, 0xba, 0xd1, 0xff, 0xe9,
+	0x96, 0x7e, 0x4d, 0x59, 0xcf, 0xea, 0x4f, 0x6a, 0x7d, 0x4f, 0x5d, 0xfc, 0xa6, 0x21, 0xe7, 0xd1,
+	0x9a, 0xe7, 0x29, 0xf9, 0x14, 0x0e, 0xd4, 0x82, 0x15, 0x05, 0xa6, 0xc9, 0x4d, 0xc2, 0xf7, 0xfd,
+	0x41, 0xf5, 0x31, 0xf9, 0x11, 0x8c, 0x6d, 0xf9, 0x37, 0x8a, 0x8e, 0xfc, 0x91, 0x41, 0x6b, 0xad,
+	0x9a, 0x87, 0x6e, 0x8b, 0x87, 0xf8, 0x1f, 0x01, 0x1c, 0x9c, 0x89, 0xec, 0x98, 0x4b, 0x5c, 0x68,
+	0x21, 0x37, 0x2e, 0xcd, 0x11, 0xec, 0xb2, 0x45, 0xc3, 0xda, 0x80, 0x56, 0x22, 0x21, 0xd0, 0x2b,
+	0xd8, 0xca, 0x79, 0x18, 0x50, 0xfb, 0xdb, 0xf8, 0x2f, 0xd7, 0x97, 0x39, 0x5f, 0xd4, 0x93, 0xb2,
+	0x6f, 0x2b, 0x6d, 0xe4, 0x50, 0x3f, 0x28, 0x3f, 0x82, 0xf0, 0x0d, 0x5e, 0x2e, 0x85, 0x78, 0xad,
+	0xec, 0x34, 0x0b, 0x69, 0x2d, 0x1b, 0x7f, 0xb6, 0xe1, 0x62, 0x6a, 0xc7, 0x58, 0x48, 0x2b, 0xd1,
+	0x54, 0xe9, 0x8a, 0x95, 0xa5, 0x1f, 0x4a, 0x21, 0xf5, 0xd2, 0xd3, 0x5e, 0xd8, 0x9d, 0xf4, 0x9e,
+	0xf6, 0xc2, 0xde, 0xa4, 0x1f, 0xff, 0x01, 0x46, 0xed, 0x2b, 0x90, 0xe7, 0xd0, 0xcb, 0x45, 0xa6,
+	0xde, 0xb9, 0x76, 0x6e, 0xf1, 0x40, 0xad, 0x9d, 0x38, 0x83, 0x83, 0xdf, 0xb8, 0x48, 0xe7, 0xeb,
+	0x4b, 0x33, 0xd7, 0x2f, 0x51, 0x92, 0x31, 0x74, 0x7c, 0xab, 0x19, 0xd0, 0x0e, 0x4f, 0xab, 0x49,
+	0xde, 0x69, 0x26, 0xf9, 0x3d, 0xd8, 0x51, 0xb8, 0x90, 0xa8, 0x7d, 0xeb, 0xf6, 0x52, 0x7b, 0xd0,
+	0xf6, 0xb6, 0x06, 0x6d, 0xfc, 0xd7, 0x0e, 0xec, 0x7b, 0x4f, 0xc7, 0x98, 0xf3, 0x2b, 0x94, 0x9b,
+	0x96, 0x9f, 0xae, 0xf5, 0xf3, 0x43, 0xd8, 0x53, 0x75, 0x14, 0xa6, 0xdb, 0x39, 0x8f, 0xa3, 0x06,
+	0x3c, 0x4d, 0xed, 0x44, 0x64, 0x9b, 0x5c, 0xb0, 0xd4, 0xfb, 0xae, 0xc4, 0xff, 0xee, 0xdc, 0xe4,
+	0x87, 0x69, 0x8d, 0xab, 0x52, 0x2b, 0x9b, 0xbf, 0x3e, 0xad, 0x65, 0x33, 0x24, 0x0b, 0xbc, 0xd6,
+	0x89, 0x07, 0xfc, 0x36, 0x32, 0x34, 0xd8, 0x91, 0x83, 0xcc, 0x92, 0x90, 0x33, 0xa5, 0x13, 0x33,
+	0xf1, 0xd7, 0xca, 0xa6, 0xb1, 0x4f, 0xc1, 0x40, 0x73, 0x8b, 0x90, 0xef, 0x81, 0x95, 0x12, 0x94,
+	0x52, 0x48, 0x9b, 0xcd, 0x01, 0x1d, 0x18, 0xe4, 0xc4, 0x00, 0xa6, 0x79, 0x2c, 0xc4, 0xaa, 0xcc,
+	0xd1, 0x84, 0x36, 0x70, 0xcd, 0xa3, 0x06, 0xcc, 0x69, 0xea, 0x18, 0xc1, 0xd4, 0xee, 0x16, 0x21,
+	0x6d, 0x80, 0xf8, 0x4f, 0x1d, 0x18, 0x55, 0x19, 0xb2, 0xbb, 0xcc, 0xef, 0x61, 0xd8, 0xf0, 0xf1,
+	0xee, 0x0f, 0xe1, 0x56, 0xb6, 0x69, 0xdb, 0x9c, 0x99, 0xce, 0x7f, 0x5c, 0xe3, 0x1a, 0x6f, 0x57,
+	0xdc, 0xd8, 0xe1, 0x75, 0xcd, 0xcd, 0x60, 0x62, 0x79, 0xf3, 0xa1, 0x6e, 0x12, 0xee, 0x12, 0xd2,
+	0xa5, 0x63, 0x83, 0x57, 0x49, 0x3e, 0xb5, 0x3b, 0x56, 0x89, 0x45, 0xca, 0x0b, 0xd7, 0x86, 0x5d,
+	0x9f, 0x00, 0x0f, 0x9d, 0xa6, 0xca, 0xe4, 0xbd, 0xa6, 0xc3, 0xaa, 0xf4, 0xad, 0xca, 0xa8, 0x06,
+	0x4d, 0xbf, 0x2e, 0x00, 0xec, 0x3a, 0x62, 0x1b, 0x89, 0xa9, 0x78, 0xb7, 0xaf, 0xb8, 0x57, 0xea,
+	0x04, 0xf2, 0x09, 0x40, 0x86, 0x05, 0x4a, 0x66, 0xf7, 0x55, 0x17, 0x77, 0x0b, 0x31, 0x9d, 0xc7,
+	0x17, 0x5f, 0xeb, 0x7a, 0x2e, 0xe8, 0x7d, 0x7f, 0x50, 0xb7, 0xad, 0x3f, 0x07, 0xb0, 0xdf, 0x38,
+	0x74, 0xdc, 0x9f, 0x57, 0xb5, 0x5c, 0xf1, 0xfe, 0xd9, 0x5b, 0xf3, 0xde, 0x98, 0xaa, 0x1a, 0x80,
+	0x32, 0xbb, 0xa5, 0xa5, 0xf0, 0x56, 0xcc, 0x96, 0xc1, 0xc7, 0x35, 0x1a, 0x3f, 0x68, 0x87, 0xf2,
+	0x15, 0xcb, 0xd7, 0xf8, 0xcd, 0xad, 0x3f, 0xfe, 0x49, 0x5b, 0xd1, 0x8d, 0x22, 0xd7, 0x7f, 0xf8,
+	0xc2, 0xc7, 0xdc, 0xa5, 0x95, 0x18, 0xff, 0x3b, 0x80, 0x3d, 0x37, 0xbd, 0xcf, 0x59, 0xf9, 0xed,
+	0x6b, 0x5d, 0x0c, 0x7b, 0x66, 0x92, 0x36, 0x5b, 0x9b, 0xdb, 0x39, 0x86, 0xb9, 0xc8, 0xea, 0x95,
+	0xed, 0x3e, 0x0c, 0x9a, 0x55, 0xd2, 0xed, 0x1e, 0xe1, 0x6b, 0xbf, 0x47, 0x92, 0xef, 0x42, 0x68,
+	0x76, 0x4d, 0x3f, 0x2c, 0x6c, 0x81, 0xae, 0x58, 0x59, 0xd9, 0x36, 0x47, 0x8d, 0x6d, 0xd7, 0x65,
+	0xcd, 0x1a, 0x5a, 0xdb, 0xde, 0x1a, 0xc1, 0x3b, 0xff, 0x73, 0x9f, 0xdb, 0xbd, 0xb9, 0xcf, 0xfd,
+	0x33, 0x80, 0xf0, 0x9c, 0x95, 0x2e, 0x8b, 0x5b, 0x41, 0x06, 0x37, 0x82, 0x9c, 0xc1, 0xc4, 0xb5,
+	0xe1, 0xdb, 0x05, 0xe0, 0xf0, 0xba, 0x00, 0x4c, 0x57, 0x40, 0xa6, 0x30, 0x59, 0x17, 0x9a, 0xe7,
+	0xfe, 0x19, 0x81, 0x85, 0x5e, 0x1a, 0x84, 0x3c, 0x87, 0x9d, 0x9c, 0x69, 0x54, 0xda, 0xde, 0xf6,
+	0x9d, 0x76, 0x80, 0x76, 0x56, 0xa8, 0xb7, 0x12, 0x7f, 0x0e, 0x77, 0x6c, 0xeb, 0x36, 0x93, 0xb8,
+	0x95, 0xe0, 0x4f, 0x00, 0x6a, 0x1a, 0xaa, 0x1c, 0xb7, 0x90, 0x78, 0x09, 0xf7, 0xb6, 0x3f, 0xfb,
+	0x96, 0xf5, 0xe1, 0x3e, 0x0c, 0xea, 0xf5, 0xc1, 0x2f, 0x0d, 0x61, 0xb5, 0x34, 0xb4, 0x0e, 0xd9,
+	0x75, 0xd4, 0x6d, 0x1f, 0xb2, 0xeb, 0x4f, 0xbf, 0x84, 0xb0, 0xfa, 0xe7, 0x45, 0x3e, 0x80, 0xc9,
+	0xd9, 0x8b, 0xc7, 0xc9, 0xfc, 0xe2, 0xe8, 0xe2, 0x24, 0x79, 0x39, 0x3f, 0x7a, 0x74, 0x76, 0x32,
+	0xf9, 0x0e, 0xf9, 0x10, 0xee, 0x34, 0x28, 0x3d, 0x39, 0x3a, 0x4e, 0x5e, 0x3c, 0x3f, 0xfb, 0xed,
+	0x24, 0x20, 0x77, 0xe1, 0xa0, 0x7d, 0x70, 0x71, 0x4a, 0x4f, 0x8e, 0x27, 0x9d, 0x47, 0xbd, 0xdf,
+	0x75, 0xca, 0xcb, 0xcb, 0x1d, 0xfb, 0x7f, 0xf7, 0xb3, 0xff, 0x0c, 0x00, 0x16, 0x5b, 0x37, 0x6f,
+	0x01, 0x0f, 0x00, 0x00,
 }